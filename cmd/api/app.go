@@ -1,12 +1,16 @@
 package main
 
 import (
+	"sync"
+
 	"github.com/askme/api/internal/chat"
+	"github.com/askme/api/internal/config"
 	"github.com/askme/api/internal/feed"
 	"github.com/askme/api/internal/post"
 	"github.com/askme/api/internal/tag"
 	"github.com/askme/api/internal/user"
 	"github.com/askme/api/pkg/arango"
+	"github.com/askme/api/pkg/events"
 )
 
 // App holds all feature module handlers (using interfaces for easy framework switching)
@@ -16,33 +20,60 @@ type App struct {
 	chatHandler chat.Handler
 	feedHandler feed.Handler
 	tagHandler  tag.Handler
+
+	// eventBus carries chat events to open event streams
+	eventBus *events.Bus
+
+	// spec is the OpenAPI document, built on first request
+	specOnce sync.Once
+	spec     []byte
+	specErr  error
+}
+
+// Repositories holds the data access layer of every feature module, so the
+// app can run on ArangoDB or, in tests, on in-memory repositories
+type Repositories struct {
+	User user.Repository
+	Tag  tag.Repository
+	Chat chat.Repository
+	Post post.Repository
+	Feed feed.Repository
+}
+
+// NewRepositories creates the ArangoDB-backed repositories
+func NewRepositories(db *arango.Client) Repositories {
+	return Repositories{
+		User: user.NewRepository(db),
+		Tag:  tag.NewRepository(db),
+		Chat: chat.NewRepository(db),
+		Post: post.NewRepository(db),
+		Feed: feed.NewRepository(db),
+	}
 }
 
 // NewApp initializes all feature modules with dependency injection
-func NewApp(db *arango.Client) *App {
+func NewApp(repos Repositories, cfg *config.Config) *App {
+	// In-process event bus for realtime chat updates
+	eventBus := events.NewBus()
+
 	// User feature
-	userRepo := user.NewRepository(db)
-	userService := user.NewService(userRepo)
+	userService := user.NewService(repos.User)
 	userHandler := user.NewHandler(userService)
 
 	// Tag feature
-	tagRepo := tag.NewRepository(db)
-	tagService := tag.NewService(tagRepo)
+	tagService := tag.NewService(repos.Tag)
 	tagHandler := tag.NewHandler(tagService)
 
 	// Chat feature (needed by post service)
-	chatRepo := chat.NewRepository(db)
-	chatService := chat.NewService(chatRepo)
+	chatService := chat.NewService(repos.Chat, eventBus)
 	chatHandler := chat.NewHandler(chatService)
 
 	// Post feature (depends on tag and chat services)
-	postRepo := post.NewRepository(db)
-	postService := post.NewService(postRepo, tagService, chatService)
+	postService := post.NewService(repos.Post, tagService, chatService)
 	postHandler := post.NewHandler(postService)
 
 	// Feed feature (depends on post and chat repos for aggregation)
-	feedRepo := feed.NewRepository(db)
-	feedService := feed.NewService(feedRepo, postRepo, chatRepo)
+	feedService := feed.NewService(repos.Feed, repos.Post, repos.Chat, feed.Weights(cfg.Feed.Weights))
 	feedHandler := feed.NewHandler(feedService)
 
 	return &App{
@@ -51,5 +82,12 @@ func NewApp(db *arango.Client) *App {
 		chatHandler: chatHandler,
 		feedHandler: feedHandler,
 		tagHandler:  tagHandler,
+		eventBus:    eventBus,
 	}
 }
+
+// Close ends open event streams, which would otherwise hold up a graceful
+// shutdown until its timeout
+func (a *App) Close() {
+	a.eventBus.Close()
+}
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/askme/api/internal/config"
+	"github.com/askme/api/internal/domain"
+	"github.com/askme/api/internal/memrepo"
+	"github.com/askme/api/internal/post"
+	"github.com/askme/api/internal/user"
+	"github.com/askme/api/pkg/httputil"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata/contract")
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// contractStep is one request of the contract scenario. Steps run in order
+// against a single app, so later steps can use keys saved by earlier ones
+// through {{name}} placeholders in the path and body.
+type contractStep struct {
+	name   string
+	user   string
+	method string
+	path   string
+	body   string
+	// key is sent as the Idempotency-Key header
+	key    string
+	status int
+	// save maps a placeholder name to a dot path into the response data
+	save map[string]string
+	// raw responses have no envelope and no golden file
+	raw bool
+}
+
+var contractSteps = []contractStep{
+	// Users
+	{name: "create_user", method: "POST", path: "/users", body: `{"username":"carol","interests":["books"]}`, status: http.StatusCreated},
+	{name: "create_user_invalid_body", method: "POST", path: "/users", body: `{"username":`, status: http.StatusBadRequest},
+	{name: "create_user_missing_username", method: "POST", path: "/users", body: `{}`, status: http.StatusBadRequest},
+	{name: "create_user_unknown_field", method: "POST", path: "/users", body: `{"username":"dave","nickname":"d"}`, status: http.StatusBadRequest},
+	{name: "create_user_wrong_type", method: "POST", path: "/users", body: `{"username":"dave","interests":"books"}`, status: http.StatusBadRequest},
+	{name: "create_user_trailing_data", method: "POST", path: "/users", body: `{"username":"dave"}{"username":"eve"}`, status: http.StatusBadRequest},
+	{name: "get_user", method: "GET", path: "/users/u-alice", status: http.StatusOK},
+	{name: "get_user_not_found", method: "GET", path: "/users/u-nobody", status: http.StatusNotFound},
+	{name: "follow_user", user: "u-bob", method: "POST", path: "/me/follow/u-alice", status: http.StatusOK},
+	{name: "follow_user_again", user: "u-bob", method: "POST", path: "/me/follow/u-alice", status: http.StatusConflict},
+
+	// Posts
+	{name: "create_post", user: "u-alice", method: "POST", path: "/posts", status: http.StatusCreated,
+		body: `{"text":"How do I switch careers into tech?","aiRaw":{"category":"career","intent":"advice","depth":"serious","tags":["career change"],"confidence":0.9}}`,
+		save: map[string]string{"post": "_key"}},
+	{name: "create_post_missing_text", user: "u-alice", method: "POST", path: "/posts", body: `{"text":""}`, status: http.StatusBadRequest},
+	{name: "create_post_invalid_fields", user: "u-alice", method: "POST", path: "/posts", status: http.StatusBadRequest,
+		body: `{"text":"` + strings.Repeat("a", 2001) + `","resultsVisibility":"never"}`},
+	{name: "get_post", method: "GET", path: "/posts/{{post}}", status: http.StatusOK},
+	{name: "get_post_not_found", method: "GET", path: "/posts/missing", status: http.StatusNotFound},
+	{name: "update_post", user: "u-alice", method: "PATCH", path: "/posts/{{post}}", status: http.StatusOK,
+		body: `{"text":"How do I switch careers into backend development?","aiRaw":{"category":"tech","intent":"advice","tags":["backend dev"]}}`},
+	{name: "update_post_forbidden", user: "u-bob", method: "PATCH", path: "/posts/{{post}}", body: `{"text":"Hijacked"}`, status: http.StatusForbidden},
+	{name: "update_post_missing_text", user: "u-alice", method: "PATCH", path: "/posts/{{post}}", body: `{}`, status: http.StatusBadRequest},
+
+	// Polls
+	{name: "create_poll", user: "u-alice", method: "POST", path: "/posts/poll", status: http.StatusCreated,
+		body: `{"text":"Tabs or spaces?","pollOptions":["Tabs","Spaces"],"resultsVisibility":"after_vote","aiRaw":{"category":"tech","tags":["code style"]}}`,
+		save: map[string]string{"poll": "_key"}},
+	{name: "create_poll_too_few_options", user: "u-alice", method: "POST", path: "/posts/poll", body: `{"text":"Yes?","pollOptions":["Yes"]}`, status: http.StatusBadRequest},
+	{name: "create_poll_duplicate_options", user: "u-alice", method: "POST", path: "/posts/poll", body: `{"text":"Pick","pollOptions":["Dark","dark"]}`, status: http.StatusBadRequest},
+	{name: "get_poll_before_vote", user: "u-bob", method: "GET", path: "/posts/{{poll}}", status: http.StatusOK},
+	{name: "vote", user: "u-bob", method: "POST", path: "/posts/{{poll}}/vote", body: `{"optionId":"o2"}`, status: http.StatusOK},
+	{name: "vote_missing_option", user: "u-bob", method: "POST", path: "/posts/{{poll}}/vote", body: `{}`, status: http.StatusBadRequest},
+	{name: "vote_invalid_option", user: "u-bob", method: "POST", path: "/posts/{{poll}}/vote", body: `{"optionId":"o9"}`, status: http.StatusBadRequest},
+	{name: "vote_not_a_poll", user: "u-bob", method: "POST", path: "/posts/{{post}}/vote", body: `{"optionId":"o1"}`, status: http.StatusBadRequest},
+	{name: "vote_closed_poll", user: "u-bob", method: "POST", path: "/posts/p-closed/vote", body: `{"optionId":"o1"}`, status: http.StatusConflict},
+	{name: "retract_vote", user: "u-bob", method: "DELETE", path: "/posts/{{poll}}/vote", status: http.StatusOK},
+	{name: "retract_vote_again", user: "u-bob", method: "DELETE", path: "/posts/{{poll}}/vote", status: http.StatusNotFound},
+
+	// Responding starts a chat
+	{name: "respond", user: "u-bob", method: "POST", path: "/posts/{{post}}/respond", body: `{"text":"Start with a bootcamp"}`, status: http.StatusCreated,
+		save: map[string]string{"chat": "chatId", "message": "messageId"}},
+	{name: "respond_again", user: "u-bob", method: "POST", path: "/posts/{{post}}/respond", body: `{"text":"Again"}`, status: http.StatusConflict},
+	{name: "respond_own_post", user: "u-alice", method: "POST", path: "/posts/{{post}}/respond", body: `{"text":"Bump"}`, status: http.StatusBadRequest},
+
+	// Chats
+	{name: "send_message", user: "u-alice", method: "POST", path: "/chats/{{chat}}/message", key: "msg-1", status: http.StatusCreated,
+		body: `{"text":"Which bootcamp?","replyToId":"{{message}}"}`,
+		save: map[string]string{"reply": "messageId"}},
+	{name: "send_message_retry", user: "u-alice", method: "POST", path: "/chats/{{chat}}/message", key: "msg-1", status: http.StatusCreated,
+		body: `{"text":"Which bootcamp?","replyToId":"{{message}}"}`},
+	{name: "send_message_key_reused", user: "u-alice", method: "POST", path: "/chats/{{chat}}/message", key: "msg-1", body: `{"text":"Other"}`, status: http.StatusConflict},
+	{name: "send_message_forbidden", user: "u-johndoe", method: "POST", path: "/chats/{{chat}}/message", body: `{"text":"Hi"}`, status: http.StatusForbidden},
+	{name: "send_message_missing_chat", user: "u-alice", method: "POST", path: "/chats/missing/message", body: `{"text":"Hi"}`, status: http.StatusNotFound},
+	{name: "get_chat", user: "u-bob", method: "GET", path: "/chats/{{chat}}", status: http.StatusOK},
+	{name: "accept_chat_already_active", user: "u-bob", method: "POST", path: "/chats/{{chat}}/accept", status: http.StatusBadRequest},
+	{name: "mute_chat", user: "u-bob", method: "POST", path: "/chats/{{chat}}/mute", status: http.StatusOK},
+	{name: "get_participants", method: "GET", path: "/chats/{{chat}}/participants", status: http.StatusOK},
+	{name: "chat_events_forbidden", user: "u-johndoe", method: "GET", path: "/chats/{{chat}}/events", status: http.StatusForbidden},
+	{name: "chat_events_missing_chat", user: "u-alice", method: "GET", path: "/chats/missing/events", status: http.StatusNotFound},
+
+	// Messages
+	{name: "edit_message", user: "u-alice", method: "PATCH", path: "/messages/{{reply}}", body: `{"text":"Which bootcamp would you pick?"}`, status: http.StatusOK},
+	{name: "edit_message_forbidden", user: "u-bob", method: "PATCH", path: "/messages/{{reply}}", body: `{"text":"Mine"}`, status: http.StatusForbidden},
+	{name: "react", user: "u-bob", method: "POST", path: "/messages/{{reply}}/react", body: `{"emoji":"👍"}`, status: http.StatusOK},
+	{name: "react_unsupported", user: "u-bob", method: "POST", path: "/messages/{{reply}}/react", body: `{"emoji":"🦄"}`, status: http.StatusBadRequest},
+	{name: "get_reactions", method: "GET", path: "/messages/{{reply}}/reactions", status: http.StatusOK},
+	{name: "remove_reaction", user: "u-bob", method: "POST", path: "/messages/{{reply}}/react", body: `{"emoji":""}`, status: http.StatusOK},
+	{name: "get_reactions_after_remove", method: "GET", path: "/messages/{{reply}}/reactions", status: http.StatusOK},
+	{name: "get_replies", method: "GET", path: "/messages/{{message}}/replies", status: http.StatusOK},
+	{name: "delete_message", user: "u-alice", method: "DELETE", path: "/messages/{{reply}}", status: http.StatusOK},
+	{name: "delete_message_again", user: "u-alice", method: "DELETE", path: "/messages/{{reply}}", status: http.StatusNotFound},
+	{name: "get_user_chats", user: "u-bob", method: "GET", path: "/me/chats", status: http.StatusOK},
+
+	// Feed
+	{name: "get_feed", user: "u-bob", method: "GET", path: "/me/feed?limit=10", status: http.StatusOK},
+	{name: "get_feed_category", user: "u-bob", method: "GET", path: "/me/feed?category=career", status: http.StatusOK},
+
+	// Tags
+	{name: "get_tag", method: "GET", path: "/tags/backend-dev", status: http.StatusOK},
+	{name: "get_tag_not_found", method: "GET", path: "/tags/missing", status: http.StatusNotFound},
+	{name: "list_tags", method: "GET", path: "/tags?limit=10", status: http.StatusOK},
+	{name: "search_tags", method: "GET", path: "/tags?q=career", status: http.StatusOK},
+
+	// API description
+	{name: "openapi", method: "GET", path: "/openapi.json", status: http.StatusOK, raw: true},
+
+	// Deleting a post hides it
+	{name: "delete_post_forbidden", user: "u-bob", method: "DELETE", path: "/posts/{{post}}", status: http.StatusForbidden},
+	{name: "delete_post", user: "u-alice", method: "DELETE", path: "/posts/{{post}}", status: http.StatusOK},
+	{name: "get_deleted_post", method: "GET", path: "/posts/{{post}}", status: http.StatusNotFound},
+}
+
+// newContractApp builds the full handler chain over in-memory repositories
+// seeded with three users and a closed poll
+func newContractApp(t *testing.T) *App {
+	t.Helper()
+	ctx := context.Background()
+	store := memrepo.NewStore()
+
+	users := memrepo.NewUserRepository(store)
+	for _, u := range []user.User{
+		{Key: "u-johndoe", Username: "johndoe"},
+		{Key: "u-alice", Username: "alice", Interests: []string{"tech"}, Settings: user.UserSettings{AllowDMs: true}},
+		{Key: "u-bob", Username: "bob"},
+	} {
+		if _, err := users.Create(ctx, &u); err != nil {
+			t.Fatalf("seed user %s: %v", u.Key, err)
+		}
+	}
+
+	posts := memrepo.NewPostRepository(store)
+	closed := &post.Post{
+		Key:         "p-closed",
+		AuthorID:    "u-alice",
+		PostType:    domain.PostTypePoll,
+		Text:        "Closed poll",
+		PollOptions: []post.PollOption{{ID: "o1", Text: "Yes"}, {ID: "o2", Text: "No"}},
+		ClosesAt:    time.Now().Add(-time.Hour).UnixMilli(),
+		CreatedAt:   time.Now().Add(-2 * time.Hour).UnixMilli(),
+	}
+	if _, err := posts.Create(ctx, closed); err != nil {
+		t.Fatalf("seed closed poll: %v", err)
+	}
+	if err := posts.CreateCreatedEdge(ctx, closed.AuthorID, closed.Key, closed.CreatedAt); err != nil {
+		t.Fatalf("seed created edge: %v", err)
+	}
+
+	return NewApp(Repositories{
+		User: users,
+		Tag:  memrepo.NewTagRepository(store),
+		Chat: memrepo.NewChatRepository(store),
+		Post: posts,
+		Feed: memrepo.NewFeedRepository(store),
+	}, config.Default())
+}
+
+func TestContract(t *testing.T) {
+	app := newContractApp(t)
+	handler := newHandler(app, &config.Config{RateLimit: config.DefaultRateLimits(), IdempotencyTTL: time.Hour}, NewHealth(nil, nil))
+	vars := map[string]string{}
+	covered := map[string]bool{}
+
+	// A bare mux resolves each request to the route pattern it exercises
+	routes := http.NewServeMux()
+	app.RegisterRoutes(routes)
+
+	for _, step := range contractSteps {
+		t.Run(step.name, func(t *testing.T) {
+			path, body := expand(t, step.path, vars), expand(t, step.body, vars)
+			req := httptest.NewRequest(step.method, path, strings.NewReader(body))
+			if body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if step.user != "" {
+				req.Header.Set("X-User-ID", step.user)
+			}
+			if step.key != "" {
+				req.Header.Set("Idempotency-Key", step.key)
+			}
+			if _, pattern := routes.Handler(req); pattern != "" {
+				covered[pattern] = true
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != step.status {
+				t.Fatalf("status = %d, want %d; body: %s", rec.Code, step.status, rec.Body)
+			}
+			wantType := "application/json"
+			if rec.Code >= 400 {
+				wantType = httputil.ProblemContentType
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != wantType {
+				t.Errorf("Content-Type = %q, want %s", ct, wantType)
+			}
+			requestID := rec.Header().Get("X-Request-ID")
+			if requestID == "" {
+				t.Error("missing X-Request-ID header")
+			}
+
+			if step.raw {
+				return
+			}
+
+			envelope := checkEnvelope(t, rec.Code, rec.Body.Bytes())
+			if rec.Code >= 400 && envelope["requestId"] != requestID {
+				t.Errorf("requestId = %v, want the X-Request-ID header %s", envelope["requestId"], requestID)
+			}
+			for name, field := range step.save {
+				vars[name] = lookup(t, envelope["data"], field)
+			}
+
+			compareGolden(t, step.name, rec.Body.Bytes())
+		})
+	}
+
+	for _, route := range app.Routes() {
+		if !covered[route.Pattern] {
+			t.Errorf("route %q has no contract step", route.Pattern)
+		}
+	}
+}
+
+var placeholder = regexp.MustCompile(`\{\{(\w+)\}\}`)
+
+// expand replaces {{name}} placeholders with keys saved by earlier steps
+func expand(t *testing.T, s string, vars map[string]string) string {
+	t.Helper()
+	return placeholder.ReplaceAllStringFunc(s, func(m string) string {
+		name := placeholder.FindStringSubmatch(m)[1]
+		v, ok := vars[name]
+		if !ok {
+			t.Fatalf("placeholder %s is not saved by an earlier step", m)
+		}
+		return v
+	})
+}
+
+// checkEnvelope asserts the httputil.Response shape: success mirrors the
+// status class, successes carry data and failures are RFC 9457 problems
+func checkEnvelope(t *testing.T, status int, body []byte) map[string]any {
+	t.Helper()
+	var envelope map[string]any
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		t.Fatalf("body is not a JSON object: %v; body: %s", err, body)
+	}
+
+	wantSuccess := status >= 200 && status < 300
+	if envelope["success"] != wantSuccess {
+		t.Errorf("success = %v, want %v", envelope["success"], wantSuccess)
+	}
+	if wantSuccess {
+		if _, ok := envelope["data"]; !ok {
+			t.Error("successful response has no data")
+		}
+		if _, ok := envelope["error"]; ok {
+			t.Error("successful response has an error")
+		}
+	} else {
+		// Errors are RFC 9457 problems
+		if envelope["status"] != float64(status) {
+			t.Errorf("problem status = %v, want %d", envelope["status"], status)
+		}
+		code, _ := envelope["code"].(string)
+		if code == "" || envelope["type"] != httputil.ProblemTypeBase+code {
+			t.Errorf("problem code %q does not match type %v", code, envelope["type"])
+		}
+		if title, _ := envelope["title"].(string); title == "" {
+			t.Error("problem has no title")
+		}
+		if _, ok := envelope["data"]; ok {
+			t.Error("error response has data")
+		}
+	}
+	return envelope
+}
+
+// lookup follows a dot path into decoded JSON and returns the string there
+func lookup(t *testing.T, v any, path string) string {
+	t.Helper()
+	for _, field := range strings.Split(path, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			t.Fatalf("cannot read %q from %v", field, v)
+		}
+		v = obj[field]
+	}
+	s, ok := v.(string)
+	if !ok {
+		t.Fatalf("%s = %v, want a string", path, v)
+	}
+	return s
+}
+
+// volatileFields hold wall-clock values that differ between runs
+var volatileFields = map[string]bool{
+	"requestId":     true,
+	"createdAt":     true,
+	"updatedAt":     true,
+	"editedAt":      true,
+	"deletedAt":     true,
+	"joinedAt":      true,
+	"closesAt":      true,
+	"formattedTime": true,
+	"nextCursor":    true,
+}
+
+// normalize replaces volatile values so bodies can be compared byte for byte
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if volatileFields[k] && child != nil {
+				v[k] = "<" + k + ">"
+				continue
+			}
+			v[k] = normalize(child)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = normalize(child)
+		}
+	}
+	return v
+}
+
+func compareGolden(t *testing.T, name string, body []byte) {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(normalize(v)); err != nil {
+		t.Fatalf("encode body: %v", err)
+	}
+	got := buf.Bytes()
+
+	path := filepath.Join("testdata", "contract", name+".json")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create golden dir: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("write golden: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden (run go test ./cmd/api -run TestContract -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("body does not match %s\n--- got\n%s\n--- want\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/askme/api/internal/config"
+)
+
+func TestChatEventStream(t *testing.T) {
+	app := newContractApp(t)
+	server := httptest.NewServer(newHandler(app, config.Default(), NewHealth(nil, nil)))
+	defer server.Close()
+
+	do := func(method, path, userID, body string) map[string]any {
+		t.Helper()
+		req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		req.Header.Set("X-User-ID", userID)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var envelope struct {
+			Data map[string]any `json:"data"`
+		}
+		json.NewDecoder(resp.Body).Decode(&envelope)
+		return envelope.Data
+	}
+
+	created := do("POST", "/posts", "u-alice", `{"text":"Best coffee in town?"}`)
+	responded := do("POST", "/posts/"+created["_key"].(string)+"/respond", "u-bob", `{"text":"Try the place on 5th"}`)
+	chatID, messageID := responded["chatId"].(string), responded["messageId"].(string)
+
+	req, _ := http.NewRequest("GET", server.URL+"/chats/"+chatID+"/events", nil)
+	req.Header.Set("X-User-ID", "u-alice")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("stream = %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	// The headers are flushed once the subscription exists, so the edit
+	// cannot race ahead of it
+	do("PATCH", "/messages/"+messageID, "u-bob", `{"text":"Try the place on 6th"}`)
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	var got []string
+	for len(got) < 2 {
+		select {
+		case line := <-lines:
+			if line != "" {
+				got = append(got, line)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no event, got %q", got)
+		}
+	}
+	if got[0] != "event: message.edited" || !strings.Contains(got[1], `"text":"Try the place on 6th"`) {
+		t.Errorf("event = %q", got)
+	}
+
+	// Closing the app ends the stream, as on shutdown
+	app.Close()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case _, ok := <-lines:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("stream still open after Close")
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"github.com/askme/api/pkg/arango/migrate"
+	"github.com/askme/api/pkg/httputil"
+)
+
+// Build information, set at build time:
+//
+//	go build -ldflags "-X main.commit=$(git rev-parse HEAD) -X main.buildTime=$(date -u +%FT%TZ)" ./cmd/api
+//
+// Without ldflags the VCS stamp of `go build` is used, if any.
+var (
+	commit    string
+	buildTime string
+)
+
+// readinessTimeout bounds each readiness check, so a hung database fails
+// the probe instead of stalling it
+const readinessTimeout = 2 * time.Second
+
+// pinger checks that a dependency is reachable
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// migrationStatus reports which schema migrations are applied
+type migrationStatus interface {
+	Status(ctx context.Context) ([]migrate.Status, error)
+}
+
+// Health serves the liveness, readiness and version endpoints
+type Health struct {
+	db         pinger
+	migrations migrationStatus
+	draining   atomic.Bool
+}
+
+// NewHealth creates the probes checking db and migrations; nil ones are
+// skipped
+func NewHealth(db pinger, migrations migrationStatus) *Health {
+	return &Health{db: db, migrations: migrations}
+}
+
+// Drain makes readiness fail from now on, so load balancers stop sending
+// requests before the server shuts down
+func (h *Health) Drain() {
+	h.draining.Store(true)
+}
+
+// Check is the outcome of one readiness check
+type Check struct {
+	Status     string  `json:"status"`
+	DurationMs float64 `json:"durationMs,omitempty"`
+	Error      string  `json:"error,omitempty"`
+	Applied    int     `json:"applied,omitempty"`
+	Pending    []int   `json:"pending,omitempty"`
+}
+
+// Readiness is the body of /readyz
+type Readiness struct {
+	Status string           `json:"status"`
+	Checks map[string]Check `json:"checks"`
+}
+
+// BuildInfo is the body of /version
+type BuildInfo struct {
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+	GoVersion string `json:"goVersion"`
+}
+
+// Live handles GET /healthz: the process is up and serving
+func (h *Health) Live(w http.ResponseWriter, r *http.Request) {
+	httputil.JSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Ready handles GET /readyz: the database answers within readinessTimeout,
+// every migration is applied and the server is not shutting down
+func (h *Health) Ready(w http.ResponseWriter, r *http.Request) {
+	resp := Readiness{Status: "ready", Checks: map[string]Check{}}
+
+	if h.db != nil {
+		resp.Checks["arangodb"] = h.check(r.Context(), func(ctx context.Context, c *Check) error {
+			return h.db.Ping(ctx)
+		})
+	}
+	if h.migrations != nil {
+		resp.Checks["migrations"] = h.check(r.Context(), func(ctx context.Context, c *Check) error {
+			statuses, err := h.migrations.Status(ctx)
+			if err != nil {
+				return err
+			}
+			for _, s := range statuses {
+				if s.Applied {
+					c.Applied++
+				} else {
+					c.Pending = append(c.Pending, s.Version)
+				}
+			}
+			if len(c.Pending) > 0 {
+				c.Status = "pending"
+			}
+			return nil
+		})
+	}
+
+	status := http.StatusOK
+	for _, c := range resp.Checks {
+		if c.Status != "ok" {
+			resp.Status = "not_ready"
+			status = http.StatusServiceUnavailable
+		}
+	}
+	if h.draining.Load() {
+		resp.Status = "draining"
+		status = http.StatusServiceUnavailable
+	}
+	httputil.JSON(w, status, resp)
+}
+
+// check runs fn with readinessTimeout and times it
+func (h *Health) check(ctx context.Context, fn func(ctx context.Context, c *Check) error) Check {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	c := Check{Status: "ok"}
+	start := time.Now()
+	if err := fn(ctx, &c); err != nil {
+		c.Status = "error"
+		c.Error = err.Error()
+	}
+	c.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+	return c
+}
+
+// Version handles GET /version
+func (h *Health) Version(w http.ResponseWriter, r *http.Request) {
+	httputil.JSON(w, http.StatusOK, buildInfo())
+}
+
+// buildInfo returns the ldflags values, falling back to the VCS stamp
+func buildInfo() BuildInfo {
+	info := BuildInfo{Commit: commit, BuildTime: buildTime, GoVersion: runtime.Version()}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.BuildTime == "":
+				info.BuildTime = s.Value
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = "unknown"
+	}
+	return info
+}
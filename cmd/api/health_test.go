@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/askme/api/internal/config"
+	"github.com/askme/api/pkg/arango/migrate"
+)
+
+type fakeDB struct{ err error }
+
+func (f fakeDB) Ping(context.Context) error {
+	return f.err
+}
+
+type fakeMigrations []migrate.Status
+
+func (f fakeMigrations) Status(context.Context) ([]migrate.Status, error) {
+	return f, nil
+}
+
+func TestReadiness(t *testing.T) {
+	applied := fakeMigrations{{Version: 1, Applied: true}, {Version: 2, Applied: true}}
+	tests := []struct {
+		name   string
+		health *Health
+		drain  bool
+		status int
+		want   string
+	}{
+		{"ready", NewHealth(fakeDB{}, applied), false, 200, "ready"},
+		{"database down", NewHealth(fakeDB{err: errors.New("connection refused")}, applied), false, 503, "not_ready"},
+		{"pending migration", NewHealth(fakeDB{}, fakeMigrations{{Version: 1, Applied: true}, {Version: 2}}), false, 503, "not_ready"},
+		{"draining", NewHealth(fakeDB{}, applied), true, 503, "draining"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.drain {
+				tt.health.Drain()
+			}
+			handler := newHandler(NewApp(Repositories{}, config.Default()), &config.Config{}, tt.health)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+
+			var body struct {
+				Data Readiness `json:"data"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if rec.Code != tt.status || body.Data.Status != tt.want {
+				t.Errorf("status = %d %q: %s", rec.Code, body.Data.Status, rec.Body)
+			}
+			if tt.name == "pending migration" {
+				if c := body.Data.Checks["migrations"]; c.Status != "pending" || c.Applied != 1 || len(c.Pending) != 1 || c.Pending[0] != 2 {
+					t.Errorf("migrations check = %+v", c)
+				}
+			}
+		})
+	}
+}
+
+func TestLivenessAndVersion(t *testing.T) {
+	health := NewHealth(fakeDB{err: errors.New("down")}, nil)
+	health.Drain()
+	handler := newHandler(NewApp(Repositories{}, config.Default()), &config.Config{}, health)
+
+	// Liveness ignores dependencies and draining, so the process is not
+	// restarted while it shuts down or the database is away
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != 200 {
+		t.Errorf("healthz = %d", rec.Code)
+	}
+
+	commit, buildTime = "abc123", "2026-10-19T12:00:00Z"
+	t.Cleanup(func() { commit, buildTime = "", "" })
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/version", nil))
+	var body struct {
+		Data BuildInfo `json:"data"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if body.Data.Commit != "abc123" || body.Data.BuildTime != "2026-10-19T12:00:00Z" || body.Data.GoVersion == "" {
+		t.Errorf("version = %s", rec.Body)
+	}
+}
+
+func TestOperationalEndpointsSkipAuth(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth.RequireUser = true
+	handler := newHandler(NewApp(Repositories{}, cfg), cfg, NewHealth(fakeDB{}, nil))
+
+	// Probes and scrapes send no X-User-ID, even when the API requires one
+	for _, path := range []string{"/healthz", "/readyz", "/version", "/metrics"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != 200 {
+			t.Errorf("GET %s = %d: %s", path, rec.Code, rec.Body)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/me/feed", nil))
+	if rec.Code != 401 {
+		t.Errorf("GET /me/feed without X-User-ID = %d, want 401", rec.Code)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/askme/api/pkg/middleware"
+)
+
+// idempotentRoutes accept an Idempotency-Key header, so clients can retry
+// creates without duplicating them
+var idempotentRoutes = []string{
+	"POST /posts",
+	"POST /posts/poll",
+	"POST /posts/{postId}/respond",
+	"POST /posts/{postId}/vote",
+	"POST /chats/{chatId}/message",
+}
+
+// idempotentRequests replays stored responses on idempotentRoutes for ttl.
+// Must run after auth.
+func idempotentRequests(ttl time.Duration, store middleware.IdempotencyStore) RouteMiddleware {
+	return func(pattern string, next http.Handler) http.Handler {
+		if ttl <= 0 || !slices.Contains(idempotentRoutes, pattern) {
+			return next
+		}
+		return middleware.Idempotency(store, pattern, ttl)(next)
+	}
+}
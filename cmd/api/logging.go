@@ -0,0 +1,70 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/askme/api/internal/config"
+	"github.com/askme/api/pkg/httputil"
+	"github.com/askme/api/pkg/middleware"
+	"github.com/askme/api/pkg/slogutil"
+)
+
+// logLevel is the minimum level of the default logger, changed at runtime
+// through /admin/log-level
+var logLevel = new(slog.LevelVar)
+
+// newLogger creates the logger selected by cfg, writing to w. Records logged
+// with a request context carry its requestId, traceId, userId and route.
+func newLogger(w io.Writer, cfg config.LogConfig) (*slog.Logger, error) {
+	logLevel.Set(cfg.Level)
+	h, err := slogutil.NewHandler(w, slogutil.Options{
+		Format:     cfg.Format,
+		Level:      logLevel,
+		RedactKeys: cfg.RedactKeys,
+		Sampling: slogutil.SamplingOptions{
+			First:      cfg.SampleFirst,
+			Thereafter: cfg.SampleThereafter,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slog.New(slogutil.NewContextHandler(h)), nil
+}
+
+// LogLevel is the body of /admin/log-level
+type LogLevel struct {
+	Level string `json:"level" validate:"required"`
+}
+
+// logLevelHandler reports the log level on GET and sets it on PUT, e.g.
+// {"level":"debug"}, for the users in adminUserIDs
+func logLevelHandler(adminUserIDs []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !slices.Contains(adminUserIDs, middleware.GetUserID(r.Context())) {
+			httputil.Error(w, http.StatusForbidden, "admin only")
+			return
+		}
+
+		if r.Method == http.MethodPut {
+			req, err := httputil.DecodeJSON[LogLevel](r)
+			if err != nil {
+				httputil.InvalidRequest(w, err)
+				return
+			}
+			var level slog.Level
+			if err := level.UnmarshalText([]byte(req.Level)); err != nil {
+				httputil.Error(w, http.StatusBadRequest, "level must be debug, info, warn or error")
+				return
+			}
+			previous := logLevel.Level()
+			logLevel.Set(level)
+			slog.WarnContext(r.Context(), "log level changed", "from", previous.String(), "to", level.String())
+		}
+
+		httputil.JSON(w, http.StatusOK, LogLevel{Level: logLevel.Level().String()})
+	})
+}
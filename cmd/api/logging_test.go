@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/askme/api/internal/config"
+	"github.com/askme/api/internal/memrepo"
+	"github.com/askme/api/pkg/slogutil"
+)
+
+func TestRequestLogFields(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slogutil.NewContextHandler(slog.NewJSONHandler(&buf, nil))))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	store := memrepo.NewStore()
+	handler := newHandler(NewApp(Repositories{User: memrepo.NewUserRepository(store)}, config.Default()), &config.Config{}, NewHealth(nil, nil))
+
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`{"username":"ana"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-ID", "req-1")
+	req.Header.Set("X-User-ID", "u-ana")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != 201 {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+
+	logs := map[string]map[string]any{}
+	for line := range strings.Lines(buf.String()) {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		logs[entry["msg"].(string)] = entry
+	}
+
+	// The service log and the access log, which runs outside auth and
+	// routing, both carry every request field
+	for _, msg := range []string{"user created", "http request"} {
+		entry := logs[msg]
+		if entry == nil {
+			t.Fatalf("no %q log in\n%s", msg, buf.String())
+		}
+		want := map[string]string{
+			"requestId": "req-1",
+			"userId":    "u-ana",
+			"route":     "POST /users",
+			"traceId":   "4bf92f3577b34da6a3ce929d0e0e4736",
+		}
+		for k, v := range want {
+			if entry[k] != v {
+				t.Errorf("%s: %s = %v, want %s", msg, k, entry[k], v)
+			}
+		}
+	}
+}
+
+func TestLogLevelEndpoint(t *testing.T) {
+	t.Cleanup(func() { logLevel.Set(slog.LevelInfo) })
+	handler := newHandler(NewApp(Repositories{}, config.Default()), &config.Config{Auth: config.AuthConfig{AdminUserIDs: []string{"u-admin"}}}, NewHealth(nil, nil))
+
+	tests := []struct {
+		name   string
+		user   string
+		method string
+		body   string
+		status int
+		level  string
+	}{
+		{"not admin", "u-ana", "GET", "", 403, ""},
+		{"get", "u-admin", "GET", "", 200, "INFO"},
+		{"set", "u-admin", "PUT", `{"level":"debug"}`, 200, "DEBUG"},
+		{"invalid", "u-admin", "PUT", `{"level":"loud"}`, 400, ""},
+		{"kept", "u-admin", "GET", "", 200, "DEBUG"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/admin/log-level", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", tt.user)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("%s: status = %d: %s", tt.name, rec.Code, rec.Body)
+			continue
+		}
+		if tt.level != "" && !strings.Contains(rec.Body.String(), `"level":"`+tt.level+`"`) {
+			t.Errorf("%s: body = %s", tt.name, rec.Body)
+		}
+	}
+	if logLevel.Level() != slog.LevelDebug {
+		t.Errorf("level = %s, want DEBUG", logLevel.Level())
+	}
+}
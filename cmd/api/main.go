@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log/slog"
 	"net/http"
 	"os"
@@ -11,21 +12,47 @@ import (
 
 	"github.com/askme/api/internal/config"
 	"github.com/askme/api/pkg/arango"
+	"github.com/askme/api/pkg/arango/migrate"
+	"github.com/askme/api/pkg/metrics"
 	"github.com/askme/api/pkg/middleware"
-	"github.com/askme/api/pkg/slogutil"
+	"github.com/askme/api/pkg/trace"
 )
 
 func main() {
-	logger := slog.New(slogutil.NewIndentedJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
-	slog.SetDefault(logger)
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "TOML config file; environment variables override it")
+	printConfig := flag.Bool("print-config", false, "print the effective config with secrets redacted and exit")
+	flag.Parse()
 
-	cfg, err := config.Load()
+	cfg, err := config.Read(*configFile)
 	if err != nil {
 		slog.Error("failed to load config", "error", err)
 		os.Exit(1)
 	}
+	if *printConfig {
+		cfg.Print(os.Stdout)
+	}
+	if err := cfg.Validate(); err != nil {
+		slog.Error("failed to load config", "error", err)
+		os.Exit(1)
+	}
+	if *printConfig {
+		return
+	}
+
+	logger, err := newLogger(os.Stdout, cfg.Log)
+	if err != nil {
+		slog.Error("failed to create logger", "error", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	metrics.Default.RegisterRuntime()
+
+	tracer := newTracer(cfg.Tracing)
+	if tracer != nil {
+		trace.SetDefault(tracer)
+		slog.Info("tracing enabled", "exporter", cfg.Tracing.Exporter, "sampleRatio", cfg.Tracing.SampleRatio)
+	}
 
 	// Initialize ArangoDB client
 	db, err := arango.NewClient(cfg.ArangoDB)
@@ -35,36 +62,28 @@ func main() {
 	}
 
 	// Initialize app with dependency injection
-	app := NewApp(db)
-
-	// Setup router
-	mux := http.NewServeMux()
-	app.RegisterRoutes(mux)
-
-	// Apply middleware chain (order matters: outermost first)
-	// Recovery -> RequestID -> Logger -> SecureHeaders -> CORS -> FakeAuth -> JSON -> handler
-	handler := middleware.Chain(
-		mux,
-		middleware.Recovery,      // Recover from panics (outermost)
-		middleware.RequestID,     // Add request ID for tracing
-		middleware.Logger,        // Log all requests
-		middleware.SecureHeaders, // Add security headers
-		middleware.CORS(middleware.DefaultCORSConfig()),         // Handle CORS
-		middleware.FakeAuth(middleware.DefaultFakeAuthConfig()), // Fake auth for dev (extracts X-User-ID header)
-		middleware.JSON, // Set JSON content type
-	)
+	app := NewApp(NewRepositories(db), cfg)
+	if err := checkRateLimits(app.Routes(), cfg.RateLimit); err != nil {
+		slog.Error("invalid rate limits", "error", err)
+		os.Exit(1)
+	}
+	health := NewHealth(db, migrate.NewRunner(db, migrate.Migrations))
+	handler := newHandler(app, cfg, health)
 
 	server := &http.Server{
-		Addr:         ":" + cfg.Port,
+		Addr:         ":" + cfg.Server.Port,
 		Handler:      handler,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
+	// Shutdown waits for active connections; end event streams so it
+	// does not wait for them
+	server.RegisterOnShutdown(app.Close)
 
 	// Graceful shutdown
 	go func() {
-		slog.Info("server starting", "port", cfg.Port)
+		slog.Info("server starting", "port", cfg.Server.Port)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			slog.Error("server failed", "error", err)
 			os.Exit(1)
@@ -75,13 +94,76 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
+	// Fail readiness first and give load balancers time to stop routing
+	// here before open connections are closed
+	slog.Info("draining", "delay", cfg.Server.ShutdownDelay.String())
+	health.Drain()
+	time.Sleep(cfg.Server.ShutdownDelay)
+
 	slog.Info("shutting down server...")
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
 		slog.Error("server forced to shutdown", "error", err)
 	}
+	if tracer != nil {
+		if err := tracer.Shutdown(ctx); err != nil {
+			slog.Error("failed to flush traces", "error", err)
+		}
+	}
 
 	slog.Info("server stopped")
 }
+
+// newHandler routes the app and wraps it in the middleware chain
+func newHandler(app *App, cfg *config.Config, health *Health) http.Handler {
+	mux := http.NewServeMux()
+	app.RegisterRoutes(mux,
+		routeNames,    // Name request spans and log fields by route pattern
+		measureRoutes, // Request metrics per route pattern
+		idempotentRequests(cfg.IdempotencyTTL, middleware.NewMemoryIdempotencyStore()), // Replay Idempotency-Key retries
+		rateLimitRoutes(cfg.RateLimit, middleware.NewMemoryRateLimitStore()),           // Per-route limits, after auth
+	)
+	mux.Handle("GET /admin/log-level", logLevelHandler(cfg.Auth.AdminUserIDs))
+	mux.Handle("PUT /admin/log-level", logLevelHandler(cfg.Auth.AdminUserIDs))
+
+	// Unset sections, e.g. in tests, keep the development defaults
+	cors := middleware.DefaultCORSConfig()
+	if len(cfg.CORS.AllowedOrigins) > 0 {
+		cors.AllowedOrigins = cfg.CORS.AllowedOrigins
+	}
+	auth := middleware.DefaultFakeAuthConfig()
+	if cfg.Auth.DefaultUserID != "" {
+		auth.DefaultUserID = cfg.Auth.DefaultUserID
+	}
+	auth.Required = cfg.Auth.RequireUser
+
+	api := middleware.Chain(
+		mux,
+		middleware.CORS(cors),                 // Handle CORS
+		middleware.FakeAuth(auth),             // Fake auth for dev (extracts X-User-ID header)
+		profileQueries(cfg.Auth.AdminUserIDs), // AQL profile for admins on ?profile=1
+		middleware.JSON,                       // Set JSON content type
+	)
+
+	// Operational endpoints, outside the API route table and its auth, so
+	// probes and scrapes work without X-User-ID even with auth required
+	root := http.NewServeMux()
+	root.HandleFunc("GET /healthz", health.Live)
+	root.HandleFunc("GET /readyz", health.Ready)
+	root.HandleFunc("GET /version", health.Version)
+	root.Handle("GET /metrics", metrics.Default.Handler())
+	root.Handle("/", api)
+
+	// Apply middleware chain (order matters: outermost first)
+	// Recovery -> RequestID -> Trace -> Logger -> SecureHeaders -> [CORS -> FakeAuth -> Profile -> JSON] -> handler
+	return middleware.Chain(
+		root,
+		middleware.Recovery,      // Recover from panics (outermost)
+		middleware.RequestID,     // Add request ID for correlation
+		middleware.Trace,         // Span per request, continuing traceparent
+		middleware.Logger,        // Log all requests
+		middleware.SecureHeaders, // Add security headers
+	)
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/askme/api/pkg/metrics"
+	"github.com/askme/api/pkg/middleware"
+)
+
+// httpMetrics are registered once, as newHandler may run several times in
+// tests
+var httpMetrics = middleware.NewHTTPMetrics(metrics.Default)
+
+// measureRoutes records request metrics under each route's pattern
+func measureRoutes(pattern string, next http.Handler) http.Handler {
+	return httpMetrics.Route(pattern)(next)
+}
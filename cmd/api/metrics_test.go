@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/askme/api/internal/config"
+	"github.com/askme/api/internal/memrepo"
+	"github.com/askme/api/pkg/metrics"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	store := memrepo.NewStore()
+	handler := newHandler(NewApp(Repositories{Tag: memrepo.NewTagRepository(store)}, config.Default()), &config.Config{}, NewHealth(nil, nil))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/tags/missing-tag", nil))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != metrics.ContentType {
+		t.Fatalf("status %d, Content-Type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		`http_requests_total{route="GET /tags/{tagId}",status="404"} `,
+		`http_request_duration_seconds_count{route="GET /tags/{tagId}"} `,
+		`http_response_size_bytes_count{route="GET /tags/{tagId}"} `,
+		`http_requests_in_flight{route="GET /tags/{tagId}"} 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+	if strings.Contains(body, "missing-tag") {
+		t.Error("metrics are labeled with the raw path")
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/askme/api/internal/chat"
+	"github.com/askme/api/internal/domain"
+	"github.com/askme/api/internal/feed"
+	"github.com/askme/api/internal/post"
+	"github.com/askme/api/internal/tag"
+	"github.com/askme/api/internal/user"
+	"github.com/askme/api/pkg/httputil"
+	"github.com/askme/api/pkg/middleware"
+	"github.com/askme/api/pkg/openapi"
+)
+
+// endpoint documents a route of the table for the OpenAPI spec. Every route
+// needs an entry, or the spec fails to build.
+type endpoint struct {
+	summary string
+	tag     string
+	// auth is set when the route requires X-User-ID
+	auth  bool
+	query []openapi.Parameter
+	// request is the body type, nil when the route takes no body
+	request any
+	// response is the data type of the success envelope
+	response any
+	status   int
+	// errors lists domain error statuses; 400, 401 and 413 are added for
+	// routes with parameters, bodies or auth
+	errors []int
+	// raw responses are sent as is, without the envelope
+	raw bool
+	// stream responses are text/event-stream instead of JSON
+	stream bool
+}
+
+// endpoints documents the route table, keyed by route pattern
+var endpoints = map[string]endpoint{
+	// Users
+	"GET /users/{userId}": {summary: "Get a user profile", tag: "users",
+		response: user.User{}, status: http.StatusOK, errors: []int{http.StatusNotFound}},
+	"POST /users": {summary: "Create a user", tag: "users",
+		request: user.CreateUserRequest{}, response: user.CreateUserResponse{}, status: http.StatusCreated,
+		errors: []int{http.StatusConflict}},
+
+	// Current user
+	"POST /me/follow/{userId}": {summary: "Follow a user", tag: "me", auth: true,
+		response: user.FollowUserResponse{}, status: http.StatusOK, errors: []int{http.StatusConflict}},
+	"GET /me/chats": {summary: "List the caller's chat threads, newest message first", tag: "me", auth: true,
+		query: []openapi.Parameter{
+			queryParam("limit", "integer", "Page size, default 50"),
+			queryParam("cursor", "string", "nextCursor of the previous page"),
+		},
+		response: chat.ChatThreadsResponse{}, status: http.StatusOK},
+	"GET /me/feed": {summary: "Get the caller's personalized feed", tag: "me", auth: true,
+		query: []openapi.Parameter{
+			queryParam("limit", "integer", "Page size, default 20, at most 50"),
+			queryParam("cursor", "string", "nextCursor of the previous page"),
+			queryParam("category", "string", "Only posts of this category"),
+			queryParam("depth", "string", "Only posts of this depth"),
+		},
+		response: feed.FeedResponse{}, status: http.StatusOK},
+
+	// Posts
+	"GET /posts/{postId}": {summary: "Get a post with its tags and poll results", tag: "posts",
+		response: post.GetPostResponse{}, status: http.StatusOK, errors: []int{http.StatusNotFound}},
+	"POST /posts": {summary: "Create a text post", tag: "posts", auth: true,
+		request: post.CreatePostRequest{}, response: post.CreatePostResponse{}, status: http.StatusCreated},
+	"POST /posts/poll": {summary: "Create a poll", tag: "posts", auth: true,
+		request: post.CreatePostRequest{}, response: post.CreatePostResponse{}, status: http.StatusCreated},
+	"PATCH /posts/{postId}": {summary: "Edit a post's text", tag: "posts", auth: true,
+		request: post.UpdatePostRequest{}, response: post.UpdatePostResponse{}, status: http.StatusOK,
+		errors: []int{http.StatusForbidden, http.StatusNotFound}},
+	"DELETE /posts/{postId}": {summary: "Delete a post", tag: "posts", auth: true,
+		response: post.DeletePostResponse{}, status: http.StatusOK,
+		errors: []int{http.StatusForbidden, http.StatusNotFound}},
+	"POST /posts/{postId}/respond": {summary: "Respond to a post, starting a chat with its author", tag: "posts", auth: true,
+		request: post.RespondToPostRequest{}, response: post.RespondToPostResponse{}, status: http.StatusCreated,
+		errors: []int{http.StatusNotFound, http.StatusConflict}},
+	"POST /posts/{postId}/vote": {summary: "Vote on a poll, replacing any earlier vote", tag: "posts", auth: true,
+		request: post.VoteRequest{}, response: post.VoteResponse{}, status: http.StatusOK,
+		errors: []int{http.StatusNotFound, http.StatusConflict}},
+	"DELETE /posts/{postId}/vote": {summary: "Retract the caller's vote", tag: "posts", auth: true,
+		response: post.VoteResponse{}, status: http.StatusOK,
+		errors: []int{http.StatusNotFound, http.StatusConflict}},
+
+	// Chats
+	"GET /chats/{chatId}": {summary: "Get a chat with its messages", tag: "chats",
+		response: chat.GetChatResponse{}, status: http.StatusOK, errors: []int{http.StatusNotFound}},
+	"POST /chats/{chatId}/message": {summary: "Send a message", tag: "chats", auth: true,
+		request: chat.SendMessageRequest{}, response: chat.SendMessageResponse{}, status: http.StatusCreated,
+		errors: []int{http.StatusForbidden, http.StatusNotFound}},
+	"POST /chats/{chatId}/accept": {summary: "Accept a chat invite", tag: "chats", auth: true,
+		response: chat.AcceptChatResponse{}, status: http.StatusOK, errors: []int{http.StatusNotFound}},
+	"POST /chats/{chatId}/mute": {summary: "Mute a chat", tag: "chats", auth: true,
+		response: chat.MuteChatResponse{}, status: http.StatusOK, errors: []int{http.StatusNotFound}},
+	"GET /chats/{chatId}/participants": {summary: "List chat participants", tag: "chats",
+		response: chat.ParticipantsResponse{}, status: http.StatusOK, errors: []int{http.StatusNotFound}},
+	"GET /chats/{chatId}/events": {summary: "Stream message edits and unsends as server-sent events", tag: "chats", auth: true,
+		status: http.StatusOK, stream: true, errors: []int{http.StatusForbidden, http.StatusNotFound}},
+
+	// Messages
+	"PATCH /messages/{messageId}": {summary: "Edit a message", tag: "messages", auth: true,
+		request: chat.EditMessageRequest{}, response: chat.MessageResponse{}, status: http.StatusOK,
+		errors: []int{http.StatusForbidden, http.StatusNotFound}},
+	"DELETE /messages/{messageId}": {summary: "Unsend a message", tag: "messages", auth: true,
+		response: chat.DeleteMessageResponse{}, status: http.StatusOK,
+		errors: []int{http.StatusForbidden, http.StatusNotFound}},
+	"POST /messages/{messageId}/react": {summary: "React to a message, replacing any earlier reaction", tag: "messages", auth: true,
+		request: chat.ReactToMessageRequest{}, response: chat.ReactToMessageResponse{}, status: http.StatusOK,
+		errors: []int{http.StatusNotFound}},
+	"GET /messages/{messageId}/replies": {summary: "List replies to a message", tag: "messages",
+		response: chat.RepliesResponse{}, status: http.StatusOK, errors: []int{http.StatusNotFound}},
+	"GET /messages/{messageId}/reactions": {summary: "List reactions to a message", tag: "messages",
+		response: chat.MessageReactionsResponse{}, status: http.StatusOK, errors: []int{http.StatusNotFound}},
+
+	// Tags
+	"GET /tags/{tagId}": {summary: "Get a tag", tag: "tags",
+		response: tag.Tag{}, status: http.StatusOK, errors: []int{http.StatusNotFound}},
+	"GET /tags": {summary: "List tags by usage, or search them with q", tag: "tags",
+		query: []openapi.Parameter{
+			queryParam("limit", "integer", "Page size, default 50 (20 when searching), at most 100"),
+			queryParam("offset", "integer", "Tags to skip; ignored when searching"),
+			queryParam("q", "string", "Search term, matched against labels and aliases"),
+		},
+		response: []tag.Tag{}, status: http.StatusOK},
+
+	// Meta
+	"GET /openapi.json": {summary: "This OpenAPI document", tag: "meta",
+		status: http.StatusOK, raw: true},
+}
+
+func queryParam(name, typ, description string) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "query", Description: description, Schema: &openapi.Schema{Type: typ}}
+}
+
+// buildSpec generates the OpenAPI document of a route table. It fails when a
+// route has no endpoints entry or an entry matches no route.
+func buildSpec(routes []Route) (*openapi.Document, error) {
+	doc := openapi.New(openapi.Info{
+		Title:       "ask.me API",
+		Version:     "1.0.0",
+		Description: "Question-driven social API. Generated from the route table and handler types.",
+	})
+	doc.Components.SecuritySchemes = map[string]*openapi.SecurityScheme{
+		"userId": {Type: "apiKey", In: "header", Name: "X-User-ID", Description: "Development auth: the caller's user key"},
+	}
+
+	doc.Enum(reflect.TypeFor[domain.PostType](), string(domain.PostTypeText), string(domain.PostTypePoll))
+	doc.Enum(reflect.TypeFor[domain.PollResultsVisibility](),
+		string(domain.ResultsAlways), string(domain.ResultsAfterVote), string(domain.ResultsAfterClose))
+	doc.Enum(reflect.TypeFor[domain.PostCategory](),
+		string(domain.CategoryCareer), string(domain.CategoryRelationships), string(domain.CategoryTech),
+		string(domain.CategoryHealth), string(domain.CategoryFinance), string(domain.CategoryFun),
+		string(domain.CategoryOpinion), string(domain.CategoryLifestyle), string(domain.CategoryEducation),
+		string(domain.CategoryOther))
+	doc.Enum(reflect.TypeFor[domain.PostDepth](),
+		string(domain.DepthCasual), string(domain.DepthNeutral), string(domain.DepthSerious))
+	doc.Enum(reflect.TypeFor[domain.MessageStatus](),
+		string(domain.MessageStatusSending), string(domain.MessageStatusSent), string(domain.MessageStatusDelivered),
+		string(domain.MessageStatusSeen), string(domain.MessageStatusFailed))
+	doc.Enum(reflect.TypeFor[domain.ChatType](), string(domain.ChatTypeDirect), string(domain.ChatTypeGroup))
+	doc.Enum(reflect.TypeFor[domain.ParticipantRole](),
+		string(domain.RoleAuthor), string(domain.RoleResponder), string(domain.RoleInvited))
+	doc.Enum(reflect.TypeFor[domain.ParticipantStatus](),
+		string(domain.StatusActive), string(domain.StatusPending), string(domain.StatusMuted))
+
+	errorContent := map[string]openapi.MediaType{
+		httputil.ProblemContentType: {Schema: doc.Schema(reflect.TypeFor[httputil.Problem]())},
+	}
+
+	var missing []string
+	for _, route := range routes {
+		e, ok := endpoints[route.Pattern]
+		if !ok {
+			missing = append(missing, route.Pattern)
+			continue
+		}
+		method, path, _ := strings.Cut(route.Pattern, " ")
+
+		op := &openapi.Operation{
+			OperationID: operationID(route.Handler),
+			Summary:     e.summary,
+			Tags:        []string{e.tag},
+			Parameters:  append(openapi.PathParams(path), e.query...),
+			Responses:   make(map[string]*openapi.Response),
+		}
+		if e.auth {
+			op.Security = []map[string][]string{{"userId": {}}}
+		}
+		if e.request != nil {
+			op.RequestBody = &openapi.RequestBody{
+				Required: true,
+				Content:  jsonContent(doc.Schema(reflect.TypeOf(e.request))),
+			}
+		}
+
+		success := &openapi.Response{Description: http.StatusText(e.status)}
+		switch {
+		case e.stream:
+			success.Content = map[string]openapi.MediaType{"text/event-stream": {Schema: &openapi.Schema{Type: "string"}}}
+		case e.raw:
+			success.Content = jsonContent(&openapi.Schema{Type: "object"})
+		default:
+			success.Content = jsonContent(envelope(doc.Schema(reflect.TypeOf(e.response))))
+		}
+		op.Responses[strconv.Itoa(e.status)] = success
+
+		errs := slices.Clone(e.errors)
+		if len(op.Parameters) > 0 || e.request != nil {
+			errs = append(errs, http.StatusBadRequest)
+		}
+		if e.auth {
+			errs = append(errs, http.StatusUnauthorized)
+		}
+		if e.request != nil {
+			errs = append(errs, http.StatusRequestEntityTooLarge)
+		}
+		// Any route may be rate limited by config
+		errs = append(errs, http.StatusTooManyRequests)
+		if slices.Contains(idempotentRoutes, route.Pattern) {
+			maxKey := middleware.MaxIdempotencyKeyLength
+			op.Parameters = append(op.Parameters, openapi.Parameter{
+				Name:        middleware.IdempotencyKeyHeader,
+				In:          "header",
+				Description: "Client-chosen key; retries with the same key and body replay the first response",
+				Schema:      &openapi.Schema{Type: "string", MaxLength: &maxKey},
+			})
+			if !slices.Contains(errs, http.StatusConflict) {
+				errs = append(errs, http.StatusConflict)
+			}
+		}
+		for _, status := range errs {
+			op.Responses[strconv.Itoa(status)] = &openapi.Response{
+				Description: http.StatusText(status),
+				Content:     errorContent,
+			}
+		}
+
+		doc.AddOperation(method, path, op)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("routes without an OpenAPI entry: %s", strings.Join(missing, ", "))
+	}
+
+	for pattern := range endpoints {
+		if !slices.ContainsFunc(routes, func(r Route) bool { return r.Pattern == pattern }) {
+			return nil, fmt.Errorf("OpenAPI entry %q matches no route", pattern)
+		}
+	}
+
+	return doc, nil
+}
+
+// envelope wraps a data schema in the httputil.Response envelope
+func envelope(data *openapi.Schema) *openapi.Schema {
+	return &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"success": {Type: "boolean"},
+			"data":    data,
+		},
+		Required: []string{"success"},
+	}
+}
+
+func jsonContent(schema *openapi.Schema) map[string]openapi.MediaType {
+	return map[string]openapi.MediaType{"application/json": {Schema: schema}}
+}
+
+// operationID names an operation after its handler method, e.g.
+// "post.Handler.CreatePost-fm" becomes "CreatePost"
+func operationID(h http.HandlerFunc) string {
+	name := runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+	name = strings.TrimSuffix(name, "-fm")
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// OpenAPI handles GET /openapi.json
+func (a *App) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	a.specOnce.Do(func() {
+		doc, err := buildSpec(a.Routes())
+		if err != nil {
+			a.specErr = err
+			return
+		}
+		a.spec, a.specErr = json.Marshal(doc)
+	})
+	if a.specErr != nil {
+		slog.Error("failed to build OpenAPI spec", "error", a.specErr)
+		httputil.Error(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(a.spec)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/askme/api/internal/config"
+	"github.com/askme/api/pkg/openapi"
+)
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	routes := NewApp(Repositories{}, config.Default()).Routes()
+	doc, err := buildSpec(routes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := map[string]string{}
+	for _, route := range routes {
+		method, path, _ := strings.Cut(route.Pattern, " ")
+		item, ok := doc.Paths[path]
+		if !ok {
+			t.Errorf("%s: path missing from the spec", route.Pattern)
+			continue
+		}
+		op, ok := (*item)[strings.ToLower(method)]
+		if !ok {
+			t.Errorf("%s: method missing from the spec", route.Pattern)
+			continue
+		}
+
+		if other, dup := ids[op.OperationID]; dup {
+			t.Errorf("%s: operationId %q already used by %s", route.Pattern, op.OperationID, other)
+		}
+		ids[op.OperationID] = route.Pattern
+
+		for _, param := range openapi.PathParams(path) {
+			if !slices.ContainsFunc(op.Parameters, func(p openapi.Parameter) bool { return p.Name == param.Name && p.In == "path" }) {
+				t.Errorf("%s: path parameter %s is not documented", route.Pattern, param.Name)
+			}
+		}
+	}
+
+	// Every reference resolves to a component
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("marshal spec: %v", err)
+	}
+	for _, ref := range strings.Split(string(raw), `"$ref":"`)[1:] {
+		name := strings.TrimPrefix(ref[:strings.IndexByte(ref, '"')], "#/components/schemas/")
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("dangling schema reference %q", name)
+		}
+	}
+}
+
+func TestOpenAPIRejectsUndocumentedRoute(t *testing.T) {
+	routes := append(NewApp(Repositories{}, config.Default()).Routes(), Route{"GET /undocumented", func(http.ResponseWriter, *http.Request) {}})
+	if _, err := buildSpec(routes); err == nil || !strings.Contains(err.Error(), "GET /undocumented") {
+		t.Fatalf("got %v, want an error naming the undocumented route", err)
+	}
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	doc, err := buildSpec(NewApp(Repositories{}, config.Default()).Routes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := doc.Components.Schemas["CreatePostRequest"]
+	if req == nil {
+		t.Fatal("CreatePostRequest schema missing")
+	}
+	if _, ok := req.Properties["authorId"]; ok {
+		t.Error("server-set authorId is documented as a request field")
+	}
+	if !slices.Equal(req.Required, []string{"text"}) {
+		t.Errorf("required = %v, want [text]", req.Required)
+	}
+
+	item := doc.Components.Schemas["FeedItem"]
+	if item == nil || item.Properties["category"] == nil || len(item.Properties["category"].Enum) == 0 {
+		t.Fatalf("FeedItem.category should be an enum: %+v", item)
+	}
+	if author := item.Properties["author"]; author.Ref != "#/components/schemas/FeedAuthor" {
+		t.Errorf("author = %+v, want a FeedAuthor reference", author)
+	}
+}
+
+func TestOpenAPIEndpoint(t *testing.T) {
+	handler := newHandler(NewApp(Repositories{}, config.Default()), &config.Config{}, NewHealth(nil, nil))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/openapi.json", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var doc struct {
+		OpenAPI string         `json:"openapi"`
+		Paths   map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+	if doc.OpenAPI != openapi.Version || doc.Paths["/posts/{postId}/vote"] == nil {
+		t.Errorf("unexpected spec: openapi %q with %d paths", doc.OpenAPI, len(doc.Paths))
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/askme/api/pkg/arango"
+	"github.com/askme/api/pkg/middleware"
+)
+
+// profileQueries lets admins append ?profile=1 to any request to get the
+// AQL queries it ran, with timings, stats and plans, in a "profile" field
+// next to the response envelope. Must run after auth.
+func profileQueries(adminUserIDs []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("profile") != "1" || !slices.Contains(adminUserIDs, middleware.GetUserID(r.Context())) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, profile := arango.WithProfile(r.Context())
+			buf := &bufferedResponse{header: w.Header(), status: http.StatusOK}
+			next.ServeHTTP(buf, r.WithContext(ctx))
+
+			body := buf.body.Bytes()
+			var envelope map[string]json.RawMessage
+			if json.Unmarshal(body, &envelope) == nil && envelope != nil {
+				if raw, err := json.Marshal(profile); err == nil {
+					envelope["profile"] = raw
+					if out, err := json.Marshal(envelope); err == nil {
+						body = append(out, '\n')
+					}
+				}
+			}
+
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.WriteHeader(buf.status)
+			w.Write(body)
+		})
+	}
+}
+
+// bufferedResponse holds a response so it can be rewritten before sending
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/askme/api/internal/config"
+	"github.com/askme/api/pkg/middleware"
+)
+
+// rateLimitRoutes limits each route to its configured limit, or the default.
+// Routes share one store but each has its own buckets. Must run after auth.
+func rateLimitRoutes(cfg config.RateLimitConfig, store middleware.RateLimitStore) RouteMiddleware {
+	return func(pattern string, next http.Handler) http.Handler {
+		limit, ok := cfg.Routes[pattern]
+		if !ok {
+			limit = cfg.Default
+		}
+		if limit.Requests == 0 {
+			return next
+		}
+		return middleware.RateLimit(store, pattern, middleware.Limit(limit))(next)
+	}
+}
+
+// checkRateLimits rejects limits for patterns that are not routes, which
+// would otherwise be ignored silently
+func checkRateLimits(routes []Route, cfg config.RateLimitConfig) error {
+	known := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		known[route.Pattern] = true
+	}
+	for pattern := range cfg.Routes {
+		if !known[pattern] {
+			return fmt.Errorf("rate limit for unknown route %q", pattern)
+		}
+	}
+	return nil
+}
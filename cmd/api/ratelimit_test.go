@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/askme/api/internal/config"
+	"github.com/askme/api/pkg/httputil"
+)
+
+func TestCheckRateLimits(t *testing.T) {
+	routes := NewApp(Repositories{}, config.Default()).Routes()
+	if err := checkRateLimits(routes, config.DefaultRateLimits()); err != nil {
+		t.Errorf("default limits: %v", err)
+	}
+
+	cfg := config.RateLimitConfig{Routes: map[string]config.RateLimit{"POST /post": {Requests: 1, Window: time.Minute}}}
+	if err := checkRateLimits(routes, cfg); err == nil || !strings.Contains(err.Error(), "POST /post") {
+		t.Errorf("got %v, want an unknown route error", err)
+	}
+}
+
+func TestRateLimitedRoute(t *testing.T) {
+	cfg := &config.Config{RateLimit: config.RateLimitConfig{
+		Routes: map[string]config.RateLimit{"GET /openapi.json": {Requests: 1, Window: time.Minute}},
+	}}
+	handler := newHandler(NewApp(Repositories{}, config.Default()), cfg, NewHealth(nil, nil))
+
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/openapi.json", nil))
+		return rec
+	}
+	if rec := get(); rec.Code != http.StatusOK {
+		t.Fatalf("first request: %d", rec.Code)
+	}
+	rec := get()
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("second request: %d, headers %v", rec.Code, rec.Header())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != httputil.ProblemContentType {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if id := rec.Header().Get("X-Request-ID"); id == "" || !strings.Contains(rec.Body.String(), id) {
+		t.Errorf("body %s lacks the request ID %q", rec.Body, id)
+	}
+}
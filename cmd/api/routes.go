@@ -2,36 +2,72 @@ package main
 
 import "net/http"
 
-// RegisterRoutes registers all HTTP routes using stdlib mux
+// Route is a single entry of the route table
+type Route struct {
+	// Pattern is a stdlib mux pattern, "METHOD /path/{param}"
+	Pattern string
+	Handler http.HandlerFunc
+}
+
+// Routes returns the route table of the API
+func (a *App) Routes() []Route {
+	return []Route{
+		// User routes
+		{"GET /users/{userId}", a.userHandler.GetUser},
+		{"POST /users", a.userHandler.CreateUser},
+
+		// Current user routes (/me)
+		{"POST /me/follow/{userId}", a.userHandler.FollowUser},
+		{"GET /me/chats", a.chatHandler.GetUserChats},
+		{"GET /me/feed", a.feedHandler.GetFeed},
+
+		// Post routes
+		{"GET /posts/{postId}", a.postHandler.GetPost},
+		{"POST /posts", a.postHandler.CreatePost},
+		{"POST /posts/poll", a.postHandler.CreatePoll},
+		{"PATCH /posts/{postId}", a.postHandler.UpdatePost},
+		{"DELETE /posts/{postId}", a.postHandler.DeletePost},
+		{"POST /posts/{postId}/respond", a.postHandler.RespondToPost},
+		{"POST /posts/{postId}/vote", a.postHandler.Vote},
+		{"DELETE /posts/{postId}/vote", a.postHandler.RetractVote},
+
+		// Chat routes
+		{"GET /chats/{chatId}", a.chatHandler.GetChat},
+		{"POST /chats/{chatId}/message", a.chatHandler.SendMessage},
+		{"POST /chats/{chatId}/accept", a.chatHandler.AcceptChat},
+		{"POST /chats/{chatId}/mute", a.chatHandler.MuteChat},
+		{"GET /chats/{chatId}/participants", a.chatHandler.GetParticipants},
+		{"GET /chats/{chatId}/events", a.chatHandler.StreamEvents},
+
+		// Message routes
+		{"PATCH /messages/{messageId}", a.chatHandler.EditMessage},
+		{"DELETE /messages/{messageId}", a.chatHandler.DeleteMessage},
+		{"POST /messages/{messageId}/react", a.chatHandler.ReactToMessage},
+		{"GET /messages/{messageId}/replies", a.chatHandler.GetReplies},
+		{"GET /messages/{messageId}/reactions", a.chatHandler.GetReactions},
+
+		// Tag routes
+		{"GET /tags/{tagId}", a.tagHandler.GetTag},
+		{"GET /tags", a.tagHandler.ListTags},
+
+		// API description
+		{"GET /openapi.json", a.OpenAPI},
+	}
+}
+
+// RouteMiddleware wraps the handler of a single route, for middleware that
+// depends on the route pattern
+type RouteMiddleware func(pattern string, next http.Handler) http.Handler
+
+// RegisterRoutes registers all HTTP routes using stdlib mux, wrapping each in
+// the route middleware (first is outermost)
 // This can be easily swapped for Chi, Gin, or other routers
-func (a *App) RegisterRoutes(mux *http.ServeMux) {
-	// User routes
-	mux.HandleFunc("GET /users/{userId}", a.userHandler.GetUser)
-	mux.HandleFunc("POST /users", a.userHandler.CreateUser)
-
-	// Current user routes (/me)
-	mux.HandleFunc("POST /me/follow/{userId}", a.userHandler.FollowUser)
-	mux.HandleFunc("GET /me/chats", a.chatHandler.GetUserChats)
-	mux.HandleFunc("GET /me/feed", a.feedHandler.GetFeed)
-
-	// Post routes
-	mux.HandleFunc("GET /posts/{postId}", a.postHandler.GetPost)
-	mux.HandleFunc("POST /posts", a.postHandler.CreatePost)
-	mux.HandleFunc("POST /posts/poll", a.postHandler.CreatePoll)
-	mux.HandleFunc("POST /posts/{postId}/respond", a.postHandler.RespondToPost)
-	mux.HandleFunc("POST /posts/{postId}/vote", a.postHandler.Vote)
-
-	// Chat routes
-	mux.HandleFunc("GET /chats/{chatId}", a.chatHandler.GetChat)
-	mux.HandleFunc("POST /chats/{chatId}/message", a.chatHandler.SendMessage)
-	mux.HandleFunc("POST /chats/{chatId}/accept", a.chatHandler.AcceptChat)
-	mux.HandleFunc("POST /chats/{chatId}/mute", a.chatHandler.MuteChat)
-	mux.HandleFunc("GET /chats/{chatId}/participants", a.chatHandler.GetParticipants)
-
-	// Message routes
-	mux.HandleFunc("POST /messages/{messageId}/react", a.chatHandler.ReactToMessage)
-
-	// Tag routes
-	mux.HandleFunc("GET /tags/{tagId}", a.tagHandler.GetTag)
-	mux.HandleFunc("GET /tags", a.tagHandler.ListTags)
+func (a *App) RegisterRoutes(mux *http.ServeMux, middlewares ...RouteMiddleware) {
+	for _, route := range a.Routes() {
+		var handler http.Handler = route.Handler
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			handler = middlewares[i](route.Pattern, handler)
+		}
+		mux.Handle(route.Pattern, handler)
+	}
 }
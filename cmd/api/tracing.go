@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/askme/api/internal/config"
+	"github.com/askme/api/pkg/middleware"
+	"github.com/askme/api/pkg/trace"
+)
+
+// newTracer creates the tracer selected by cfg, or nil when tracing is off
+func newTracer(cfg config.TracingConfig) *trace.Tracer {
+	opts := trace.Options{SampleRatio: cfg.SampleRatio}
+	switch cfg.Exporter {
+	case "stdout":
+		return trace.NewTracer(trace.NewStdoutExporter(os.Stdout), opts)
+	case "otlp":
+		return trace.NewTracer(trace.NewOTLPExporter(cfg.Endpoint, cfg.ServiceName), opts)
+	}
+	return nil
+}
+
+// routeNames names each request span after the route pattern and adds the
+// route to the request's log fields
+func routeNames(pattern string, next http.Handler) http.Handler {
+	return middleware.Route(pattern)(next)
+}
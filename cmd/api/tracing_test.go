@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/askme/api/internal/config"
+	"github.com/askme/api/internal/memrepo"
+	"github.com/askme/api/pkg/trace"
+)
+
+// spanRecorder keeps exported spans in memory
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*trace.SpanData
+}
+
+func (r *spanRecorder) Export(_ context.Context, spans []*trace.SpanData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func TestTracing(t *testing.T) {
+	exp := &spanRecorder{}
+	tracer := trace.NewTracer(exp, trace.Options{SampleRatio: 1})
+	trace.SetDefault(tracer)
+	t.Cleanup(func() { trace.SetDefault(nil) })
+
+	store := memrepo.NewStore()
+	handler := newHandler(NewApp(Repositories{Tag: memrepo.NewTagRepository(store)}, config.Default()), &config.Config{}, NewHealth(nil, nil))
+
+	req := httptest.NewRequest("GET", "/tags/missing", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	tracer.ForceFlush(context.Background())
+
+	spans := map[string]*trace.SpanData{}
+	for _, s := range exp.spans {
+		spans[s.Name] = s
+	}
+	server, service := spans["GET /tags/{tagId}"], spans["tag.Service.GetTag"]
+	if server == nil || service == nil {
+		t.Fatalf("spans = %v, want the request and service spans", spans)
+	}
+	if server.SpanContext.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || server.Parent.String() != "00f067aa0ba902b7" {
+		t.Errorf("request span does not continue the traceparent: %+v", server.SpanContext)
+	}
+	if service.Parent != server.SpanContext.SpanID {
+		t.Error("service span is not a child of the request span")
+	}
+
+	attrs := map[string]string{}
+	for _, a := range server.Attributes {
+		attrs[a.Key] = a.Value.String()
+	}
+	if attrs["http.route"] != "GET /tags/{tagId}" || attrs["http.response.status_code"] != "404" {
+		t.Errorf("request span attributes = %v", attrs)
+	}
+}
@@ -0,0 +1,126 @@
+// Command migrate applies schema migrations to ArangoDB.
+//
+// Usage:
+//
+//	migrate up [--dry-run]   apply pending migrations (default)
+//	migrate status           list migrations and whether they are applied
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/askme/api/internal/config"
+	"github.com/askme/api/pkg/arango"
+	"github.com/askme/api/pkg/arango/migrate"
+)
+
+func main() {
+	cmd := "up"
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		cmd, args = args[0], args[1:]
+	}
+
+	fs := flag.NewFlagSet("migrate "+cmd, flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "print pending migrations without applying them")
+	fs.Parse(args)
+
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("failed to load config", "error", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	exists, err := arango.DatabaseExists(ctx, cfg.ArangoDB)
+	if err != nil {
+		slog.Error("failed to connect to ArangoDB", "error", err)
+		os.Exit(1)
+	}
+
+	// The database itself is created here rather than by a migration,
+	// since migrations are recorded inside it
+	if !exists && cmd == "up" && !*dryRun {
+		if err := arango.EnsureDatabase(ctx, cfg.ArangoDB); err != nil {
+			slog.Error("failed to create database", "error", err)
+			os.Exit(1)
+		}
+		exists = true
+	}
+
+	// Without a database, status and dry runs report every migration as
+	// pending
+	var db *arango.Client
+	if exists {
+		db, err = arango.NewClient(cfg.ArangoDB)
+		if err != nil {
+			slog.Error("failed to connect to ArangoDB", "error", err)
+			os.Exit(1)
+		}
+	} else {
+		slog.Info("database does not exist yet", "database", cfg.ArangoDB.Database)
+	}
+
+	runner := migrate.NewRunner(db, migrate.Migrations)
+
+	switch cmd {
+	case "up":
+		err = up(ctx, runner, *dryRun)
+	case "status":
+		err = status(ctx, runner)
+	default:
+		err = fmt.Errorf("unknown command %q (want up or status)", cmd)
+	}
+	if err != nil {
+		slog.Error("migrate failed", "command", cmd, "error", err)
+		os.Exit(1)
+	}
+}
+
+func up(ctx context.Context, runner *migrate.Runner, dryRun bool) error {
+	migrations, err := runner.Up(ctx, dryRun)
+	if err != nil {
+		return err
+	}
+
+	if len(migrations) == 0 {
+		fmt.Println("database is up to date")
+		return nil
+	}
+
+	if dryRun {
+		fmt.Printf("%d pending migration(s):\n", len(migrations))
+		for _, m := range migrations {
+			fmt.Printf("  %04d %s\n", m.Version, m.Name)
+			for _, step := range m.Steps {
+				fmt.Printf("         - %s\n", step.Describe())
+			}
+		}
+		return nil
+	}
+
+	fmt.Printf("applied %d migration(s)\n", len(migrations))
+	return nil
+}
+
+func status(ctx context.Context, runner *migrate.Runner) error {
+	statuses, err := runner.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range statuses {
+		state := "pending"
+		if s.Applied {
+			state = "applied " + time.UnixMilli(s.AppliedAt).UTC().Format(time.RFC3339)
+		}
+		fmt.Printf("%04d %-24s %s\n", s.Version, s.Name, state)
+	}
+	return nil
+}
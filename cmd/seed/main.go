@@ -502,15 +502,20 @@ func (s *Seeder) SeedAll() error {
 		},
 		// p11 - Poll
 		{
-			"_key":        "p11",
-			"authorId":    "users/u-dev",
-			"postType":    "poll",
-			"text":        "Which frontend framework do you prefer for new projects?",
-			"pollOptions": []string{"React", "Vue", "Angular", "Svelte"},
-			"category":    "tech",
-			"intent":      "seeking-opinion",
-			"depth":       "casual",
-			"createdAt":   now - 12*hour,
+			"_key":     "p11",
+			"authorId": "users/u-dev",
+			"postType": "poll",
+			"text":     "Which frontend framework do you prefer for new projects?",
+			"pollOptions": []map[string]string{
+				{"id": "o1", "text": "React"},
+				{"id": "o2", "text": "Vue"},
+				{"id": "o3", "text": "Angular"},
+				{"id": "o4", "text": "Svelte"},
+			},
+			"category":  "tech",
+			"intent":    "seeking-opinion",
+			"depth":     "casual",
+			"createdAt": now - 12*hour,
 		},
 		// p12 - Sharing story
 		{
@@ -608,21 +613,21 @@ func (s *Seeder) SeedAll() error {
 	// ============================================
 	chats := []map[string]any{
 		// Direct chats for posts johndoe answered (from posts.ts)
-		{"_key": "c1", "postId": "posts/p1", "type": "direct", "createdAt": now - 4*day, "participantCount": 2},   // sandro's relationship post
-		{"_key": "c4", "postId": "posts/p4", "type": "direct", "createdAt": now - 7*day, "participantCount": 2},   // jordan's 20-year-old advice
-		{"_key": "c6", "postId": "posts/p6", "type": "direct", "createdAt": now - 4*day, "participantCount": 2},   // emma's work-life balance
-		{"_key": "c7", "postId": "posts/p7", "type": "direct", "createdAt": now - 13*day, "participantCount": 2},  // lucas's 20s mistake
+		{"_key": "c1", "postId": "posts/p1", "type": "direct", "createdAt": now - 4*day, "participantCount": 2},  // sandro's relationship post
+		{"_key": "c4", "postId": "posts/p4", "type": "direct", "createdAt": now - 7*day, "participantCount": 2},  // jordan's 20-year-old advice
+		{"_key": "c6", "postId": "posts/p6", "type": "direct", "createdAt": now - 4*day, "participantCount": 2},  // emma's work-life balance
+		{"_key": "c7", "postId": "posts/p7", "type": "direct", "createdAt": now - 13*day, "participantCount": 2}, // lucas's 20s mistake
 
 		// Direct chats for johndoe's questions (multiple responders to same question)
-		{"_key": "chat-1", "postId": "posts/q-mine-1", "type": "direct", "createdAt": now - 1*day, "participantCount": 2},  // alice answered
-		{"_key": "chat-2", "postId": "posts/q-mine-1", "type": "direct", "createdAt": now - 1*day, "participantCount": 2},  // bob answered
+		{"_key": "chat-1", "postId": "posts/q-mine-1", "type": "direct", "createdAt": now - 1*day, "participantCount": 2},   // alice answered
+		{"_key": "chat-2", "postId": "posts/q-mine-1", "type": "direct", "createdAt": now - 1*day, "participantCount": 2},   // bob answered
 		{"_key": "chat-7", "postId": "posts/q-mine-2", "type": "direct", "createdAt": now - 12*hour, "participantCount": 2}, // maria answered imposter syndrome
 		{"_key": "chat-8", "postId": "posts/q-mine-2", "type": "direct", "createdAt": now - 12*hour, "participantCount": 2}, // oliver answered imposter syndrome
 
 		// Group chats
 		{"_key": "chat-group-1", "postId": "posts/q-mine-3", "type": "group", "createdAt": now - 6*hour, "participantCount": 4},  // Japan trip
 		{"_key": "chat-group-2", "postId": "posts/p-group-1", "type": "group", "createdAt": now - 2*day, "participantCount": 4},  // Tech stack (david's question)
-		{"_key": "chat-group-3", "postId": "posts/q-mine-4", "type": "group", "createdAt": now - 4*day, "participantCount": 4},  // Book club
+		{"_key": "chat-group-3", "postId": "posts/q-mine-4", "type": "group", "createdAt": now - 4*day, "participantCount": 4},   // Book club
 		{"_key": "chat-group-4", "postId": "posts/p-group-2", "type": "group", "createdAt": now - 10*day, "participantCount": 4}, // Coffee vs Tea
 	}
 
@@ -723,10 +728,10 @@ func (s *Seeder) SeedAll() error {
 	// ============================================
 	responded := []map[string]any{
 		// Johndoe responded to other people's posts
-		{"_from": "users/u-johndoe", "_to": "posts/p1", "chatId": "chats/c1", "createdAt": now - 4*day},   // sandro's post
-		{"_from": "users/u-johndoe", "_to": "posts/p4", "chatId": "chats/c4", "createdAt": now - 7*day},   // jordan's post
-		{"_from": "users/u-johndoe", "_to": "posts/p6", "chatId": "chats/c6", "createdAt": now - 4*day},   // emma's post
-		{"_from": "users/u-johndoe", "_to": "posts/p7", "chatId": "chats/c7", "createdAt": now - 13*day},  // lucas's post
+		{"_from": "users/u-johndoe", "_to": "posts/p1", "chatId": "chats/c1", "createdAt": now - 4*day},  // sandro's post
+		{"_from": "users/u-johndoe", "_to": "posts/p4", "chatId": "chats/c4", "createdAt": now - 7*day},  // jordan's post
+		{"_from": "users/u-johndoe", "_to": "posts/p6", "chatId": "chats/c6", "createdAt": now - 4*day},  // emma's post
+		{"_from": "users/u-johndoe", "_to": "posts/p7", "chatId": "chats/c7", "createdAt": now - 13*day}, // lucas's post
 		// Others responded to johndoe's posts
 		{"_from": "users/u-alice", "_to": "posts/q-mine-1", "chatId": "chats/chat-1", "createdAt": now - 1*day},
 		{"_from": "users/u-bob", "_to": "posts/q-mine-1", "chatId": "chats/chat-2", "createdAt": now - 1*day},
@@ -895,10 +900,10 @@ func (s *Seeder) SeedAll() error {
 	// EDGES: voted (users -> posts) for polls
 	// ============================================
 	voted := []map[string]any{
-		{"_from": "users/u-johndoe", "_to": "posts/p11", "option": "React", "createdAt": now - 10*hour},
-		{"_from": "users/u-maria", "_to": "posts/p11", "option": "Vue", "createdAt": now - 9*hour},
-		{"_from": "users/u-alex", "_to": "posts/p11", "option": "React", "createdAt": now - 8*hour},
-		{"_from": "users/u-emma", "_to": "posts/p11", "option": "Svelte", "createdAt": now - 7*hour},
+		{"_from": "users/u-johndoe", "_to": "posts/p11", "optionIds": []string{"o1"}, "createdAt": now - 10*hour},
+		{"_from": "users/u-maria", "_to": "posts/p11", "optionIds": []string{"o2"}, "createdAt": now - 9*hour},
+		{"_from": "users/u-alex", "_to": "posts/p11", "optionIds": []string{"o1"}, "createdAt": now - 8*hour},
+		{"_from": "users/u-emma", "_to": "posts/p11", "optionIds": []string{"o4"}, "createdAt": now - 7*hour},
 	}
 
 	log.Println("Seeding voted edges...")
@@ -1,5 +1,7 @@
 package chat
 
+import "github.com/askme/api/pkg/arango"
+
 // AQL queries for chat operations
 const (
 	// GetChatByID retrieves a chat by its key
@@ -18,6 +20,51 @@ const (
 		RETURN m
 	`
 
+	// GetMessageByID retrieves a message by its key
+	GetMessageByID = `
+		FOR m IN messages
+		FILTER m._key == @key
+		RETURN m
+	`
+
+	// GetMessagesByIDs retrieves messages by their keys
+	GetMessagesByIDs = `
+		FOR m IN messages
+		FILTER m._key IN @keys
+		RETURN m
+	`
+
+	// GetMessageReplies retrieves replies to a message
+	GetMessageReplies = `
+		FOR m IN messages
+		FILTER m.replyToId == @messageId
+		SORT m.createdAt ASC
+		LIMIT @offset, @limit
+		RETURN m
+	`
+
+	// EditMessage replaces a message's text and appends the previous text to its revisions
+	EditMessage = `
+		FOR m IN messages
+		FILTER m._key == @key
+		UPDATE m WITH {
+			text: @text,
+			editedAt: @editedAt,
+			revisions: APPEND(m.revisions || [], [@revision])
+		} IN messages
+	`
+
+	// TombstoneMessage clears a message's content and marks it deleted
+	TombstoneMessage = `
+		FOR m IN messages
+		FILTER m._key == @key
+		UPDATE m WITH {
+			text: "",
+			revisions: null,
+			deletedAt: @deletedAt
+		} IN messages OPTIONS { keepNull: false }
+	`
+
 	// UpdateMessageStatus updates a message's delivery status
 	UpdateMessageStatus = `
 		FOR m IN messages
@@ -56,10 +103,7 @@ const (
 
 	// GetChatParticipants retrieves all participants of a chat
 	GetChatParticipants = `
-		FOR edge IN participates_in
-		FILTER edge._to == @chatId
-		FOR user IN users
-		FILTER user._id == edge._from
+		FOR user, edge IN 1..1 INBOUND @chatId participates_in
 		RETURN {
 			id: user._key,
 			username: user.username,
@@ -71,34 +115,32 @@ const (
 
 	// GetUserChatThreads retrieves all chat threads for a user
 	GetUserChatThreads = `
-		FOR edge IN participates_in
-		FILTER edge._from == @userId
+		FOR chat IN 1..1 OUTBOUND @userId participates_in
 		
-		LET chat = DOCUMENT(edge._to)
 		LET post = DOCUMENT(chat.postId)
 		
+		// One traversal serves both partner and participants
+		LET members = (
+			FOR user, participantEdge IN 1..1 INBOUND chat participates_in
+			RETURN { user, edge: participantEdge }
+		)
+		
 		// Get partner (first other participant, typically question author or first responder)
 		LET partner = FIRST(
-			FOR otherEdge IN participates_in
-			FILTER otherEdge._to == chat._id
-			   AND otherEdge._from != @userId
-			FOR user IN users
-			FILTER user._id == otherEdge._from
-			RETURN user
+			FOR member IN members
+			FILTER member.user._id != @userId
+			RETURN member.user
 		)
 		
 		// Get all participants (only used for group chats, but always fetched for simplicity)
 		LET participants = (
-			FOR participantEdge IN participates_in
-			FILTER participantEdge._to == chat._id
-			FOR user IN users
-			FILTER user._id == participantEdge._from
+			FOR member IN members
 			RETURN {
-				id: user._key,
-				username: user.username,
-				avatarUrl: user.avatarUrl,
-				role: participantEdge.role,
-				status: participantEdge.status
+				id: member.user._key,
+				username: member.user.username,
+				avatarUrl: member.user.avatarUrl,
+				role: member.edge.role,
+				status: member.edge.status
 			}
 		)
 		
@@ -143,6 +185,7 @@ const (
 				id: lastMsg._key,
 				text: lastMsg.text,
 				senderId: LAST(SPLIT(lastMsg.senderId, "/")),
+				deleted: lastMsg.deletedAt != null,
 				createdAt: lastMsg.createdAt
 			},
 			unreadCount: unreadCount,
@@ -167,6 +210,42 @@ const (
 		RETURN e
 	`
 
+	// GetReactionCounts aggregates reactions per emoji for a set of messages,
+	// skipping unsent ones
+	GetReactionCounts = `
+		FOR e IN reacted
+		FILTER e._to IN @messageIds
+		FILTER DOCUMENT(e._to).deletedAt == null
+		COLLECT messageId = e._to, emoji = e.emoji WITH COUNT INTO count
+		RETURN { messageId: LAST(SPLIT(messageId, "/")), emoji, count }
+	`
+
+	// GetUserReactions retrieves a user's reactions to a set of messages,
+	// skipping unsent ones
+	GetUserReactions = `
+		FOR e IN reacted
+		FILTER e._from == @userId AND e._to IN @messageIds
+		FILTER DOCUMENT(e._to).deletedAt == null
+		RETURN e
+	`
+
+	// GetMessageReactors lists who reacted to a message with what; an unsent
+	// message has no reactors
+	GetMessageReactors = `
+		FOR e IN reacted
+		FILTER e._to == @messageId
+		FILTER DOCUMENT(e._to).deletedAt == null
+		LET user = DOCUMENT(e._from)
+		SORT e.createdAt ASC
+		RETURN {
+			userId: user._key,
+			username: user.username,
+			avatarUrl: user.avatarUrl,
+			emoji: e.emoji,
+			createdAt: e.createdAt
+		}
+	`
+
 	// DeleteReaction removes a user's reaction to a message
 	DeleteReaction = `
 		FOR e IN reacted
@@ -183,3 +262,28 @@ const (
 		RETURN NEW
 	`
 )
+
+func init() {
+	arango.RegisterQueries("chat", map[string]string{
+		"GetChatByID":           GetChatByID,
+		"GetChatMessages":       GetChatMessages,
+		"GetMessageByID":        GetMessageByID,
+		"GetMessagesByIDs":      GetMessagesByIDs,
+		"GetMessageReplies":     GetMessageReplies,
+		"EditMessage":           EditMessage,
+		"TombstoneMessage":      TombstoneMessage,
+		"UpdateMessageStatus":   UpdateMessageStatus,
+		"GetChatUnreadCount":    GetChatUnreadCount,
+		"UpdateParticipation":   UpdateParticipation,
+		"GetParticipation":      GetParticipation,
+		"GetChatParticipants":   GetChatParticipants,
+		"GetUserChatThreads":    GetUserChatThreads,
+		"GetChatForPostAndUser": GetChatForPostAndUser,
+		"GetReaction":           GetReaction,
+		"GetReactionCounts":     GetReactionCounts,
+		"GetUserReactions":      GetUserReactions,
+		"GetMessageReactors":    GetMessageReactors,
+		"DeleteReaction":        DeleteReaction,
+		"UpsertReaction":        UpsertReaction,
+	})
+}
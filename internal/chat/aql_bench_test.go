@@ -0,0 +1,153 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/askme/api/pkg/arango"
+	"github.com/askme/api/pkg/arango/arangotest"
+)
+
+func BenchmarkGetChatParticipants(b *testing.B) {
+	db := arangotest.DB(b)
+	ctx := context.Background()
+	bindVars := map[string]any{"chatId": fmt.Sprintf("chats/%s", arangotest.ChatKey)}
+
+	b.Run("join", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := arango.Query[Participant](ctx, db, legacyGetChatParticipants, bindVars); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("traversal", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := arango.Query[Participant](ctx, db, GetChatParticipants, bindVars); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkGetUserChatThreads(b *testing.B) {
+	db := arangotest.DB(b)
+	ctx := context.Background()
+	bindVars := map[string]any{
+		"userId": fmt.Sprintf("users/%s", arangotest.UserKey),
+		"limit":  20,
+	}
+
+	b.Run("join", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := arango.Query[ChatThread](ctx, db, legacyGetUserChatThreads, bindVars); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("traversal", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := arango.Query[ChatThread](ctx, db, GetUserChatThreads, bindVars); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// Hand-joined queries the traversal versions replaced, kept as baselines
+const legacyGetChatParticipants = `
+		FOR edge IN participates_in
+		FILTER edge._to == @chatId
+		FOR user IN users
+		FILTER user._id == edge._from
+		RETURN {
+			id: user._key,
+			username: user.username,
+			avatarUrl: user.avatarUrl,
+			role: edge.role,
+			status: edge.status
+		}
+`
+
+const legacyGetUserChatThreads = `
+		FOR edge IN participates_in
+		FILTER edge._from == @userId
+		
+		LET chat = DOCUMENT(edge._to)
+		LET post = DOCUMENT(chat.postId)
+		
+		// Get partner (first other participant, typically question author or first responder)
+		LET partner = FIRST(
+			FOR otherEdge IN participates_in
+			FILTER otherEdge._to == chat._id
+			   AND otherEdge._from != @userId
+			FOR user IN users
+			FILTER user._id == otherEdge._from
+			RETURN user
+		)
+		
+		// Get all participants (only used for group chats, but always fetched for simplicity)
+		LET participants = (
+			FOR participantEdge IN participates_in
+			FILTER participantEdge._to == chat._id
+			FOR user IN users
+			FILTER user._id == participantEdge._from
+			RETURN {
+				id: user._key,
+				username: user.username,
+				avatarUrl: user.avatarUrl,
+				role: participantEdge.role,
+				status: participantEdge.status
+			}
+		)
+		
+		// Get last message
+		LET lastMsg = FIRST(
+			FOR m IN messages
+			FILTER m.chatId == chat._id
+			SORT m.createdAt DESC
+			LIMIT 1
+			RETURN m
+		)
+		
+		// Count unread
+		LET unreadCount = LENGTH(
+			FOR m IN messages
+			FILTER m.chatId == chat._id
+			   AND m.senderId != @userId
+			   AND m.status != 'seen'
+			RETURN 1
+		)
+		
+		FILTER lastMsg != null
+		SORT lastMsg.createdAt DESC
+		LIMIT @limit
+		
+		RETURN {
+			id: chat._key,
+			type: chat.type,
+			question: {
+				id: post._key,
+				text: post.text,
+				authorId: LAST(SPLIT(post.authorId, "/")),
+				createdAt: post.createdAt
+			},
+			partner: {
+				id: partner._key,
+				username: partner.username,
+				avatarUrl: partner.avatarUrl
+			},
+			participants: chat.type == "group" ? participants : null,
+			lastMessage: {
+				id: lastMsg._key,
+				text: lastMsg.text,
+				senderId: LAST(SPLIT(lastMsg.senderId, "/")),
+				deleted: lastMsg.deletedAt != null,
+				createdAt: lastMsg.createdAt
+			},
+			unreadCount: unreadCount,
+			hasUnread: unreadCount > 0
+		}
+`
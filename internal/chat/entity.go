@@ -12,6 +12,9 @@ type Chat struct {
 	ParticipantCount int             `json:"participantCount,omitempty"`
 }
 
+// DeletedMessageText is shown in place of the text of a deleted message
+const DeletedMessageText = "message deleted"
+
 // Message represents a message document in ArangoDB
 type Message struct {
 	Key       string               `json:"_key,omitempty"`
@@ -19,7 +22,22 @@ type Message struct {
 	SenderID  string               `json:"senderId"`
 	Text      string               `json:"text"`
 	Status    domain.MessageStatus `json:"status"`
+	ReplyToID string               `json:"replyToId,omitempty"`
 	CreatedAt int64                `json:"createdAt"`
+	EditedAt  int64                `json:"editedAt,omitempty"`
+	DeletedAt int64                `json:"deletedAt,omitempty"`
+	Revisions []MessageRevision    `json:"revisions,omitempty"`
+}
+
+// IsDeleted reports whether the message has been unsent
+func (m *Message) IsDeleted() bool {
+	return m.DeletedAt != 0
+}
+
+// MessageRevision is a prior text of an edited message
+type MessageRevision struct {
+	Text     string `json:"text"`
+	EditedAt int64  `json:"editedAt"`
 }
 
 // ParticipatesInEdge represents chat participation
@@ -60,6 +78,7 @@ type LastMessage struct {
 	ID            string `json:"id"`
 	Text          string `json:"text"`
 	SenderID      string `json:"senderId"`
+	Deleted       bool   `json:"deleted,omitempty"`
 	CreatedAt     int64  `json:"createdAt"`
 	FormattedTime string `json:"formattedTime"`
 }
@@ -102,24 +121,74 @@ type GetChatResponse struct {
 
 // MessageResponse is a message in API responses
 type MessageResponse struct {
-	Key       string               `json:"_key"`
-	SenderID  string               `json:"senderId"`
-	Text      string               `json:"text"`
-	Status    domain.MessageStatus `json:"status"`
-	CreatedAt int64                `json:"createdAt"`
+	Key        string               `json:"_key"`
+	SenderID   string               `json:"senderId"`
+	Text       string               `json:"text"`
+	Status     domain.MessageStatus `json:"status"`
+	Deleted    bool                 `json:"deleted,omitempty"`
+	ReplyToID  string               `json:"replyToId,omitempty"`
+	ReplyTo    *QuotedMessage       `json:"replyTo,omitempty"`
+	Reactions  []ReactionCount      `json:"reactions,omitempty"`
+	MyReaction *string              `json:"myReaction,omitempty"`
+	CreatedAt  int64                `json:"createdAt"`
+	EditedAt   int64                `json:"editedAt,omitempty"`
+}
+
+// ReactionCount is the number of users who reacted to a message with an emoji
+type ReactionCount struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
+}
+
+// MessageReactionCount is a per-message, per-emoji reaction tally row
+type MessageReactionCount struct {
+	MessageID string `json:"messageId"`
+	Emoji     string `json:"emoji"`
+	Count     int    `json:"count"`
+}
+
+// Reactor is a user who reacted to a message
+type Reactor struct {
+	UserID    string  `json:"userId"`
+	Username  string  `json:"username"`
+	AvatarURL *string `json:"avatarUrl,omitempty"`
+	Emoji     string  `json:"emoji"`
+	CreatedAt int64   `json:"createdAt"`
+}
+
+// MessageReactionsResponse is the response for listing reactions to a message
+type MessageReactionsResponse struct {
+	MessageID string          `json:"messageId"`
+	Counts    []ReactionCount `json:"counts"`
+	Reactions []Reactor       `json:"reactions"`
+}
+
+// QuotedMessage is an inline preview of the message being replied to
+type QuotedMessage struct {
+	ID       string `json:"id"`
+	SenderID string `json:"senderId"`
+	Text     string `json:"text"`
+	Deleted  bool   `json:"deleted,omitempty"`
+}
+
+// RepliesResponse is the response for listing replies to a message
+type RepliesResponse struct {
+	MessageID string            `json:"messageId"`
+	Replies   []MessageResponse `json:"replies"`
 }
 
 // ParticipantsResponse is the response for listing participants
 type ParticipantsResponse struct {
-	ChatID       string         `json:"chatId"`
+	ChatID       string          `json:"chatId"`
 	Type         domain.ChatType `json:"type"`
 	Participants []Participant   `json:"participants"`
 }
 
 // SendMessageRequest is the request for sending a message
 type SendMessageRequest struct {
-	SenderID string `json:"senderId"`
-	Text     string `json:"text"`
+	SenderID  string `json:"senderId" openapi:"-"`
+	Text      string `json:"text" validate:"required,max=4000"`
+	ReplyToID string `json:"replyToId,omitempty"`
 }
 
 // SendMessageResponse is the response for sending a message
@@ -128,6 +197,19 @@ type SendMessageResponse struct {
 	CreatedAt int64  `json:"createdAt"`
 }
 
+// EditMessageRequest is the request for editing a message
+type EditMessageRequest struct {
+	SenderID  string `json:"senderId" openapi:"-"`
+	MessageID string `json:"messageId" openapi:"-"`
+	Text      string `json:"text" validate:"required,max=4000"`
+}
+
+// DeleteMessageResponse is the response for deleting a message
+type DeleteMessageResponse struct {
+	Success   bool   `json:"success"`
+	MessageID string `json:"messageId"`
+}
+
 // AcceptChatRequest is the request for accepting a chat invite
 type AcceptChatRequest struct {
 	UserID string `json:"userId"`
@@ -162,8 +244,8 @@ type ReactedEdge struct {
 
 // ReactToMessageRequest is the request for reacting to a message
 type ReactToMessageRequest struct {
-	UserID    string `json:"userId"`
-	MessageID string `json:"messageId"`
+	UserID    string `json:"userId" openapi:"-"`
+	MessageID string `json:"messageId" openapi:"-"`
 	Emoji     string `json:"emoji"`
 }
 
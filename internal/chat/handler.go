@@ -1,12 +1,20 @@
 package chat
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/askme/api/pkg/httputil"
 	"github.com/askme/api/pkg/middleware"
 )
 
+// streamPingInterval is how often an idle event stream sends a comment, so
+// proxies keep the connection open
+const streamPingInterval = 25 * time.Second
+
 type handler struct {
 	service Service
 }
@@ -45,7 +53,10 @@ func (h *handler) GetChat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.service.GetChat(r.Context(), chatID)
+	// Caller is optional here; it only personalizes myReaction
+	currentUserID := middleware.GetUserID(r.Context())
+
+	resp, err := h.service.GetChat(r.Context(), chatID, currentUserID)
 	if err != nil {
 		httputil.ErrorFromDomain(w, err)
 		return
@@ -71,25 +82,95 @@ func (h *handler) SendMessage(w http.ResponseWriter, r *http.Request) {
 
 	req, err := httputil.DecodeJSON[SendMessageRequest](r)
 	if err != nil {
-		httputil.Error(w, http.StatusBadRequest, "invalid request body")
+		httputil.InvalidRequest(w, err)
+		return
+	}
+
+	// Set sender from authenticated user
+	req.SenderID = currentUserID
+
+	resp, err := h.service.SendMessage(r.Context(), chatID, req)
+	if err != nil {
+		httputil.ErrorFromDomain(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusCreated, resp)
+}
+
+// EditMessage handles PATCH /messages/{messageId}
+func (h *handler) EditMessage(w http.ResponseWriter, r *http.Request) {
+	// Get current user from auth context
+	currentUserID := middleware.GetUserID(r.Context())
+	if currentUserID == "" {
+		httputil.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	messageID := httputil.PathValue(r, "messageId")
+	if messageID == "" {
+		httputil.Error(w, http.StatusBadRequest, "messageId is required")
 		return
 	}
 
-	if req.Text == "" {
-		httputil.Error(w, http.StatusBadRequest, "text is required")
+	req, err := httputil.DecodeJSON[EditMessageRequest](r)
+	if err != nil {
+		httputil.InvalidRequest(w, err)
 		return
 	}
 
 	// Set sender from authenticated user
 	req.SenderID = currentUserID
+	req.MessageID = messageID
 
-	resp, err := h.service.SendMessage(r.Context(), chatID, req)
+	resp, err := h.service.EditMessage(r.Context(), req)
 	if err != nil {
 		httputil.ErrorFromDomain(w, err)
 		return
 	}
 
-	httputil.JSON(w, http.StatusCreated, resp)
+	httputil.JSON(w, http.StatusOK, resp)
+}
+
+// DeleteMessage handles DELETE /messages/{messageId}
+func (h *handler) DeleteMessage(w http.ResponseWriter, r *http.Request) {
+	// Get current user from auth context
+	currentUserID := middleware.GetUserID(r.Context())
+	if currentUserID == "" {
+		httputil.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	messageID := httputil.PathValue(r, "messageId")
+	if messageID == "" {
+		httputil.Error(w, http.StatusBadRequest, "messageId is required")
+		return
+	}
+
+	resp, err := h.service.DeleteMessage(r.Context(), messageID, currentUserID)
+	if err != nil {
+		httputil.ErrorFromDomain(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, resp)
+}
+
+// GetReplies handles GET /messages/{messageId}/replies
+func (h *handler) GetReplies(w http.ResponseWriter, r *http.Request) {
+	messageID := httputil.PathValue(r, "messageId")
+	if messageID == "" {
+		httputil.Error(w, http.StatusBadRequest, "messageId is required")
+		return
+	}
+
+	resp, err := h.service.GetReplies(r.Context(), messageID)
+	if err != nil {
+		httputil.ErrorFromDomain(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, resp)
 }
 
 // AcceptChat handles POST /chats/{chatId}/accept
@@ -180,7 +261,7 @@ func (h *handler) ReactToMessage(w http.ResponseWriter, r *http.Request) {
 
 	req, err := httputil.DecodeJSON[ReactToMessageRequest](r)
 	if err != nil {
-		httputil.Error(w, http.StatusBadRequest, "invalid request body")
+		httputil.InvalidRequest(w, err)
 		return
 	}
 
@@ -196,3 +277,80 @@ func (h *handler) ReactToMessage(w http.ResponseWriter, r *http.Request) {
 
 	httputil.JSON(w, http.StatusOK, resp)
 }
+
+// GetReactions handles GET /messages/{messageId}/reactions
+func (h *handler) GetReactions(w http.ResponseWriter, r *http.Request) {
+	messageID := httputil.PathValue(r, "messageId")
+	if messageID == "" {
+		httputil.Error(w, http.StatusBadRequest, "messageId is required")
+		return
+	}
+
+	resp, err := h.service.GetReactions(r.Context(), messageID)
+	if err != nil {
+		httputil.ErrorFromDomain(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, resp)
+}
+
+// StreamEvents handles GET /chats/{chatId}/events, a text/event-stream of
+// message edits and unsends for the chat's participants
+func (h *handler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	// Get current user from auth context
+	currentUserID := middleware.GetUserID(r.Context())
+	if currentUserID == "" {
+		httputil.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID := httputil.PathValue(r, "chatId")
+	if chatID == "" {
+		httputil.Error(w, http.StatusBadRequest, "chatId is required")
+		return
+	}
+
+	events, unsubscribe, err := h.service.Subscribe(r.Context(), chatID, currentUserID)
+	if err != nil {
+		httputil.ErrorFromDomain(w, err)
+		return
+	}
+	defer unsubscribe()
+
+	// The stream outlives the server's write timeout; writers without
+	// deadlines, such as test recorders, need no change
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ping := time.NewTicker(streamPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-events:
+			// The bus closed the subscription, e.g. on shutdown
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+		case <-ping.C:
+			io.WriteString(w, ": ping\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/askme/api/internal/domain"
+	"github.com/askme/api/pkg/events"
 )
 
 // Repository defines the interface for chat data access
@@ -14,6 +15,11 @@ type Repository interface {
 
 	// Message operations
 	CreateMessage(ctx context.Context, msg *Message) (string, error)
+	GetMessageByID(ctx context.Context, id string) (*Message, error)
+	GetMessagesByIDs(ctx context.Context, ids []string) ([]Message, error)
+	GetReplies(ctx context.Context, messageID string, limit, offset int) ([]Message, error)
+	EditMessage(ctx context.Context, msgID, text string, editedAt int64, revision MessageRevision) error
+	TombstoneMessage(ctx context.Context, msgID string, deletedAt int64) error
 	GetMessages(ctx context.Context, chatID string, limit, offset int) ([]Message, error)
 	UpdateMessageStatus(ctx context.Context, msgID string, status domain.MessageStatus) error
 	GetUnreadCount(ctx context.Context, chatID, userID string) (int, error)
@@ -32,18 +38,32 @@ type Repository interface {
 	GetReaction(ctx context.Context, userID, messageID string) (*ReactedEdge, error)
 	UpsertReaction(ctx context.Context, edge *ReactedEdge) error
 	DeleteReaction(ctx context.Context, userID, messageID string) error
+	GetReactionCounts(ctx context.Context, messageIDs []string) ([]MessageReactionCount, error)
+	GetUserReactions(ctx context.Context, userID string, messageIDs []string) ([]ReactedEdge, error)
+	GetReactors(ctx context.Context, messageID string) ([]Reactor, error)
 }
 
 // Service defines the interface for chat business logic
 type Service interface {
-	GetChat(ctx context.Context, chatID string) (*GetChatResponse, error)
+	GetChat(ctx context.Context, chatID, userID string) (*GetChatResponse, error)
 	GetUserChats(ctx context.Context, userID string, limit int, cursor string) (*ChatThreadsResponse, error)
 	SendMessage(ctx context.Context, chatID string, req *SendMessageRequest) (*SendMessageResponse, error)
+	EditMessage(ctx context.Context, req *EditMessageRequest) (*MessageResponse, error)
+	DeleteMessage(ctx context.Context, messageID, userID string) (*DeleteMessageResponse, error)
+	GetReplies(ctx context.Context, messageID string) (*RepliesResponse, error)
 	AcceptChat(ctx context.Context, chatID string, req *AcceptChatRequest) (*AcceptChatResponse, error)
 	MuteChat(ctx context.Context, chatID string, req *MuteChatRequest) (*MuteChatResponse, error)
 	GetParticipants(ctx context.Context, chatID string) (*ParticipantsResponse, error)
 	CreateChat(ctx context.Context, postID string, chatType domain.ChatType, participants []string) (string, error)
 	ReactToMessage(ctx context.Context, req *ReactToMessageRequest) (*ReactToMessageResponse, error)
+	GetReactions(ctx context.Context, messageID string) (*MessageReactionsResponse, error)
+	Subscribe(ctx context.Context, chatID, userID string) (<-chan events.Event, func(), error)
+}
+
+// EventBus delivers chat events to the participants subscribed to a chat
+type EventBus interface {
+	Publish(ctx context.Context, e events.Event)
+	Subscribe(topic string, buffer int) (<-chan events.Event, func())
 }
 
 // Handler defines the interface for chat HTTP handlers
@@ -51,8 +71,13 @@ type Handler interface {
 	GetUserChats(w http.ResponseWriter, r *http.Request)
 	GetChat(w http.ResponseWriter, r *http.Request)
 	SendMessage(w http.ResponseWriter, r *http.Request)
+	EditMessage(w http.ResponseWriter, r *http.Request)
+	DeleteMessage(w http.ResponseWriter, r *http.Request)
+	GetReplies(w http.ResponseWriter, r *http.Request)
 	AcceptChat(w http.ResponseWriter, r *http.Request)
 	MuteChat(w http.ResponseWriter, r *http.Request)
 	GetParticipants(w http.ResponseWriter, r *http.Request)
 	ReactToMessage(w http.ResponseWriter, r *http.Request)
+	GetReactions(w http.ResponseWriter, r *http.Request)
+	StreamEvents(w http.ResponseWriter, r *http.Request)
 }
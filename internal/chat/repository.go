@@ -29,6 +29,40 @@ func (r *repository) CreateMessage(ctx context.Context, msg *Message) (string, e
 	return arango.InsertDocument(ctx, r.db, arango.CollectionMessages, msg)
 }
 
+func (r *repository) GetMessageByID(ctx context.Context, id string) (*Message, error) {
+	return arango.QueryOne[Message](ctx, r.db, GetMessageByID, map[string]any{"key": id})
+}
+
+func (r *repository) GetMessagesByIDs(ctx context.Context, ids []string) ([]Message, error) {
+	return arango.Query[Message](ctx, r.db, GetMessagesByIDs, map[string]any{"keys": ids})
+}
+
+func (r *repository) GetReplies(ctx context.Context, messageID string, limit, offset int) ([]Message, error) {
+	return arango.Query[Message](ctx, r.db, GetMessageReplies, map[string]any{
+		"messageId": fmt.Sprintf("messages/%s", messageID),
+		"limit":     limit,
+		"offset":    offset,
+	})
+}
+
+func (r *repository) EditMessage(ctx context.Context, msgID, text string, editedAt int64, revision MessageRevision) error {
+	_, err := arango.Query[any](ctx, r.db, EditMessage, map[string]any{
+		"key":      msgID,
+		"text":     text,
+		"editedAt": editedAt,
+		"revision": revision,
+	})
+	return err
+}
+
+func (r *repository) TombstoneMessage(ctx context.Context, msgID string, deletedAt int64) error {
+	_, err := arango.Query[any](ctx, r.db, TombstoneMessage, map[string]any{
+		"key":       msgID,
+		"deletedAt": deletedAt,
+	})
+	return err
+}
+
 func (r *repository) GetMessages(ctx context.Context, chatID string, limit, offset int) ([]Message, error) {
 	return arango.Query[Message](ctx, r.db, GetChatMessages, map[string]any{
 		"chatId": fmt.Sprintf("chats/%s", chatID),
@@ -138,3 +172,31 @@ func (r *repository) DeleteReaction(ctx context.Context, userID, messageID strin
 	})
 	return err
 }
+
+func (r *repository) GetReactionCounts(ctx context.Context, messageIDs []string) ([]MessageReactionCount, error) {
+	return arango.Query[MessageReactionCount](ctx, r.db, GetReactionCounts, map[string]any{
+		"messageIds": messageDocIDs(messageIDs),
+	})
+}
+
+func (r *repository) GetUserReactions(ctx context.Context, userID string, messageIDs []string) ([]ReactedEdge, error) {
+	return arango.Query[ReactedEdge](ctx, r.db, GetUserReactions, map[string]any{
+		"userId":     fmt.Sprintf("users/%s", userID),
+		"messageIds": messageDocIDs(messageIDs),
+	})
+}
+
+func (r *repository) GetReactors(ctx context.Context, messageID string) ([]Reactor, error) {
+	return arango.Query[Reactor](ctx, r.db, GetMessageReactors, map[string]any{
+		"messageId": fmt.Sprintf("messages/%s", messageID),
+	})
+}
+
+// messageDocIDs converts message keys to document IDs
+func messageDocIDs(keys []string) []string {
+	ids := make([]string, len(keys))
+	for i, key := range keys {
+		ids[i] = fmt.Sprintf("messages/%s", key)
+	}
+	return ids
+}
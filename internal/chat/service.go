@@ -2,22 +2,67 @@ package chat
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/askme/api/internal/domain"
+	"github.com/askme/api/pkg/events"
+	"github.com/askme/api/pkg/trace"
 )
 
+// Chat event types published to the chat's topic
+const (
+	EventMessageEdited  = "message.edited"
+	EventMessageDeleted = "message.deleted"
+)
+
+// eventBuffer is how many events a slow subscriber may fall behind before
+// it misses some
+const eventBuffer = 16
+
+// allowedReactions is the set of emoji users can react with
+var allowedReactions = map[string]bool{
+	"❤️": true,
+	"👍":  true,
+	"👎":  true,
+	"😂":  true,
+	"😮":  true,
+	"😢":  true,
+	"🙏":  true,
+	"🔥":  true,
+	"👏":  true,
+}
+
+// normalizeReaction maps emoji typed without the emoji variation selector,
+// e.g. a plain "❤", to the form in allowedReactions, so both count as one
+func normalizeReaction(emoji string) string {
+	if !allowedReactions[emoji] && allowedReactions[emoji+"\uFE0F"] {
+		return emoji + "\uFE0F"
+	}
+	return emoji
+}
+
+// quotePreviewLength caps the text shown in an inline reply quote
+const quotePreviewLength = 140
+
 type service struct {
 	repo Repository
+	bus  EventBus
 }
 
 // NewService creates a new chat service
-func NewService(repo Repository) Service {
-	return &service{repo: repo}
+func NewService(repo Repository, bus EventBus) Service {
+	return &service{repo: repo, bus: bus}
 }
 
-func (s *service) GetChat(ctx context.Context, chatID string) (*GetChatResponse, error) {
+func (s *service) GetChat(ctx context.Context, chatID, userID string) (*GetChatResponse, error) {
+	ctx, span := trace.Start(ctx, "chat.Service.GetChat")
+	defer span.End()
+
 	chat, err := s.repo.GetByID(ctx, chatID)
 	if err != nil {
 		return nil, fmt.Errorf("get chat: %w", err)
@@ -33,13 +78,14 @@ func (s *service) GetChat(ctx context.Context, chatID string) (*GetChatResponse,
 
 	msgResponses := make([]MessageResponse, len(messages))
 	for i, msg := range messages {
-		msgResponses[i] = MessageResponse{
-			Key:       msg.Key,
-			SenderID:  msg.SenderID,
-			Text:      msg.Text,
-			Status:    msg.Status,
-			CreatedAt: msg.CreatedAt,
-		}
+		msgResponses[i] = toMessageResponse(&msg)
+	}
+
+	if err := s.attachQuotes(ctx, msgResponses, messages); err != nil {
+		return nil, err
+	}
+	if err := s.attachReactions(ctx, msgResponses, userID); err != nil {
+		return nil, err
 	}
 
 	return &GetChatResponse{
@@ -52,6 +98,9 @@ func (s *service) GetChat(ctx context.Context, chatID string) (*GetChatResponse,
 }
 
 func (s *service) GetUserChats(ctx context.Context, userID string, limit int, cursor string) (*ChatThreadsResponse, error) {
+	ctx, span := trace.Start(ctx, "chat.Service.GetUserChats")
+	defer span.End()
+
 	if limit <= 0 {
 		limit = 50
 	}
@@ -64,10 +113,13 @@ func (s *service) GetUserChats(ctx context.Context, userID string, limit int, cu
 		return nil, fmt.Errorf("get user chats: %w", err)
 	}
 
-	// Format times
+	// Format times and render tombstones
 	for i := range threads {
 		threads[i].Question.FormattedTime = formatTime(threads[i].Question.CreatedAt)
 		threads[i].LastMessage.FormattedTime = formatTime(threads[i].LastMessage.CreatedAt)
+		if threads[i].LastMessage.Deleted {
+			threads[i].LastMessage.Text = DeletedMessageText
+		}
 	}
 
 	var cursorPtr *string
@@ -82,6 +134,9 @@ func (s *service) GetUserChats(ctx context.Context, userID string, limit int, cu
 }
 
 func (s *service) SendMessage(ctx context.Context, chatID string, req *SendMessageRequest) (*SendMessageResponse, error) {
+	ctx, span := trace.Start(ctx, "chat.Service.SendMessage")
+	defer span.End()
+
 	// Verify chat exists
 	chat, err := s.repo.GetByID(ctx, chatID)
 	if err != nil {
@@ -97,7 +152,7 @@ func (s *service) SendMessage(ctx context.Context, chatID string, req *SendMessa
 		return nil, fmt.Errorf("get participation: %w", err)
 	}
 	if participation == nil {
-		return nil, domain.ErrForbidden
+		return nil, domain.ErrNotParticipant
 	}
 
 	now := time.Now().UnixMilli()
@@ -110,6 +165,18 @@ func (s *service) SendMessage(ctx context.Context, chatID string, req *SendMessa
 		CreatedAt: now,
 	}
 
+	// Replies must reference a message in the same chat
+	if req.ReplyToID != "" {
+		parent, err := s.repo.GetMessageByID(ctx, req.ReplyToID)
+		if err != nil {
+			return nil, fmt.Errorf("get reply target: %w", err)
+		}
+		if parent == nil || parent.ChatID != msg.ChatID {
+			return nil, fmt.Errorf("%w: reply target not found in chat", domain.ErrInvalidInput)
+		}
+		msg.ReplyToID = fmt.Sprintf("messages/%s", req.ReplyToID)
+	}
+
 	msgID, err := s.repo.CreateMessage(ctx, msg)
 	if err != nil {
 		return nil, fmt.Errorf("create message: %w", err)
@@ -121,7 +188,221 @@ func (s *service) SendMessage(ctx context.Context, chatID string, req *SendMessa
 	}, nil
 }
 
+func (s *service) GetReplies(ctx context.Context, messageID string) (*RepliesResponse, error) {
+	ctx, span := trace.Start(ctx, "chat.Service.GetReplies")
+	defer span.End()
+
+	parent, err := s.repo.GetMessageByID(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("get message: %w", err)
+	}
+	if parent == nil {
+		return nil, domain.ErrNotFound
+	}
+
+	replies, err := s.repo.GetReplies(ctx, messageID, 100, 0)
+	if err != nil {
+		return nil, fmt.Errorf("get replies: %w", err)
+	}
+
+	quote := toQuotedMessage(parent)
+	resp := make([]MessageResponse, len(replies))
+	for i, reply := range replies {
+		resp[i] = toMessageResponse(&reply)
+		resp[i].ReplyTo = quote
+	}
+
+	return &RepliesResponse{
+		MessageID: messageID,
+		Replies:   resp,
+	}, nil
+}
+
+// attachQuotes fills the inline parent preview of every reply. Parents that
+// are not part of the loaded page are fetched in a single query.
+func (s *service) attachQuotes(ctx context.Context, responses []MessageResponse, messages []Message) error {
+	byKey := make(map[string]*Message, len(messages))
+	for i := range messages {
+		byKey[messages[i].Key] = &messages[i]
+	}
+
+	var missing []string
+	for _, resp := range responses {
+		if resp.ReplyToID != "" && byKey[resp.ReplyToID] == nil {
+			missing = append(missing, resp.ReplyToID)
+		}
+	}
+
+	if len(missing) > 0 {
+		parents, err := s.repo.GetMessagesByIDs(ctx, missing)
+		if err != nil {
+			return fmt.Errorf("get quoted messages: %w", err)
+		}
+		for i := range parents {
+			byKey[parents[i].Key] = &parents[i]
+		}
+	}
+
+	for i := range responses {
+		if parent := byKey[responses[i].ReplyToID]; parent != nil {
+			responses[i].ReplyTo = toQuotedMessage(parent)
+		}
+	}
+	return nil
+}
+
+// attachReactions fills per-emoji reaction counts and the caller's own reaction
+func (s *service) attachReactions(ctx context.Context, responses []MessageResponse, userID string) error {
+	if len(responses) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(responses))
+	for i, resp := range responses {
+		keys[i] = resp.Key
+	}
+
+	counts, err := s.repo.GetReactionCounts(ctx, keys)
+	if err != nil {
+		return fmt.Errorf("get reaction counts: %w", err)
+	}
+
+	byMessage := make(map[string][]ReactionCount)
+	for _, c := range counts {
+		byMessage[c.MessageID] = append(byMessage[c.MessageID], ReactionCount{Emoji: c.Emoji, Count: c.Count})
+	}
+
+	mine := make(map[string]string)
+	if userID != "" {
+		edges, err := s.repo.GetUserReactions(ctx, userID, keys)
+		if err != nil {
+			return fmt.Errorf("get user reactions: %w", err)
+		}
+		for _, e := range edges {
+			mine[strings.TrimPrefix(e.To, "messages/")] = e.Emoji
+		}
+	}
+
+	for i := range responses {
+		responses[i].Reactions = sortReactionCounts(byMessage[responses[i].Key])
+		if emoji, ok := mine[responses[i].Key]; ok {
+			responses[i].MyReaction = &emoji
+		}
+	}
+	return nil
+}
+
+func (s *service) EditMessage(ctx context.Context, req *EditMessageRequest) (*MessageResponse, error) {
+	ctx, span := trace.Start(ctx, "chat.Service.EditMessage")
+	defer span.End()
+
+	msg, err := s.getOwnMessage(ctx, req.MessageID, req.SenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()
+	revision := MessageRevision{
+		Text:     msg.Text,
+		EditedAt: now,
+	}
+
+	if err := s.repo.EditMessage(ctx, req.MessageID, req.Text, now, revision); err != nil {
+		return nil, fmt.Errorf("edit message: %w", err)
+	}
+
+	msg.Text = req.Text
+	msg.EditedAt = now
+	resp := toMessageResponse(msg)
+
+	s.bus.Publish(ctx, events.Event{
+		Type:      EventMessageEdited,
+		Topic:     chatTopic(msg.ChatID),
+		Payload:   resp,
+		CreatedAt: now,
+	})
+
+	return &resp, nil
+}
+
+func (s *service) DeleteMessage(ctx context.Context, messageID, userID string) (*DeleteMessageResponse, error) {
+	ctx, span := trace.Start(ctx, "chat.Service.DeleteMessage")
+	defer span.End()
+
+	msg, err := s.getOwnMessage(ctx, messageID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()
+	if err := s.repo.TombstoneMessage(ctx, messageID, now); err != nil {
+		return nil, fmt.Errorf("delete message: %w", err)
+	}
+
+	msg.DeletedAt = now
+	s.bus.Publish(ctx, events.Event{
+		Type:      EventMessageDeleted,
+		Topic:     chatTopic(msg.ChatID),
+		Payload:   toMessageResponse(msg),
+		CreatedAt: now,
+	})
+
+	return &DeleteMessageResponse{
+		Success:   true,
+		MessageID: messageID,
+	}, nil
+}
+
+// Subscribe streams the chat's events to a participant until the returned
+// function is called
+func (s *service) Subscribe(ctx context.Context, chatID, userID string) (<-chan events.Event, func(), error) {
+	ctx, span := trace.Start(ctx, "chat.Service.Subscribe")
+	defer span.End()
+
+	chat, err := s.repo.GetByID(ctx, chatID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get chat: %w", err)
+	}
+	if chat == nil {
+		return nil, nil, domain.ErrNotFound
+	}
+
+	participation, err := s.repo.GetParticipation(ctx, userID, chatID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get participation: %w", err)
+	}
+	if participation == nil {
+		return nil, nil, domain.ErrNotParticipant
+	}
+
+	ch, unsubscribe := s.bus.Subscribe(chatTopic(chatID), eventBuffer)
+	return ch, unsubscribe, nil
+}
+
+// chatTopic is the bus topic of a chat, from its key or document ID
+func chatTopic(chatID string) string {
+	return "chats/" + strings.TrimPrefix(chatID, "chats/")
+}
+
+// getOwnMessage loads a live message and verifies that userID sent it
+func (s *service) getOwnMessage(ctx context.Context, messageID, userID string) (*Message, error) {
+	msg, err := s.repo.GetMessageByID(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("get message: %w", err)
+	}
+	if msg == nil || msg.IsDeleted() {
+		return nil, domain.ErrNotFound
+	}
+	if msg.SenderID != fmt.Sprintf("users/%s", userID) {
+		return nil, domain.ErrNotSender
+	}
+	return msg, nil
+}
+
 func (s *service) AcceptChat(ctx context.Context, chatID string, req *AcceptChatRequest) (*AcceptChatResponse, error) {
+	ctx, span := trace.Start(ctx, "chat.Service.AcceptChat")
+	defer span.End()
+
 	// Verify participation exists and is pending
 	participation, err := s.repo.GetParticipation(ctx, req.UserID, chatID)
 	if err != nil {
@@ -131,7 +412,7 @@ func (s *service) AcceptChat(ctx context.Context, chatID string, req *AcceptChat
 		return nil, domain.ErrNotFound
 	}
 	if participation.Status != domain.StatusPending {
-		return nil, fmt.Errorf("%w: chat already accepted or muted", domain.ErrInvalidInput)
+		return nil, domain.ErrChatNotPending.With("status", participation.Status)
 	}
 
 	now := time.Now().UnixMilli()
@@ -148,6 +429,9 @@ func (s *service) AcceptChat(ctx context.Context, chatID string, req *AcceptChat
 }
 
 func (s *service) MuteChat(ctx context.Context, chatID string, req *MuteChatRequest) (*MuteChatResponse, error) {
+	ctx, span := trace.Start(ctx, "chat.Service.MuteChat")
+	defer span.End()
+
 	// Verify participation exists
 	participation, err := s.repo.GetParticipation(ctx, req.UserID, chatID)
 	if err != nil {
@@ -170,6 +454,9 @@ func (s *service) MuteChat(ctx context.Context, chatID string, req *MuteChatRequ
 }
 
 func (s *service) GetParticipants(ctx context.Context, chatID string) (*ParticipantsResponse, error) {
+	ctx, span := trace.Start(ctx, "chat.Service.GetParticipants")
+	defer span.End()
+
 	chat, err := s.repo.GetByID(ctx, chatID)
 	if err != nil {
 		return nil, fmt.Errorf("get chat: %w", err)
@@ -191,6 +478,9 @@ func (s *service) GetParticipants(ctx context.Context, chatID string) (*Particip
 }
 
 func (s *service) CreateChat(ctx context.Context, postID string, chatType domain.ChatType, participants []string) (string, error) {
+	ctx, span := trace.Start(ctx, "chat.Service.CreateChat")
+	defer span.End()
+
 	now := time.Now().UnixMilli()
 
 	chat := &Chat{
@@ -227,10 +517,28 @@ func (s *service) CreateChat(ctx context.Context, postID string, chatType domain
 		}
 	}
 
+	slog.InfoContext(ctx, "chat created", "chatId", chatID, "postId", postID, "chatType", chatType, "participants", len(participants))
+
 	return chatID, nil
 }
 
 func (s *service) ReactToMessage(ctx context.Context, req *ReactToMessageRequest) (*ReactToMessageResponse, error) {
+	ctx, span := trace.Start(ctx, "chat.Service.ReactToMessage")
+	defer span.End()
+
+	req.Emoji = normalizeReaction(req.Emoji)
+	if req.Emoji != "" && !allowedReactions[req.Emoji] {
+		return nil, domain.ErrBadReaction
+	}
+
+	msg, err := s.repo.GetMessageByID(ctx, req.MessageID)
+	if err != nil {
+		return nil, fmt.Errorf("get message: %w", err)
+	}
+	if msg == nil || msg.IsDeleted() {
+		return nil, domain.ErrNotFound
+	}
+
 	// Empty emoji means remove reaction
 	if req.Emoji == "" {
 		if err := s.repo.DeleteReaction(ctx, req.UserID, req.MessageID); err != nil {
@@ -251,7 +559,14 @@ func (s *service) ReactToMessage(ctx context.Context, req *ReactToMessageRequest
 		CreatedAt: now,
 	}
 
-	if err := s.repo.UpsertReaction(ctx, edge); err != nil {
+	// Concurrent first reactions race on the unique reacted index, and
+	// concurrent changes of one reaction conflict; retry so the loser
+	// replaces the winner's emoji
+	err = s.repo.UpsertReaction(ctx, edge)
+	if errors.Is(err, domain.ErrAlreadyExists) || errors.Is(err, domain.ErrConflict) {
+		err = s.repo.UpsertReaction(ctx, edge)
+	}
+	if err != nil {
 		return nil, fmt.Errorf("upsert reaction: %w", err)
 	}
 
@@ -262,6 +577,85 @@ func (s *service) ReactToMessage(ctx context.Context, req *ReactToMessageRequest
 	}, nil
 }
 
+func (s *service) GetReactions(ctx context.Context, messageID string) (*MessageReactionsResponse, error) {
+	ctx, span := trace.Start(ctx, "chat.Service.GetReactions")
+	defer span.End()
+
+	msg, err := s.repo.GetMessageByID(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("get message: %w", err)
+	}
+	if msg == nil || msg.IsDeleted() {
+		return nil, domain.ErrNotFound
+	}
+
+	reactors, err := s.repo.GetReactors(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("get reactors: %w", err)
+	}
+
+	tally := make(map[string]int)
+	for _, r := range reactors {
+		tally[r.Emoji]++
+	}
+	counts := make([]ReactionCount, 0, len(tally))
+	for emoji, count := range tally {
+		counts = append(counts, ReactionCount{Emoji: emoji, Count: count})
+	}
+
+	return &MessageReactionsResponse{
+		MessageID: messageID,
+		Counts:    sortReactionCounts(counts),
+		Reactions: reactors,
+	}, nil
+}
+
+// sortReactionCounts orders counts by popularity, then emoji for stability
+func sortReactionCounts(counts []ReactionCount) []ReactionCount {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Emoji < counts[j].Emoji
+	})
+	return counts
+}
+
+// toMessageResponse maps a message to its API form, hiding the content of tombstones
+func toMessageResponse(msg *Message) MessageResponse {
+	resp := MessageResponse{
+		Key:       msg.Key,
+		SenderID:  msg.SenderID,
+		Text:      msg.Text,
+		Status:    msg.Status,
+		ReplyToID: strings.TrimPrefix(msg.ReplyToID, "messages/"),
+		CreatedAt: msg.CreatedAt,
+		EditedAt:  msg.EditedAt,
+	}
+	if msg.IsDeleted() {
+		resp.Text = DeletedMessageText
+		resp.Deleted = true
+		resp.EditedAt = 0
+	}
+	return resp
+}
+
+// toQuotedMessage builds the inline preview of a replied-to message
+func toQuotedMessage(msg *Message) *QuotedMessage {
+	quote := &QuotedMessage{
+		ID:       msg.Key,
+		SenderID: msg.SenderID,
+		Text:     msg.Text,
+	}
+	if msg.IsDeleted() {
+		quote.Text = DeletedMessageText
+		quote.Deleted = true
+	} else if runes := []rune(msg.Text); len(runes) > quotePreviewLength {
+		quote.Text = string(runes[:quotePreviewLength]) + "…"
+	}
+	return quote
+}
+
 // formatTime formats a timestamp to a human-readable string
 func formatTime(timestamp int64) string {
 	t := time.UnixMilli(timestamp)
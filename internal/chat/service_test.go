@@ -0,0 +1,360 @@
+package chat_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/askme/api/internal/chat"
+	"github.com/askme/api/internal/domain"
+	"github.com/askme/api/internal/memrepo"
+	"github.com/askme/api/internal/post"
+)
+
+// fixture adds chat helpers to the shared seeded fixture
+type fixture struct {
+	*memrepo.Fixture
+}
+
+func newFixture(t *testing.T) *fixture {
+	return &fixture{memrepo.Seed(t)}
+}
+
+// createChat creates a post by the first participant and a chat about it
+func (f *fixture) createChat(t *testing.T, chatType domain.ChatType, participants ...string) string {
+	t.Helper()
+	ctx := context.Background()
+
+	postID, err := f.PostRepo.Create(ctx, &post.Post{
+		AuthorID:  participants[0],
+		PostType:  domain.PostTypeText,
+		Text:      "Any book recommendations?",
+		CreatedAt: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	chatID, err := f.Chats.CreateChat(ctx, postID, chatType, participants)
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	return chatID
+}
+
+func (f *fixture) send(t *testing.T, chatID, senderID, text string) string {
+	t.Helper()
+	resp, err := f.Chats.SendMessage(context.Background(), chatID, &chat.SendMessageRequest{SenderID: senderID, Text: text})
+	if err != nil {
+		t.Fatalf("send message: %v", err)
+	}
+	return resp.MessageID
+}
+
+func TestAcceptChat(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	chatID := f.createChat(t, domain.ChatTypeGroup, "alice", "bob")
+
+	// Invitees start out pending until they accept
+	if err := f.ChatRepo.CreateParticipation(ctx, &chat.ParticipatesInEdge{
+		From:   "users/carol",
+		To:     fmt.Sprintf("chats/%s", chatID),
+		Role:   domain.RoleInvited,
+		Status: domain.StatusPending,
+	}); err != nil {
+		t.Fatalf("create participation: %v", err)
+	}
+
+	resp, err := f.Chats.AcceptChat(ctx, chatID, &chat.AcceptChatRequest{UserID: "carol"})
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	if resp.Status != domain.StatusActive {
+		t.Errorf("status = %s, want active", resp.Status)
+	}
+
+	edge, err := f.ChatRepo.GetParticipation(ctx, "carol", chatID)
+	if err != nil || edge == nil {
+		t.Fatalf("get participation: %v", err)
+	}
+	if edge.Status != domain.StatusActive || !edge.NotificationsEnabled || edge.JoinedAt == nil {
+		t.Errorf("participation = %+v, want active with notifications and joinedAt", edge)
+	}
+
+	t.Run("already accepted", func(t *testing.T) {
+		_, err := f.Chats.AcceptChat(ctx, chatID, &chat.AcceptChatRequest{UserID: "carol"})
+		if !errors.Is(err, domain.ErrInvalidInput) {
+			t.Fatalf("got %v, want ErrInvalidInput", err)
+		}
+	})
+
+	t.Run("not invited", func(t *testing.T) {
+		other := f.createChat(t, domain.ChatTypeDirect, "alice", "bob")
+		_, err := f.Chats.AcceptChat(ctx, other, &chat.AcceptChatRequest{UserID: "carol"})
+		if !errors.Is(err, domain.ErrNotFound) {
+			t.Fatalf("got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestMuteChat(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	chatID := f.createChat(t, domain.ChatTypeDirect, "alice", "bob")
+
+	before, err := f.ChatRepo.GetParticipation(ctx, "bob", chatID)
+	if err != nil || before == nil {
+		t.Fatalf("get participation: %v", err)
+	}
+
+	if _, err := f.Chats.MuteChat(ctx, chatID, &chat.MuteChatRequest{UserID: "bob"}); err != nil {
+		t.Fatalf("mute: %v", err)
+	}
+
+	after, err := f.ChatRepo.GetParticipation(ctx, "bob", chatID)
+	if err != nil || after == nil {
+		t.Fatalf("get participation: %v", err)
+	}
+	if after.Status != domain.StatusMuted || after.NotificationsEnabled {
+		t.Errorf("participation = %+v, want muted without notifications", after)
+	}
+	if after.JoinedAt == nil || *after.JoinedAt != *before.JoinedAt {
+		t.Errorf("joinedAt changed from %v to %v", before.JoinedAt, after.JoinedAt)
+	}
+
+	if _, err := f.Chats.MuteChat(ctx, chatID, &chat.MuteChatRequest{UserID: "carol"}); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("mute by outsider: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestSendMessage(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	chatID := f.createChat(t, domain.ChatTypeDirect, "alice", "bob")
+	parent := f.send(t, chatID, "alice", "Try Dune")
+
+	t.Run("reply", func(t *testing.T) {
+		resp, err := f.Chats.SendMessage(ctx, chatID, &chat.SendMessageRequest{SenderID: "bob", Text: "Loved it", ReplyToID: parent})
+		if err != nil {
+			t.Fatalf("reply: %v", err)
+		}
+		replies, err := f.Chats.GetReplies(ctx, parent)
+		if err != nil {
+			t.Fatalf("get replies: %v", err)
+		}
+		if len(replies.Replies) != 1 || replies.Replies[0].Key != resp.MessageID || replies.Replies[0].ReplyTo.Text != "Try Dune" {
+			t.Errorf("replies = %+v, want the reply quoting its parent", replies.Replies)
+		}
+	})
+
+	t.Run("non-participant", func(t *testing.T) {
+		_, err := f.Chats.SendMessage(ctx, chatID, &chat.SendMessageRequest{SenderID: "carol", Text: "Hi"})
+		if !errors.Is(err, domain.ErrForbidden) {
+			t.Fatalf("got %v, want ErrForbidden", err)
+		}
+	})
+
+	t.Run("reply to another chat", func(t *testing.T) {
+		other := f.createChat(t, domain.ChatTypeDirect, "alice", "bob")
+		_, err := f.Chats.SendMessage(ctx, other, &chat.SendMessageRequest{SenderID: "bob", Text: "Hi", ReplyToID: parent})
+		if !errors.Is(err, domain.ErrInvalidInput) {
+			t.Fatalf("got %v, want ErrInvalidInput", err)
+		}
+	})
+
+	t.Run("missing chat", func(t *testing.T) {
+		_, err := f.Chats.SendMessage(ctx, "nope", &chat.SendMessageRequest{SenderID: "bob", Text: "Hi"})
+		if !errors.Is(err, domain.ErrNotFound) {
+			t.Fatalf("got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestDeleteMessage(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	chatID := f.createChat(t, domain.ChatTypeDirect, "alice", "bob")
+	msgID := f.send(t, chatID, "alice", "Oops")
+
+	if _, err := f.Chats.DeleteMessage(ctx, msgID, "bob"); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("delete by other user: got %v, want ErrForbidden", err)
+	}
+	if _, err := f.Chats.DeleteMessage(ctx, msgID, "alice"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	threads, err := f.Chats.GetUserChats(ctx, "bob", 0, "")
+	if err != nil {
+		t.Fatalf("get chats: %v", err)
+	}
+	last := threads.Threads[0].LastMessage
+	if !last.Deleted || last.Text != chat.DeletedMessageText {
+		t.Errorf("last message = %+v, want a tombstone", last)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	chatID := f.createChat(t, domain.ChatTypeDirect, "alice", "bob")
+	msgID := f.send(t, chatID, "alice", "Helo")
+
+	if _, _, err := f.Chats.Subscribe(ctx, chatID, "carol"); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("subscribe by non-participant: got %v, want ErrForbidden", err)
+	}
+	if _, _, err := f.Chats.Subscribe(ctx, "nope", "bob"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("subscribe to missing chat: got %v, want ErrNotFound", err)
+	}
+
+	events, unsubscribe, err := f.Chats.Subscribe(ctx, chatID, "bob")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer unsubscribe()
+
+	if _, err := f.Chats.EditMessage(ctx, &chat.EditMessageRequest{MessageID: msgID, SenderID: "alice", Text: "Hello"}); err != nil {
+		t.Fatalf("edit: %v", err)
+	}
+	if _, err := f.Chats.DeleteMessage(ctx, msgID, "alice"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	for _, want := range []string{chat.EventMessageEdited, chat.EventMessageDeleted} {
+		select {
+		case e := <-events:
+			if e.Type != want {
+				t.Errorf("event = %s, want %s", e.Type, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no %s event", want)
+		}
+	}
+}
+
+func TestReactToMessage(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	chatID := f.createChat(t, domain.ChatTypeDirect, "alice", "bob")
+	msgID := f.send(t, chatID, "alice", "Try Dune")
+
+	if _, err := f.Chats.ReactToMessage(ctx, &chat.ReactToMessageRequest{UserID: "bob", MessageID: msgID, Emoji: "🦄"}); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Fatalf("unsupported emoji: got %v, want ErrInvalidInput", err)
+	}
+	for _, emoji := range []string{"👍", "🔥"} {
+		if _, err := f.Chats.ReactToMessage(ctx, &chat.ReactToMessageRequest{UserID: "bob", MessageID: msgID, Emoji: emoji}); err != nil {
+			t.Fatalf("react %s: %v", emoji, err)
+		}
+	}
+
+	reactions, err := f.Chats.GetReactions(ctx, msgID)
+	if err != nil {
+		t.Fatalf("get reactions: %v", err)
+	}
+	if len(reactions.Reactions) != 1 || reactions.Reactions[0].Emoji != "🔥" || reactions.Reactions[0].UserID != "bob" {
+		t.Errorf("reactions = %+v, want bob's reaction replaced by 🔥", reactions.Reactions)
+	}
+
+	// A heart without the variation selector counts as ❤️
+	resp, err := f.Chats.ReactToMessage(ctx, &chat.ReactToMessageRequest{UserID: "bob", MessageID: msgID, Emoji: "\u2764"})
+	if err != nil {
+		t.Fatalf("react plain heart: %v", err)
+	}
+	if resp.Emoji != "\u2764\uFE0F" {
+		t.Errorf("emoji = %q, want ❤️", resp.Emoji)
+	}
+
+	if _, err := f.Chats.ReactToMessage(ctx, &chat.ReactToMessageRequest{UserID: "bob", MessageID: msgID}); err != nil {
+		t.Fatalf("remove reaction: %v", err)
+	}
+	reactions, err = f.Chats.GetReactions(ctx, msgID)
+	if err != nil {
+		t.Fatalf("get reactions: %v", err)
+	}
+	if len(reactions.Reactions) != 0 {
+		t.Errorf("reactions = %+v, want none", reactions.Reactions)
+	}
+}
+
+func TestReactionsOfUnsentMessage(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	chatID := f.createChat(t, domain.ChatTypeDirect, "alice", "bob")
+	msgID := f.send(t, chatID, "alice", "Try Dune")
+
+	if _, err := f.Chats.ReactToMessage(ctx, &chat.ReactToMessageRequest{UserID: "bob", MessageID: msgID, Emoji: "👍"}); err != nil {
+		t.Fatalf("react: %v", err)
+	}
+	if _, err := f.Chats.DeleteMessage(ctx, msgID, "alice"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	if _, err := f.Chats.GetReactions(ctx, msgID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("get reactions: got %v, want ErrNotFound", err)
+	}
+	counts, err := f.ChatRepo.GetReactionCounts(ctx, []string{msgID})
+	if err != nil || len(counts) != 0 {
+		t.Errorf("counts = %+v, %v, want none", counts, err)
+	}
+	reactors, err := f.ChatRepo.GetReactors(ctx, msgID)
+	if err != nil || len(reactors) != 0 {
+		t.Errorf("reactors = %+v, %v, want none", reactors, err)
+	}
+
+	resp, err := f.Chats.GetChat(ctx, chatID, "bob")
+	if err != nil {
+		t.Fatalf("get chat: %v", err)
+	}
+	if msg := resp.Messages[0]; len(msg.Reactions) != 0 || msg.MyReaction != nil {
+		t.Errorf("tombstone = %+v, want no reactions", msg)
+	}
+}
+
+func TestGetUserChats(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	older := f.createChat(t, domain.ChatTypeDirect, "alice", "bob")
+	newer := f.createChat(t, domain.ChatTypeGroup, "carol", "alice", "bob")
+	f.createChat(t, domain.ChatTypeDirect, "alice", "carol") // no messages, not listed
+
+	now := time.Now().UnixMilli()
+	for i, m := range []chat.Message{
+		{ChatID: "chats/" + older, SenderID: "users/bob", Text: "Hello", Status: domain.MessageStatusSent, CreatedAt: now - 2000},
+		{ChatID: "chats/" + newer, SenderID: "users/alice", Text: "Mine", Status: domain.MessageStatusSent, CreatedAt: now - 1000},
+		{ChatID: "chats/" + newer, SenderID: "users/carol", Text: "Latest", Status: domain.MessageStatusSeen, CreatedAt: now},
+	} {
+		if _, err := f.ChatRepo.CreateMessage(ctx, &m); err != nil {
+			t.Fatalf("create message %d: %v", i, err)
+		}
+	}
+
+	resp, err := f.Chats.GetUserChats(ctx, "alice", 0, "")
+	if err != nil {
+		t.Fatalf("get chats: %v", err)
+	}
+	if len(resp.Threads) != 2 || resp.Threads[0].ID != newer || resp.Threads[1].ID != older {
+		t.Fatalf("threads = %+v, want [%s %s]", resp.Threads, newer, older)
+	}
+
+	group, direct := resp.Threads[0], resp.Threads[1]
+	if group.LastMessage.Text != "Latest" || group.LastMessage.SenderID != "carol" || group.UnreadCount != 0 {
+		t.Errorf("group thread = %+v, want carol's seen message", group)
+	}
+	if len(group.Participants) != 3 || group.Partner.ID != "carol" {
+		t.Errorf("group participants = %+v, partner = %+v", group.Participants, group.Partner)
+	}
+	if direct.Partner.ID != "bob" || direct.Participants != nil || direct.UnreadCount != 1 || !direct.HasUnread {
+		t.Errorf("direct thread = %+v, want one unread from bob", direct)
+	}
+
+	t.Run("limit sets cursor", func(t *testing.T) {
+		resp, err := f.Chats.GetUserChats(ctx, "alice", 1, "")
+		if err != nil {
+			t.Fatalf("get chats: %v", err)
+		}
+		if len(resp.Threads) != 1 || resp.NextCursor == nil || *resp.NextCursor != fmt.Sprint(now) {
+			t.Errorf("threads = %d, cursor = %v, want one thread and cursor %d", len(resp.Threads), resp.NextCursor, now)
+		}
+	})
+}
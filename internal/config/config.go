@@ -1,45 +1,407 @@
+// Package config loads the server configuration from an optional TOML file
+// and environment variables, which override the file. Every setting has a
+// default, so a bare environment with ARANGO_DATABASE is enough to run.
 package config
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
-	Port     string
-	ArangoDB ArangoDBConfig
+	// IdempotencyTTL is how long responses are kept for Idempotency-Key retries
+	IdempotencyTTL time.Duration `toml:"idempotency_ttl"`
+
+	Server     ServerConfig     `toml:"server"`
+	ArangoDB   ArangoDBConfig   `toml:"arangodb"`
+	CORS       CORSConfig       `toml:"cors"`
+	Auth       AuthConfig       `toml:"auth"`
+	RateLimit  RateLimitConfig  `toml:"rate_limit"`
+	Tracing    TracingConfig    `toml:"tracing"`
+	Log        LogConfig        `toml:"log"`
+	Feed       FeedConfig       `toml:"feed"`
+	Classifier ClassifierConfig `toml:"classifier"`
+}
+
+// ServerConfig holds the HTTP server's address and timeouts
+type ServerConfig struct {
+	Port         string        `toml:"port"`
+	ReadTimeout  time.Duration `toml:"read_timeout"`
+	WriteTimeout time.Duration `toml:"write_timeout"`
+	IdleTimeout  time.Duration `toml:"idle_timeout"`
+	// ShutdownTimeout bounds finishing open requests on shutdown
+	ShutdownTimeout time.Duration `toml:"shutdown_timeout"`
+	// ShutdownDelay is how long readiness fails before the server stops
+	// accepting connections on shutdown
+	ShutdownDelay time.Duration `toml:"shutdown_delay"`
 }
 
 type ArangoDBConfig struct {
-	Endpoint string
-	Database string
-	Username string
-	Password string
+	Endpoint string `toml:"endpoint"`
+	Database string `toml:"database"`
+	Username string `toml:"username"`
+	Password string `toml:"password,secret"`
+	// SlowQueryThreshold is the duration above which queries are logged
+	SlowQueryThreshold time.Duration `toml:"slow_query_threshold"`
 }
 
-func Load() (*Config, error) {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+// CORSConfig lists the browser origins allowed to call the API
+type CORSConfig struct {
+	// AllowedOrigins are origins such as "https://askme.app", or "*"
+	AllowedOrigins []string `toml:"allowed_origins"`
+}
+
+// AuthConfig configures who the request user is and what they may do
+type AuthConfig struct {
+	// AdminUserIDs may use admin-only features such as ?profile=1
+	AdminUserIDs []string `toml:"admin_user_ids"`
+	// DefaultUserID is the user of requests without X-User-ID, unless
+	// RequireUser is set
+	DefaultUserID string `toml:"default_user_id"`
+	RequireUser   bool   `toml:"require_user"`
+	// TokenSecret is the signing key reserved for the session tokens that
+	// will replace the X-User-ID header; at least 32 bytes when set
+	TokenSecret string `toml:"token_secret,secret"`
+}
+
+// RateLimit allows Requests per Window per client; zero Requests disables it.
+// In files and environment variables it is written "REQUESTS/WINDOW", e.g.
+// "10/1m", or "0".
+type RateLimit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// UnmarshalText parses "REQUESTS/WINDOW" or "0"
+func (l *RateLimit) UnmarshalText(text []byte) error {
+	limit, err := parseRateLimit(string(text))
+	if err != nil {
+		return err
 	}
+	*l = limit
+	return nil
+}
 
-	arangoEndpoint := os.Getenv("ARANGO_ENDPOINT")
-	if arangoEndpoint == "" {
-		arangoEndpoint = "http://localhost:8529"
+// MarshalText formats l as "REQUESTS/WINDOW"
+func (l RateLimit) MarshalText() ([]byte, error) {
+	if l.Requests == 0 {
+		return []byte("0"), nil
 	}
+	return []byte(strconv.Itoa(l.Requests) + "/" + formatDuration(l.Window)), nil
+}
+
+type RateLimitConfig struct {
+	// Default applies to routes without an entry in Routes
+	Default RateLimit `toml:"default"`
+	// Routes maps route patterns, e.g. "POST /posts", to their limit
+	Routes map[string]RateLimit `toml:"routes"`
+}
 
-	arangoDB := os.Getenv("ARANGO_DATABASE")
-	if arangoDB == "" {
-		return nil, fmt.Errorf("ARANGO_DATABASE environment variable is required")
+// DefaultRateLimits guards the write routes open to spam and scripting
+func DefaultRateLimits() RateLimitConfig {
+	return RateLimitConfig{
+		Default: RateLimit{Requests: 300, Window: time.Minute},
+		Routes: map[string]RateLimit{
+			"POST /users":                      {Requests: 10, Window: time.Hour},
+			"POST /me/follow/{userId}":         {Requests: 30, Window: time.Minute},
+			"POST /posts":                      {Requests: 10, Window: time.Minute},
+			"POST /posts/poll":                 {Requests: 5, Window: time.Minute},
+			"POST /posts/{postId}/respond":     {Requests: 20, Window: time.Minute},
+			"POST /posts/{postId}/vote":        {Requests: 30, Window: time.Minute},
+			"POST /chats/{chatId}/message":     {Requests: 60, Window: time.Minute},
+			"POST /messages/{messageId}/react": {Requests: 60, Window: time.Minute},
+		},
 	}
+}
+
+// TracingConfig selects where request spans are exported
+type TracingConfig struct {
+	// Exporter is "none", "stdout" or "otlp"
+	Exporter string `toml:"exporter"`
+	// Endpoint is the base URL of the OTLP/HTTP collector
+	Endpoint    string `toml:"endpoint"`
+	ServiceName string `toml:"service_name"`
+	// SampleRatio is the fraction of new traces recorded, from 0 to 1
+	SampleRatio float64 `toml:"sample_ratio"`
+}
 
+// LogConfig selects the log output
+type LogConfig struct {
+	// Format is "json", "pretty" or "text"
+	Format string `toml:"format"`
+	// Level is the initial minimum level; admins can change it at runtime
+	Level slog.Level `toml:"level"`
+	// SampleFirst info records per message and second are logged, then one
+	// in SampleThereafter; zero SampleFirst disables sampling
+	SampleFirst      int `toml:"sample_first"`
+	SampleThereafter int `toml:"sample_thereafter"`
+	// RedactKeys are log attribute keys whose values are never written
+	RedactKeys []string `toml:"redact_keys"`
+}
+
+// DefaultRedactKeys keeps credentials and user-written text out of logs
+var DefaultRedactKeys = []string{"password", "token", "secret", "authorization", "cookie", "text"}
+
+// FeedConfig tunes feed ranking
+type FeedConfig struct {
+	Weights FeedWeights `toml:"weights"`
+}
+
+// FeedWeights score feed candidates: points per matching category and tag,
+// minus RecencyDecay points per day of age (capped at 100 days)
+type FeedWeights struct {
+	Category     float64 `toml:"category"`
+	Tag          float64 `toml:"tag"`
+	RecencyDecay float64 `toml:"recency_decay"`
+}
+
+// ClassifierConfig points at the AI service that classifies post text into
+// category, intent, depth and tags. Until it is called server-side, clients
+// send its output as aiRaw.
+type ClassifierConfig struct {
+	// Endpoint is the classifier's base URL; empty disables it
+	Endpoint string        `toml:"endpoint"`
+	Timeout  time.Duration `toml:"timeout"`
+	APIKey   string        `toml:"api_key,secret"`
+}
+
+// Default returns the configuration used for settings that neither the
+// file nor the environment set
+func Default() *Config {
 	return &Config{
-		Port: port,
+		IdempotencyTTL: 24 * time.Hour,
+		Server: ServerConfig{
+			Port:            "8080",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    15 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+			ShutdownDelay:   5 * time.Second,
+		},
 		ArangoDB: ArangoDBConfig{
-			Endpoint: arangoEndpoint,
-			Database: arangoDB,
-			Username: os.Getenv("ARANGO_USERNAME"),
-			Password: os.Getenv("ARANGO_PASSWORD"),
+			Endpoint:           "http://localhost:8529",
+			SlowQueryThreshold: 200 * time.Millisecond,
+		},
+		CORS:      CORSConfig{AllowedOrigins: []string{"*"}},
+		Auth:      AuthConfig{DefaultUserID: "u-johndoe"},
+		RateLimit: DefaultRateLimits(),
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			Endpoint:    "http://localhost:4318",
+			ServiceName: "askme-api",
+			SampleRatio: 1,
+		},
+		Log: LogConfig{
+			Format:     "json",
+			Level:      slog.LevelInfo,
+			RedactKeys: DefaultRedactKeys,
+		},
+		Feed: FeedConfig{
+			Weights: FeedWeights{Category: 40, Tag: 20, RecencyDecay: 0.1},
 		},
-	}, nil
+		Classifier: ClassifierConfig{Timeout: 5 * time.Second},
+	}
+}
+
+// envVars maps environment variables to the config keys they override
+var envVars = []struct{ name, key string }{
+	{"IDEMPOTENCY_TTL", "idempotency_ttl"},
+	{"PORT", "server.port"},
+	{"SERVER_READ_TIMEOUT", "server.read_timeout"},
+	{"SERVER_WRITE_TIMEOUT", "server.write_timeout"},
+	{"SERVER_IDLE_TIMEOUT", "server.idle_timeout"},
+	{"SHUTDOWN_TIMEOUT", "server.shutdown_timeout"},
+	{"SHUTDOWN_DELAY", "server.shutdown_delay"},
+	{"ARANGO_ENDPOINT", "arangodb.endpoint"},
+	{"ARANGO_DATABASE", "arangodb.database"},
+	{"ARANGO_USERNAME", "arangodb.username"},
+	{"ARANGO_PASSWORD", "arangodb.password"},
+	{"CORS_ALLOWED_ORIGINS", "cors.allowed_origins"},
+	{"ADMIN_USER_IDS", "auth.admin_user_ids"},
+	{"AUTH_DEFAULT_USER_ID", "auth.default_user_id"},
+	{"AUTH_REQUIRE_USER", "auth.require_user"},
+	{"AUTH_TOKEN_SECRET", "auth.token_secret"},
+	{"RATE_LIMIT_DEFAULT", "rate_limit.default"},
+	{"TRACE_EXPORTER", "tracing.exporter"},
+	{"OTEL_EXPORTER_OTLP_ENDPOINT", "tracing.endpoint"},
+	{"OTEL_SERVICE_NAME", "tracing.service_name"},
+	{"TRACE_SAMPLE_RATIO", "tracing.sample_ratio"},
+	{"LOG_FORMAT", "log.format"},
+	{"LOG_LEVEL", "log.level"},
+	{"LOG_REDACT_KEYS", "log.redact_keys"},
+	{"FEED_CATEGORY_WEIGHT", "feed.weights.category"},
+	{"FEED_TAG_WEIGHT", "feed.weights.tag"},
+	{"FEED_RECENCY_DECAY", "feed.weights.recency_decay"},
+	{"CLASSIFIER_ENDPOINT", "classifier.endpoint"},
+	{"CLASSIFIER_TIMEOUT", "classifier.timeout"},
+	{"CLASSIFIER_API_KEY", "classifier.api_key"},
+}
+
+// Load reads the file named by CONFIG_FILE, if any, and the environment,
+// and validates the result
+func Load() (*Config, error) {
+	return LoadFile(os.Getenv("CONFIG_FILE"))
+}
+
+// LoadFile is Load with an explicit file path; an empty path reads only the
+// environment
+func LoadFile(path string) (*Config, error) {
+	cfg, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Read builds the config from defaults, the file at path and the
+// environment, without validating it
+func Read(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read config file: %w", err)
+		}
+		values, err := parseTOML(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("config file %s: %w", path, err)
+		}
+		if err := decode(reflect.ValueOf(cfg).Elem(), values, ""); err != nil {
+			return nil, fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyEnv overrides cfg with the environment variables that are set
+func (c *Config) applyEnv() error {
+	for _, env := range envVars {
+		if v := os.Getenv(env.name); v != "" {
+			if err := setKey(reflect.ValueOf(c).Elem(), env.key, v); err != nil {
+				return fmt.Errorf("%s: %w", env.name, err)
+			}
+		}
+	}
+
+	if v := os.Getenv("ARANGO_SLOW_QUERY_MS"); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms < 0 {
+			return fmt.Errorf("ARANGO_SLOW_QUERY_MS must be a non-negative integer")
+		}
+		c.ArangoDB.SlowQueryThreshold = time.Duration(ms) * time.Millisecond
+	}
+
+	// RATE_LIMITS overrides single routes: "POST /posts=5/1m,POST /users=0"
+	for _, entry := range strings.Split(os.Getenv("RATE_LIMITS"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		pattern, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("RATE_LIMITS: %q must be PATTERN=REQUESTS/WINDOW", entry)
+		}
+		limit, err := parseRateLimit(value)
+		if err != nil {
+			return fmt.Errorf("RATE_LIMITS: %s: %w", pattern, err)
+		}
+		c.RateLimit.Routes[strings.TrimSpace(pattern)] = limit
+	}
+
+	// LOG_SAMPLING is "FIRST/THEREAFTER", e.g. "100/10", or "0"
+	if v := os.Getenv("LOG_SAMPLING"); v != "" {
+		c.Log.SampleFirst, c.Log.SampleThereafter = 0, 0
+		if v != "0" {
+			first, thereafter, ok := strings.Cut(v, "/")
+			f, err1 := strconv.Atoi(first)
+			t, err2 := strconv.Atoi(thereafter)
+			if !ok || err1 != nil || err2 != nil {
+				return fmt.Errorf("LOG_SAMPLING %q must be FIRST/THEREAFTER, e.g. 100/10", v)
+			}
+			c.Log.SampleFirst, c.Log.SampleThereafter = f, t
+		}
+	}
+	return nil
+}
+
+// setKey parses an environment value into the field at the dotted key.
+// Lists are comma-separated.
+func setKey(v reflect.Value, key, raw string) error {
+	for _, k := range strings.Split(key, ".") {
+		field, ok := fieldByKey(v, k)
+		if !ok {
+			return fmt.Errorf("unknown key %s", key)
+		}
+		v = field
+	}
+
+	var value any = raw
+	switch {
+	case reflect.PointerTo(v.Type()).Implements(textUnmarshalerType), v.Type() == durationType:
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q must be true or false", raw)
+		}
+		value = b
+	case v.Kind() == reflect.Int:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q must be an integer", raw)
+		}
+		value = n
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%q must be a number", raw)
+		}
+		value = f
+	case v.Kind() == reflect.Slice:
+		items := []any{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		value = items
+	}
+	return decodeScalar(v, value)
+}
+
+// parseRateLimit parses "REQUESTS/WINDOW", e.g. "10/1m", or "0" to disable
+func parseRateLimit(s string) (RateLimit, error) {
+	if s == "0" {
+		return RateLimit{}, nil
+	}
+	requests, window, ok := strings.Cut(s, "/")
+	n, err := strconv.Atoi(requests)
+	if !ok || err != nil || n < 0 {
+		return RateLimit{}, fmt.Errorf("%q must be REQUESTS/WINDOW, e.g. 10/1m", s)
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return RateLimit{}, fmt.Errorf("%q must have a positive window, e.g. 10/1m", s)
+	}
+	return RateLimit{Requests: n, Window: d}, nil
+}
+
+// redacted replaces secrets in Print output
+const redacted = "[REDACTED]"
+
+// Print writes c as a TOML config file with secrets redacted
+func (c *Config) Print(w io.Writer) error {
+	return encode(w, reflect.ValueOf(c).Elem(), "")
 }
@@ -0,0 +1,252 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv unsets every variable Read looks at, so the developer's shell
+// does not leak into the tests
+func clearEnv(t *testing.T) {
+	for _, env := range envVars {
+		t.Setenv(env.name, "")
+	}
+	for _, name := range []string{"ARANGO_SLOW_QUERY_MS", "RATE_LIMITS", "LOG_SAMPLING"} {
+		t.Setenv(name, "")
+	}
+}
+
+func writeFile(t *testing.T, src string) string {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseTOML(t *testing.T) {
+	got, err := parseTOML(`
+# top-level keys come before any table
+idempotency_ttl = "12h"
+
+[server]
+port = "9090"   # trailing comment
+read_timeout = 'C:\raw'
+
+[rate_limit.routes]
+"POST /posts" = "5/1m"
+
+[log]
+sample_first = 100
+sample_ratio = 0.5
+enabled = true
+redact_keys = [
+  "password",
+  "token", # comments inside arrays
+]
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"idempotency_ttl": "12h",
+		"server":          map[string]any{"port": "9090", "read_timeout": `C:\raw`},
+		"rate_limit":      map[string]any{"routes": map[string]any{"POST /posts": "5/1m"}},
+		"log": map[string]any{
+			"sample_first": int64(100),
+			"sample_ratio": 0.5,
+			"enabled":      true,
+			"redact_keys":  []any{"password", "token"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v\nwant %#v", got, want)
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"port = ", "line 1"},
+		{"[server]\nport = 8080\nport = 9090", "line 3"},
+		{"a = \"unterminated", "line 1"},
+		{"a = {b = 1}", "line 1"},
+		{"[[tables]]", "line 1"},
+		{"a = 1 b = 2", "line 1"},
+	}
+	for _, tt := range tests {
+		if _, err := parseTOML(tt.src); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseTOML(%q) error = %v, want %q", tt.src, err, tt.want)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, `
+[server]
+port = "9090"
+shutdown_delay = "0s"
+
+[arangodb]
+database = "askme"
+
+[rate_limit]
+default = "100/1m"
+
+[rate_limit.routes]
+"POST /posts" = "0"
+
+[feed.weights]
+tag = 10
+`)
+	t.Setenv("PORT", "7070")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://askme.app, http://localhost:3000")
+	t.Setenv("RATE_LIMITS", "POST /users=1/1h")
+
+	cfg, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The environment wins over the file, which wins over the defaults
+	if cfg.Server.Port != "7070" {
+		t.Errorf("port = %q", cfg.Server.Port)
+	}
+	if cfg.Server.ShutdownDelay != 0 || cfg.Server.ReadTimeout != 15*time.Second {
+		t.Errorf("server = %+v", cfg.Server)
+	}
+	if cfg.ArangoDB.Database != "askme" {
+		t.Errorf("database = %q", cfg.ArangoDB.Database)
+	}
+	if got := cfg.CORS.AllowedOrigins; !reflect.DeepEqual(got, []string{"https://askme.app", "http://localhost:3000"}) {
+		t.Errorf("origins = %q", got)
+	}
+	if cfg.RateLimit.Default != (RateLimit{Requests: 100, Window: time.Minute}) {
+		t.Errorf("default limit = %+v", cfg.RateLimit.Default)
+	}
+	// Route limits merge with the built-in ones
+	routes := cfg.RateLimit.Routes
+	if routes["POST /posts"] != (RateLimit{}) || routes["POST /users"] != (RateLimit{Requests: 1, Window: time.Hour}) ||
+		routes["POST /posts/poll"] != DefaultRateLimits().Routes["POST /posts/poll"] {
+		t.Errorf("routes = %+v", routes)
+	}
+	if cfg.Feed.Weights != (FeedWeights{Category: 40, Tag: 10, RecencyDecay: 0.1}) {
+		t.Errorf("weights = %+v", cfg.Feed.Weights)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		env  map[string]string
+		want string
+	}{
+		{"unknown key", "[server]\nprot = \"8080\"", nil, "unknown key server.prot"},
+		{"unknown table", "[sever]\nport = \"8080\"", nil, "unknown key sever"},
+		{"wrong type", "[server]\nread_timeout = 15", nil, "server.read_timeout"},
+		{"bad duration", "[server]\nread_timeout = \"fast\"", nil, "server.read_timeout"},
+		{"bad rate limit", "[rate_limit]\ndefault = \"lots\"", nil, "rate_limit.default"},
+		{"bad env", "", map[string]string{"AUTH_REQUIRE_USER": "maybe"}, "AUTH_REQUIRE_USER"},
+		{"bad sampling", "", map[string]string{"LOG_SAMPLING": "10"}, "LOG_SAMPLING"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			_, err := Read(writeFile(t, tt.src))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	cfg.ArangoDB.Database = "askme"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("defaults: %v", err)
+	}
+
+	cfg.Server.Port = "http"
+	cfg.ArangoDB.Database = ""
+	cfg.CORS.AllowedOrigins = []string{"https://askme.app/feed"}
+	cfg.Auth.DefaultUserID = ""
+	cfg.Auth.TokenSecret = "short"
+	cfg.Tracing.Exporter = "jaeger"
+	cfg.Log.Format = "xml"
+	cfg.Feed.Weights.Tag = -1
+
+	var verr *ValidationError
+	if err := cfg.Validate(); !errors.As(err, &verr) {
+		t.Fatalf("error = %v", err)
+	}
+	var keys []string
+	for _, p := range verr.Problems {
+		keys = append(keys, p[:strings.Index(p, ":")])
+	}
+	want := []string{
+		"server.port", "arangodb.database", "cors.allowed_origins", "auth.default_user_id",
+		"auth.token_secret", "tracing.exporter", "log.format", "feed.weights",
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("problems = %q", verr.Problems)
+	}
+}
+
+func TestPrint(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("ARANGO_DATABASE", "askme")
+	t.Setenv("ARANGO_PASSWORD", "hunter2")
+	t.Setenv("CLASSIFIER_API_KEY", "sk-live")
+	t.Setenv("RATE_LIMITS", "POST /posts=5/1m")
+	cfg, err := Read("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := cfg.Print(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "hunter2") || strings.Contains(out, "sk-live") {
+		t.Fatalf("secret printed:\n%s", out)
+	}
+	for _, want := range []string{
+		"password = \"[REDACTED]\"",
+		"api_key = \"[REDACTED]\"",
+		"idle_timeout = \"1m\"",
+		"\"POST /posts\" = \"5/1m\"",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	// The printed file reads back and prints the same, redacted secrets
+	// included
+	clearEnv(t)
+	got, err := Read(writeFile(t, out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again bytes.Buffer
+	if err := got.Print(&again); err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != out {
+		t.Errorf("round trip:\n%s\nwant:\n%s", again.String(), out)
+	}
+}
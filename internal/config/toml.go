@@ -0,0 +1,589 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// The config file format is the subset of TOML the config needs: tables,
+// key = value pairs with bare or quoted keys, basic and literal strings,
+// integers, floats, booleans and arrays of them, and # comments. Durations
+// are strings such as "15s". Multi-line strings, dotted keys, inline tables,
+// arrays of tables and dates are rejected.
+
+type tomlParser struct {
+	src  string
+	pos  int
+	line int
+}
+
+// parseTOML parses a config file into nested tables
+func parseTOML(src string) (map[string]any, error) {
+	p := &tomlParser{src: src, line: 1}
+	root := map[string]any{}
+	current := root
+	defined := map[string]bool{}
+
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return root, nil
+		}
+
+		if p.peek() == '[' {
+			path, err := p.parseTableHeader()
+			if err != nil {
+				return nil, err
+			}
+			name := strings.Join(path, ".")
+			if defined[name] {
+				return nil, p.errorf("table [%s] defined twice", name)
+			}
+			defined[name] = true
+			if current, err = p.table(root, path); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.eof() || p.peek() != '=' {
+			return nil, p.errorf("expected = after %q", key)
+		}
+		p.pos++
+		p.skipSpace()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := current[key]; ok {
+			return nil, p.errorf("key %q defined twice", key)
+		}
+		current[key] = value
+		if err := p.endOfLine(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *tomlParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *tomlParser) eof() bool  { return p.pos >= len(p.src) }
+func (p *tomlParser) peek() byte { return p.src[p.pos] }
+
+// skipSpace skips spaces and tabs
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipBlank skips whitespace, newlines and comments
+func (p *tomlParser) skipBlank() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\r':
+			p.pos++
+		case '\n':
+			p.pos++
+			p.line++
+		case '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// endOfLine expects only a comment before the next line
+func (p *tomlParser) endOfLine() error {
+	p.skipSpace()
+	if !p.eof() && p.peek() == '#' {
+		for !p.eof() && p.peek() != '\n' {
+			p.pos++
+		}
+	}
+	if !p.eof() && p.peek() == '\r' {
+		p.pos++
+	}
+	if p.eof() {
+		return nil
+	}
+	if p.peek() != '\n' {
+		return p.errorf("unexpected %q after value", p.peek())
+	}
+	return nil
+}
+
+func (p *tomlParser) parseTableHeader() ([]string, error) {
+	p.pos++ // [
+	if !p.eof() && p.peek() == '[' {
+		return nil, p.errorf("arrays of tables are not supported")
+	}
+	var path []string
+	for {
+		p.skipSpace()
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		path = append(path, key)
+		p.skipSpace()
+		if p.eof() {
+			return nil, p.errorf("unterminated table header")
+		}
+		switch p.peek() {
+		case '.':
+			p.pos++
+		case ']':
+			p.pos++
+			return path, p.endOfLine()
+		default:
+			return nil, p.errorf("unexpected %q in table header", p.peek())
+		}
+	}
+}
+
+// table returns the table at path, creating missing ones
+func (p *tomlParser) table(root map[string]any, path []string) (map[string]any, error) {
+	t := root
+	for i, key := range path {
+		next, ok := t[key]
+		if !ok {
+			next = map[string]any{}
+			t[key] = next
+		}
+		if t, ok = next.(map[string]any); !ok {
+			return nil, p.errorf("%s is a value, not a table", strings.Join(path[:i+1], "."))
+		}
+	}
+	return t, nil
+}
+
+func (p *tomlParser) parseKey() (string, error) {
+	if p.eof() {
+		return "", p.errorf("expected a key")
+	}
+	switch p.peek() {
+	case '"':
+		return p.parseBasicString()
+	case '\'':
+		return p.parseLiteralString()
+	}
+	start := p.pos
+	for !p.eof() && isBareKeyChar(p.peek()) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("unexpected %q, expected a key", p.peek())
+	}
+	return p.src[start:p.pos], nil
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *tomlParser) parseValue() (any, error) {
+	if p.eof() {
+		return nil, p.errorf("expected a value")
+	}
+	switch c := p.peek(); {
+	case c == '"':
+		if strings.HasPrefix(p.src[p.pos:], `"""`) {
+			return nil, p.errorf("multi-line strings are not supported")
+		}
+		return p.parseBasicString()
+	case c == '\'':
+		if strings.HasPrefix(p.src[p.pos:], `'''`) {
+			return nil, p.errorf("multi-line strings are not supported")
+		}
+		return p.parseLiteralString()
+	case c == '[':
+		return p.parseArray()
+	case c == '{':
+		return nil, p.errorf("inline tables are not supported")
+	}
+
+	start := p.pos
+	for !p.eof() && (isBareKeyChar(p.peek()) || strings.IndexByte("+.:", p.peek()) >= 0) {
+		p.pos++
+	}
+	token := p.src[start:p.pos]
+	switch token {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "":
+		return nil, p.errorf("unexpected %q, expected a value", p.peek())
+	}
+	number := strings.ReplaceAll(token, "_", "")
+	if n, err := strconv.ParseInt(number, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(number, 64); err == nil && !strings.ContainsAny(number, "xXpP") {
+		return f, nil
+	}
+	return nil, p.errorf("invalid value %q (strings must be quoted)", token)
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++ // "
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.peek()
+		p.pos++
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if p.eof() {
+				return "", p.errorf("unterminated string")
+			}
+			esc := p.peek()
+			p.pos++
+			switch esc {
+			case '"', '\\':
+				b.WriteByte(esc)
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case 'u', 'U':
+				size := 4
+				if esc == 'U' {
+					size = 8
+				}
+				if p.pos+size > len(p.src) {
+					return "", p.errorf("invalid escape \\%c", esc)
+				}
+				r, err := strconv.ParseUint(p.src[p.pos:p.pos+size], 16, 32)
+				if err != nil || !utf8.ValidRune(rune(r)) {
+					return "", p.errorf("invalid escape \\%c%s", esc, p.src[p.pos:p.pos+size])
+				}
+				b.WriteRune(rune(r))
+				p.pos += size
+			default:
+				return "", p.errorf("invalid escape \\%c", esc)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++ // '
+	start := p.pos
+	for !p.eof() && p.peek() != '\'' {
+		if p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		p.pos++
+	}
+	if p.eof() {
+		return "", p.errorf("unterminated string")
+	}
+	p.pos++
+	return p.src[start : p.pos-1], nil
+}
+
+func (p *tomlParser) parseArray() ([]any, error) {
+	p.pos++ // [
+	values := []any{}
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+		if p.peek() == ']' {
+			p.pos++
+			return values, nil
+		}
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		p.skipBlank()
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, p.errorf("unexpected %q in array", p.peek())
+		}
+	}
+}
+
+var (
+	durationType        = reflect.TypeFor[time.Duration]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// tomlKey returns the key of a struct field and whether it is a secret,
+// from a tag such as `toml:"password,secret"`
+func tomlKey(f reflect.StructField) (key string, secret bool) {
+	key, opts, _ := strings.Cut(f.Tag.Get("toml"), ",")
+	return key, opts == "secret"
+}
+
+// decode stores a parsed value in v, which keeps its value for keys the
+// file leaves out. Unknown keys are errors so typos are not ignored.
+func decode(v reflect.Value, value any, path string) error {
+	if v.Kind() != reflect.Struct || reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		if err := decodeScalar(v, value); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return nil
+	}
+
+	table, ok := value.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s: must be a table", path)
+	}
+	keys := make([]string, 0, len(table))
+	for k := range table {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		field, ok := fieldByKey(v, k)
+		if !ok {
+			return fmt.Errorf("unknown key %s", joinKey(path, k))
+		}
+		if err := decode(field, table[k], joinKey(path, k)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeScalar(v reflect.Value, value any) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("must be a string")
+		}
+		return u.UnmarshalText([]byte(s))
+	}
+	if v.Type() == durationType {
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf(`must be a duration string, e.g. "15s"`)
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("must be a string")
+		}
+		v.SetString(s)
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("must be true or false")
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		n, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("must be an integer")
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		switch n := value.(type) {
+		case float64:
+			v.SetFloat(n)
+		case int64:
+			v.SetFloat(float64(n))
+		default:
+			return fmt.Errorf("must be a number")
+		}
+	case reflect.Slice:
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("must be an array")
+		}
+		s := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := decodeScalar(s.Index(i), item); err != nil {
+				return fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+		v.Set(s)
+	case reflect.Map:
+		table, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("must be a table")
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		for k, item := range table {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := decodeScalar(elem, item); err != nil {
+				return fmt.Errorf("%q: %w", k, err)
+			}
+			v.SetMapIndex(reflect.ValueOf(k), elem)
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// fieldByKey finds the struct field of v tagged with key
+func fieldByKey(v reflect.Value, key string) (reflect.Value, bool) {
+	for i := range v.NumField() {
+		if k, _ := tomlKey(v.Type().Field(i)); k == key {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// encode writes v as TOML: its values first, then one table per struct or
+// map field. Non-empty secrets are written as redacted.
+func encode(w io.Writer, v reflect.Value, path string) error {
+	var tables []int
+	for i := range v.NumField() {
+		f := v.Type().Field(i)
+		key, secret := tomlKey(f)
+		fv := v.Field(i)
+		if isTable(fv) {
+			tables = append(tables, i)
+			continue
+		}
+		value := formatValue(fv)
+		if secret && !fv.IsZero() {
+			value = strconv.Quote(redacted)
+		}
+		if _, err := fmt.Fprintf(w, "%s = %s\n", formatKey(key), value); err != nil {
+			return err
+		}
+	}
+
+	for _, i := range tables {
+		key, _ := tomlKey(v.Type().Field(i))
+		fv := v.Field(i)
+		if _, err := fmt.Fprintf(w, "\n[%s]\n", joinKey(path, formatKey(key))); err != nil {
+			return err
+		}
+		if fv.Kind() == reflect.Map {
+			keys := fv.MapKeys()
+			slices.SortFunc(keys, func(a, b reflect.Value) int { return strings.Compare(a.String(), b.String()) })
+			for _, k := range keys {
+				if _, err := fmt.Fprintf(w, "%s = %s\n", formatKey(k.String()), formatValue(fv.MapIndex(k))); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := encode(w, fv, joinKey(path, formatKey(key))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isTable(v reflect.Value) bool {
+	if v.Kind() == reflect.Map {
+		return true
+	}
+	return v.Kind() == reflect.Struct && !v.Type().Implements(textMarshalerType)
+}
+
+func formatKey(key string) string {
+	for i := range len(key) {
+		if !isBareKeyChar(key[i]) {
+			return strconv.Quote(key)
+		}
+	}
+	return key
+}
+
+func formatValue(v reflect.Value) string {
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, _ := m.MarshalText()
+		return strconv.Quote(string(text))
+	}
+	if v.Type() == durationType {
+		return strconv.Quote(formatDuration(time.Duration(v.Int())))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Float64:
+		s := strconv.FormatFloat(v.Float(), 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = formatValue(v.Index(i))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return strconv.Quote(fmt.Sprint(v.Interface()))
+}
+
+// formatDuration drops the zero units time.Duration.String adds, e.g.
+// "1m" rather than "1m0s"
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
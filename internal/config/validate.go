@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ValidationError lists every invalid setting, by config key
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks every setting and reports all problems at once
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			problems = append(problems, key+": "+fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(c.IdempotencyTTL > 0, "idempotency_ttl", "must be positive")
+
+	port, err := strconv.Atoi(c.Server.Port)
+	check(err == nil && port > 0 && port < 65536, "server.port", "%q must be a port number", c.Server.Port)
+	check(c.Server.ReadTimeout > 0, "server.read_timeout", "must be positive")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout", "must be positive")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout", "must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout", "must be positive")
+	check(c.Server.ShutdownDelay >= 0, "server.shutdown_delay", "must not be negative")
+
+	check(isHTTPURL(c.ArangoDB.Endpoint), "arangodb.endpoint", "%q must be an http(s) URL", c.ArangoDB.Endpoint)
+	check(c.ArangoDB.Database != "", "arangodb.database", "is required (ARANGO_DATABASE)")
+	check(c.ArangoDB.SlowQueryThreshold >= 0, "arangodb.slow_query_threshold", "must not be negative")
+
+	check(len(c.CORS.AllowedOrigins) > 0, "cors.allowed_origins", "must list at least one origin, or \"*\"")
+	for _, origin := range c.CORS.AllowedOrigins {
+		check(origin == "*" || isOrigin(origin), "cors.allowed_origins",
+			"%q must be \"*\" or scheme://host[:port] without a path", origin)
+	}
+
+	check(c.Auth.DefaultUserID != "" || c.Auth.RequireUser, "auth.default_user_id", "is required unless auth.require_user is set")
+	check(c.Auth.TokenSecret == "" || len(c.Auth.TokenSecret) >= 32, "auth.token_secret", "must be at least 32 bytes")
+
+	check(validLimit(c.RateLimit.Default), "rate_limit.default", "must be 0 or a positive number of requests per positive window")
+	for pattern, limit := range c.RateLimit.Routes {
+		check(validLimit(limit), "rate_limit.routes", "%q must be 0 or a positive number of requests per positive window", pattern)
+	}
+
+	check(slices.Contains([]string{"none", "stdout", "otlp"}, c.Tracing.Exporter), "tracing.exporter",
+		"%q must be none, stdout or otlp", c.Tracing.Exporter)
+	check(c.Tracing.Exporter != "otlp" || isHTTPURL(c.Tracing.Endpoint), "tracing.endpoint",
+		"%q must be an http(s) URL", c.Tracing.Endpoint)
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio", "must be from 0 to 1")
+
+	check(slices.Contains([]string{"json", "pretty", "text"}, c.Log.Format), "log.format",
+		"%q must be json, pretty or text", c.Log.Format)
+	check(c.Log.SampleFirst >= 0 && c.Log.SampleThereafter >= 0, "log.sample_first", "sampling counts must not be negative")
+
+	w := c.Feed.Weights
+	check(w.Category >= 0 && w.Tag >= 0 && w.RecencyDecay >= 0, "feed.weights", "must not be negative")
+
+	check(c.Classifier.Endpoint == "" || isHTTPURL(c.Classifier.Endpoint), "classifier.endpoint",
+		"%q must be an http(s) URL", c.Classifier.Endpoint)
+	check(c.Classifier.Timeout > 0, "classifier.timeout", "must be positive")
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func validLimit(l RateLimit) bool {
+	return l.Requests == 0 || l.Requests > 0 && l.Window > 0
+}
+
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// isOrigin reports whether s is a browser origin, e.g. https://askme.app
+func isOrigin(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != "" && u.Path == "" &&
+		u.RawQuery == "" && u.User == nil
+}
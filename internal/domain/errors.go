@@ -1,13 +1,72 @@
 package domain
 
-import "errors"
+import "net/http"
+
+// Error is a domain error with a stable code that clients can branch on, the
+// HTTP status it maps to and a user-facing message. Specific errors refine a
+// general one and unwrap to it, so errors.Is(err, ErrForbidden) keeps
+// matching ErrNotAuthor as codes are added.
+type Error struct {
+	// Code is a stable snake_case identifier, e.g. "poll_closed"
+	Code    string
+	Status  int
+	Message string
+	// Meta carries details of this occurrence, e.g. the poll's closesAt
+	Meta map[string]any
+
+	parent error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the general error this one refines
+func (e *Error) Unwrap() error {
+	return e.parent
+}
+
+// Refine declares a more specific error with its own code and message and
+// the same status
+func (e *Error) Refine(code, message string) *Error {
+	return &Error{Code: code, Status: e.Status, Message: message, parent: e}
+}
+
+// With returns a copy of the error carrying a metadata entry. The copy
+// unwraps to e, so errors.Is still matches it.
+func (e *Error) With(key string, value any) *Error {
+	meta := make(map[string]any, len(e.Meta)+1)
+	for k, v := range e.Meta {
+		meta[k] = v
+	}
+	meta[key] = value
+	return &Error{Code: e.Code, Status: e.Status, Message: e.Message, Meta: meta, parent: e}
+}
 
 // Common domain errors
 var (
-	ErrNotFound       = errors.New("resource not found")
-	ErrAlreadyExists  = errors.New("resource already exists")
-	ErrInvalidInput   = errors.New("invalid input")
-	ErrUnauthorized   = errors.New("unauthorized")
-	ErrForbidden      = errors.New("forbidden")
-	ErrMutualFollowRequired = errors.New("mutual follow required for tagging")
+	ErrNotFound             = &Error{Code: "not_found", Status: http.StatusNotFound, Message: "resource not found"}
+	ErrAlreadyExists        = &Error{Code: "already_exists", Status: http.StatusConflict, Message: "resource already exists"}
+	ErrConflict             = &Error{Code: "conflict", Status: http.StatusConflict, Message: "concurrent update, try again"}
+	ErrInvalidInput         = &Error{Code: "invalid_input", Status: http.StatusBadRequest, Message: "invalid input"}
+	ErrUnauthorized         = &Error{Code: "unauthorized", Status: http.StatusUnauthorized, Message: "unauthorized"}
+	ErrForbidden            = &Error{Code: "forbidden", Status: http.StatusForbidden, Message: "forbidden"}
+	ErrMutualFollowRequired = ErrForbidden.Refine("mutual_follow_required", "mutual follow required for tagging")
+	ErrPollClosed           = &Error{Code: "poll_closed", Status: http.StatusConflict, Message: "poll is closed"}
+)
+
+// Specific domain errors
+var (
+	ErrAlreadyFollowing = ErrAlreadyExists.Refine("already_following", "already following this user")
+	ErrAlreadyResponded = ErrAlreadyExists.Refine("already_responded", "already responded to this post")
+	ErrOwnPost          = ErrInvalidInput.Refine("own_post", "cannot respond to your own post")
+	ErrNotAPoll         = ErrInvalidInput.Refine("not_a_poll", "post is not a poll")
+	ErrInvalidOption    = ErrInvalidInput.Refine("invalid_poll_option", "invalid poll option")
+	ErrTooManyOptions   = ErrInvalidInput.Refine("too_many_selections", "too many poll options selected")
+	ErrNotAuthor        = ErrForbidden.Refine("not_author", "only the author can modify a post")
+	ErrEditWindowClosed = ErrForbidden.Refine("edit_window_expired", "edit window has expired")
+	ErrNotParticipant   = ErrForbidden.Refine("not_participant", "not a participant of this chat")
+	ErrNotSender        = ErrForbidden.Refine("not_sender", "only the sender can modify a message")
+	ErrChatNotPending   = ErrInvalidInput.Refine("chat_not_pending", "chat already accepted or muted")
+	ErrBadReaction      = ErrInvalidInput.Refine("unsupported_reaction", "unsupported reaction")
 )
@@ -8,6 +8,24 @@ const (
 	PostTypePoll PostType = "poll"
 )
 
+// PollResultsVisibility defines when voters can see poll tallies
+type PollResultsVisibility string
+
+const (
+	ResultsAlways     PollResultsVisibility = "always"
+	ResultsAfterVote  PollResultsVisibility = "after_vote"
+	ResultsAfterClose PollResultsVisibility = "after_close"
+)
+
+// ValidResultsVisibility checks if a results visibility value is valid
+func ValidResultsVisibility(v string) bool {
+	switch PollResultsVisibility(v) {
+	case ResultsAlways, ResultsAfterVote, ResultsAfterClose:
+		return true
+	}
+	return false
+}
+
 // PostCategory defines what the post is about (backend enum)
 type PostCategory string
 
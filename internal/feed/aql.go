@@ -1,50 +1,56 @@
 package feed
 
+import "github.com/askme/api/pkg/arango"
+
 // AQL queries for feed operations
 const (
 	// GetRecommendedPosts retrieves personalized posts for a user's feed
 	GetRecommendedPosts = `
 		// Get user's interaction history for personalization
-		LET userTags = (
-			FOR edge IN responded
-			FILTER edge._from == @userId
-			FOR postEdge IN post_has_tag
-			FILTER postEdge._from == edge._to
-			RETURN DISTINCT postEdge._to
+		LET respondedPosts = (
+			FOR post IN 1..1 OUTBOUND @userId responded
+			RETURN post
+		)
+		
+		LET userTags = UNIQUE(
+			FOR post IN respondedPosts
+			FOR tag IN 1..1 OUTBOUND post post_has_tag
+			RETURN tag._key
 		)
 		
 		LET userCategories = (
-			FOR edge IN responded
-			FILTER edge._from == @userId
-			FOR post IN posts
-			FILTER post._id == edge._to
+			FOR post IN respondedPosts
 			COLLECT category = post.category WITH COUNT INTO cnt
 			SORT cnt DESC
 			LIMIT 5
 			RETURN category
 		)
 		
+		// Chats the user takes part in, matched to posts below
+		LET userChats = (
+			FOR c IN 1..1 OUTBOUND @userId participates_in
+			RETURN c
+		)
+		
 		// Get recommended posts
 		FOR p IN posts
+			// Skip soft-deleted posts
+			FILTER p.deletedAt == null
+			
 			// Filter by category if specified
 			FILTER @category == '' OR p.category == @category
 			FILTER @depth == '' OR p.depth == @depth
 			
 			// Get author
 			LET author = FIRST(
-				FOR edge IN created
-				FILTER edge._to == p._id
-				FOR user IN users
-				FILTER user._id == edge._from
+				FOR user IN 1..1 INBOUND p created
 				RETURN user
 			)
 			
 			// Check if user has a chat for this post
 			LET userChat = FIRST(
-				FOR c IN chats
+				FOR c IN userChats
 				FILTER c.postId == p._id
-				FOR edge IN participates_in
-				FILTER edge._from == @userId AND edge._to == c._id
 				RETURN c
 			)
 			
@@ -75,10 +81,7 @@ const (
 			
 			// Get tags
 			LET postTags = (
-				FOR edge IN post_has_tag
-				FILTER edge._from == p._id
-				FOR tag IN tags
-				FILTER tag._id == edge._to
+				FOR tag IN 1..1 OUTBOUND p post_has_tag
 				RETURN tag._key
 			)
 			
@@ -87,7 +90,7 @@ const (
 			LET categoryMatch = p.category IN userCategories ? 1 : 0
 			LET recency = (DATE_NOW() - p.createdAt) / (1000 * 60 * 60 * 24)
 			
-			LET score = (categoryMatch * 40) + (tagMatch * 20) + (100 - MIN([recency, 100]) * 0.1)
+			LET score = (categoryMatch * @categoryWeight) + (tagMatch * @tagWeight) + (100 - MIN([recency, 100]) * @recencyDecay)
 			
 			SORT score DESC, p.createdAt DESC
 			LIMIT @limit
@@ -112,6 +115,7 @@ const (
 					text: lastMsg.text,
 					senderId: LAST(SPLIT(lastMsg.senderId, "/")),
 					status: lastMsg.status,
+					deleted: lastMsg.deletedAt != null,
 					createdAt: lastMsg.createdAt,
 					myReaction: myReaction
 				} : null,
@@ -122,12 +126,8 @@ const (
 
 	// GetUserInteractionTags retrieves tags from posts user has interacted with
 	GetUserInteractionTags = `
-		FOR edge IN responded
-		FILTER edge._from == @userId
-		FOR postEdge IN post_has_tag
-		FILTER postEdge._from == edge._to
-		FOR tag IN tags
-		FILTER tag._id == postEdge._to
+		FOR post IN 1..1 OUTBOUND @userId responded
+		FOR tag IN 1..1 OUTBOUND post post_has_tag
 		COLLECT tagKey = tag._key WITH COUNT INTO cnt
 		SORT cnt DESC
 		LIMIT 20
@@ -136,10 +136,7 @@ const (
 
 	// GetUserCategories retrieves categories user frequently engages with
 	GetUserCategories = `
-		FOR edge IN responded
-		FILTER edge._from == @userId
-		FOR post IN posts
-		FILTER post._id == edge._to
+		FOR post IN 1..1 OUTBOUND @userId responded
 		COLLECT category = post.category WITH COUNT INTO cnt
 		SORT cnt DESC
 		LIMIT 5
@@ -148,13 +145,19 @@ const (
 
 	// GetUserIntents retrieves intents user frequently responds to
 	GetUserIntents = `
-		FOR edge IN responded
-		FILTER edge._from == @userId
-		FOR post IN posts
-		FILTER post._id == edge._to
+		FOR post IN 1..1 OUTBOUND @userId responded
 		COLLECT intent = post.intent WITH COUNT INTO cnt
 		SORT cnt DESC
 		LIMIT 10
 		RETURN intent
 	`
 )
+
+func init() {
+	arango.RegisterQueries("feed", map[string]string{
+		"GetRecommendedPosts":    GetRecommendedPosts,
+		"GetUserInteractionTags": GetUserInteractionTags,
+		"GetUserCategories":      GetUserCategories,
+		"GetUserIntents":         GetUserIntents,
+	})
+}
@@ -0,0 +1,164 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/askme/api/pkg/arango"
+	"github.com/askme/api/pkg/arango/arangotest"
+)
+
+func BenchmarkGetRecommendedPosts(b *testing.B) {
+	db := arangotest.DB(b)
+	ctx := context.Background()
+	bindVars := map[string]any{
+		"userId":   fmt.Sprintf("users/%s", arangotest.UserKey),
+		"limit":    20,
+		"category": "",
+		"depth":    "",
+
+		"categoryWeight": 40,
+		"tagWeight":      20,
+		"recencyDecay":   0.1,
+	}
+
+	b.Run("join", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := arango.Query[FeedItem](ctx, db, legacyGetRecommendedPosts, bindVars); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("traversal", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := arango.Query[FeedItem](ctx, db, GetRecommendedPosts, bindVars); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// legacyGetRecommendedPosts is the hand-joined query GetRecommendedPosts
+// replaced, kept as a baseline
+const legacyGetRecommendedPosts = `
+		// Get user's interaction history for personalization
+		LET userTags = (
+			FOR edge IN responded
+			FILTER edge._from == @userId
+			FOR postEdge IN post_has_tag
+			FILTER postEdge._from == edge._to
+			RETURN DISTINCT postEdge._to
+		)
+		
+		LET userCategories = (
+			FOR edge IN responded
+			FILTER edge._from == @userId
+			FOR post IN posts
+			FILTER post._id == edge._to
+			COLLECT category = post.category WITH COUNT INTO cnt
+			SORT cnt DESC
+			LIMIT 5
+			RETURN category
+		)
+		
+		// Get recommended posts
+		FOR p IN posts
+			// Skip soft-deleted posts
+			FILTER p.deletedAt == null
+			
+			// Filter by category if specified
+			FILTER @category == '' OR p.category == @category
+			FILTER @depth == '' OR p.depth == @depth
+			
+			// Get author
+			LET author = FIRST(
+				FOR edge IN created
+				FILTER edge._to == p._id
+				FOR user IN users
+				FILTER user._id == edge._from
+				RETURN user
+			)
+			
+			// Check if user has a chat for this post
+			LET userChat = FIRST(
+				FOR c IN chats
+				FILTER c.postId == p._id
+				FOR edge IN participates_in
+				FILTER edge._from == @userId AND edge._to == c._id
+				RETURN c
+			)
+			
+			// Get last message if chat exists
+			LET lastMsg = userChat ? FIRST(
+				FOR m IN messages
+				FILTER m.chatId == userChat._id
+				SORT m.createdAt DESC
+				LIMIT 1
+				RETURN m
+			) : null
+			
+			// Get user's reaction to last message (if any)
+			LET myReaction = lastMsg ? FIRST(
+				FOR e IN reacted
+				FILTER e._from == @userId AND e._to == lastMsg._id
+				RETURN e.emoji
+			) : null
+			
+			// Count unread messages (messages not from user and not seen)
+			LET unreadCount = userChat ? LENGTH(
+				FOR m IN messages
+				FILTER m.chatId == userChat._id
+				   AND m.senderId != @userId
+				   AND m.status != 'seen'
+				RETURN 1
+			) : 0
+			
+			// Get tags
+			LET postTags = (
+				FOR edge IN post_has_tag
+				FILTER edge._from == p._id
+				FOR tag IN tags
+				FILTER tag._id == edge._to
+				RETURN tag._key
+			)
+			
+			// Calculate relevance score
+			LET tagMatch = LENGTH(INTERSECTION(postTags, userTags))
+			LET categoryMatch = p.category IN userCategories ? 1 : 0
+			LET recency = (DATE_NOW() - p.createdAt) / (1000 * 60 * 60 * 24)
+			
+			LET score = (categoryMatch * @categoryWeight) + (tagMatch * @tagWeight) + (100 - MIN([recency, 100]) * @recencyDecay)
+			
+			SORT score DESC, p.createdAt DESC
+			LIMIT @limit
+			
+			RETURN {
+				id: p._key,
+				postType: p.postType,
+				text: p.text,
+				pollOptions: p.pollOptions,
+				category: p.category,
+				intent: p.intent,
+				depth: p.depth,
+				tags: postTags,
+				author: {
+					id: author._key,
+					username: author.username,
+					avatarUrl: author.avatarUrl
+				},
+				chatId: userChat ? userChat._key : null,
+				lastMessage: lastMsg ? {
+					id: lastMsg._key,
+					text: lastMsg.text,
+					senderId: LAST(SPLIT(lastMsg.senderId, "/")),
+					status: lastMsg.status,
+					deleted: lastMsg.deletedAt != null,
+					createdAt: lastMsg.createdAt,
+					myReaction: myReaction
+				} : null,
+				unreadCount: unreadCount,
+				createdAt: p.createdAt
+			}
+`
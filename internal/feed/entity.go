@@ -1,6 +1,9 @@
 package feed
 
-import "github.com/askme/api/internal/domain"
+import (
+	"github.com/askme/api/internal/domain"
+	"github.com/askme/api/internal/post"
+)
 
 // FeedAuthor represents author information in feed items
 type FeedAuthor struct {
@@ -15,6 +18,7 @@ type FeedLastMessage struct {
 	Text          string               `json:"text"`
 	SenderID      string               `json:"senderId"`
 	Status        domain.MessageStatus `json:"status"`
+	Deleted       bool                 `json:"deleted,omitempty"`
 	CreatedAt     int64                `json:"createdAt"`
 	FormattedTime string               `json:"formattedTime"`
 	MyReaction    *string              `json:"myReaction,omitempty"`
@@ -25,7 +29,7 @@ type FeedItem struct {
 	ID          string              `json:"id"`
 	PostType    domain.PostType     `json:"postType"`
 	Text        string              `json:"text"`
-	PollOptions []string            `json:"pollOptions,omitempty"`
+	PollOptions []post.PollOption   `json:"pollOptions,omitempty"`
 	Category    domain.PostCategory `json:"category"`
 	Intent      string              `json:"intent"`
 	Depth       domain.PostDepth    `json:"depth"`
@@ -50,4 +54,14 @@ type FeedQuery struct {
 	Cursor   string
 	Category string
 	Depth    string
+	// Weights rank the candidates; the service sets them
+	Weights Weights
+}
+
+// Weights score feed candidates: points per matching category and tag,
+// minus RecencyDecay points per day of age (capped at 100 days)
+type Weights struct {
+	Category     float64
+	Tag          float64
+	RecencyDecay float64
 }
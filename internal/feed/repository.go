@@ -17,14 +17,25 @@ func NewRepository(db *arango.Client) Repository {
 }
 
 func (r *repository) GetRecommendedPosts(ctx context.Context, query FeedQuery) ([]FeedItem, string, error) {
-	items, err := arango.Query[FeedItem](ctx, r.db, GetRecommendedPosts, map[string]any{
+	bindVars := map[string]any{
 		"userId":   fmt.Sprintf("users/%s", query.UserID),
 		"limit":    query.Limit,
 		"category": query.Category,
 		"depth":    query.Depth,
-	})
-	if err != nil {
-		return nil, "", err
+
+		"categoryWeight": query.Weights.Category,
+		"tagWeight":      query.Weights.Tag,
+		"recencyDecay":   query.Weights.RecencyDecay,
+	}
+
+	// The feed scores every candidate post; streaming it in one batch of a
+	// page logs its scan stats, so a growing posts collection shows up
+	items := make([]FeedItem, 0, query.Limit)
+	for item, err := range arango.Stream[FeedItem](ctx, r.db, GetRecommendedPosts, bindVars, &arango.StreamOptions{BatchSize: query.Limit}) {
+		if err != nil {
+			return nil, "", err
+		}
+		items = append(items, item)
 	}
 
 	var nextCursor string
@@ -3,6 +3,7 @@ package feed
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"golang.org/x/sync/errgroup"
@@ -10,24 +11,30 @@ import (
 	"github.com/askme/api/internal/chat"
 	"github.com/askme/api/internal/domain"
 	"github.com/askme/api/internal/post"
+	"github.com/askme/api/pkg/trace"
 )
 
 type service struct {
 	repo     Repository
 	postRepo post.Repository
 	chatRepo chat.Repository
+	weights  Weights
 }
 
-// NewService creates a new feed service
-func NewService(repo Repository, postRepo post.Repository, chatRepo chat.Repository) Service {
+// NewService creates a new feed service ranking posts with weights
+func NewService(repo Repository, postRepo post.Repository, chatRepo chat.Repository, weights Weights) Service {
 	return &service{
 		repo:     repo,
 		postRepo: postRepo,
 		chatRepo: chatRepo,
+		weights:  weights,
 	}
 }
 
 func (s *service) GetFeed(ctx context.Context, query FeedQuery) (*FeedResponse, error) {
+	ctx, span := trace.Start(ctx, "feed.Service.GetFeed")
+	defer span.End()
+
 	if query.Limit <= 0 {
 		query.Limit = 20
 	}
@@ -70,20 +77,26 @@ func (s *service) GetFeed(ctx context.Context, query FeedQuery) (*FeedResponse,
 	}
 
 	// Log preferences for debugging (can be used for more sophisticated ranking)
-	_ = userTags
-	_ = userCategories
-	_ = userIntents
+	slog.DebugContext(ctx, "feed preferences",
+		"tags", len(userTags),
+		"categories", len(userCategories),
+		"intents", len(userIntents),
+	)
 
 	// Get recommended posts
+	query.Weights = s.weights
 	items, nextCursor, err := s.repo.GetRecommendedPosts(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("get recommended posts: %w", err)
 	}
 
-	// Format timestamps
+	// Format timestamps and render tombstones
 	for i := range items {
 		if items[i].LastMessage != nil {
 			items[i].LastMessage.FormattedTime = formatTime(items[i].LastMessage.CreatedAt)
+			if items[i].LastMessage.Deleted {
+				items[i].LastMessage.Text = chat.DeletedMessageText
+			}
 		}
 	}
 
@@ -0,0 +1,177 @@
+package feed_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/askme/api/internal/chat"
+	"github.com/askme/api/internal/domain"
+	"github.com/askme/api/internal/feed"
+	"github.com/askme/api/internal/memrepo"
+	"github.com/askme/api/internal/post"
+)
+
+// fixture adds feed helpers to the shared seeded fixture
+type fixture struct {
+	*memrepo.Fixture
+}
+
+func newFixture(t *testing.T) *fixture {
+	return &fixture{memrepo.Seed(t)}
+}
+
+// createPosts creates n posts by alice, each a minute older than the last
+func (f *fixture) createPosts(t *testing.T, n int, category domain.PostCategory) []string {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now()
+
+	keys := make([]string, n)
+	for i := range n {
+		createdAt := now.Add(-time.Duration(i) * time.Minute).UnixMilli()
+		key, err := f.PostRepo.Create(ctx, &post.Post{
+			AuthorID:  "alice",
+			PostType:  domain.PostTypeText,
+			Text:      fmt.Sprintf("%s question %d", category, i),
+			Category:  category,
+			Depth:     domain.DepthNeutral,
+			CreatedAt: createdAt,
+		})
+		if err != nil {
+			t.Fatalf("create post: %v", err)
+		}
+		if err := f.PostRepo.CreateCreatedEdge(ctx, "alice", key, createdAt); err != nil {
+			t.Fatalf("create created edge: %v", err)
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+func TestGetFeedLimits(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	f.createPosts(t, 60, domain.CategoryTech)
+
+	tests := []struct {
+		name       string
+		limit      int
+		wantLen    int
+		wantCursor bool
+	}{
+		{"default", 0, 20, true},
+		{"negative", -1, 20, true},
+		{"explicit", 5, 5, true},
+		{"capped", 500, 50, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := f.Feed.GetFeed(ctx, feed.FeedQuery{UserID: "bob", Limit: tt.limit})
+			if err != nil {
+				t.Fatalf("get feed: %v", err)
+			}
+			if len(resp.Items) != tt.wantLen {
+				t.Errorf("len = %d, want %d", len(resp.Items), tt.wantLen)
+			}
+			if (resp.NextCursor != nil) != tt.wantCursor {
+				t.Errorf("cursor = %v, want set: %v", resp.NextCursor, tt.wantCursor)
+			}
+			if resp.NextCursor != nil && *resp.NextCursor != fmt.Sprint(resp.Items[len(resp.Items)-1].CreatedAt) {
+				t.Errorf("cursor = %s, want the last item's createdAt", *resp.NextCursor)
+			}
+		})
+	}
+
+	t.Run("short last page", func(t *testing.T) {
+		small := newFixture(t)
+		small.createPosts(t, 3, domain.CategoryTech)
+		resp, err := small.Feed.GetFeed(ctx, feed.FeedQuery{UserID: "bob", Limit: 10})
+		if err != nil {
+			t.Fatalf("get feed: %v", err)
+		}
+		if len(resp.Items) != 3 || resp.NextCursor != nil {
+			t.Errorf("got %d items, cursor %v, want 3 and no cursor", len(resp.Items), resp.NextCursor)
+		}
+	})
+}
+
+func TestGetFeedFilters(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	f.createPosts(t, 3, domain.CategoryTech)
+	career := f.createPosts(t, 2, domain.CategoryCareer)
+
+	resp, err := f.Feed.GetFeed(ctx, feed.FeedQuery{UserID: "bob", Category: "career"})
+	if err != nil {
+		t.Fatalf("get feed: %v", err)
+	}
+	if len(resp.Items) != 2 {
+		t.Errorf("career feed has %d items, want 2", len(resp.Items))
+	}
+
+	resp, err = f.Feed.GetFeed(ctx, feed.FeedQuery{UserID: "bob", Category: "astrology"})
+	if err != nil {
+		t.Fatalf("get feed: %v", err)
+	}
+	if len(resp.Items) != 5 {
+		t.Errorf("invalid category returned %d items, want the unfiltered 5", len(resp.Items))
+	}
+
+	if _, err := f.Posts.DeletePost(ctx, career[0], "alice"); err != nil {
+		t.Fatalf("delete post: %v", err)
+	}
+	resp, err = f.Feed.GetFeed(ctx, feed.FeedQuery{UserID: "bob"})
+	if err != nil {
+		t.Fatalf("get feed: %v", err)
+	}
+	for _, item := range resp.Items {
+		if item.ID == career[0] {
+			t.Errorf("deleted post %s is still in the feed", item.ID)
+		}
+	}
+}
+
+func TestGetFeedChatPreview(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	tech := f.createPosts(t, 3, domain.CategoryTech)
+	career := f.createPosts(t, 1, domain.CategoryCareer)
+
+	resp, err := f.Posts.RespondToPost(ctx, career[0], &post.RespondToPostRequest{UserID: "bob", Text: "Try a bootcamp"})
+	if err != nil {
+		t.Fatalf("respond: %v", err)
+	}
+	if _, err := f.Chats.DeleteMessage(ctx, resp.MessageID, "bob"); err != nil {
+		t.Fatalf("delete message: %v", err)
+	}
+
+	got, err := f.Feed.GetFeed(ctx, feed.FeedQuery{UserID: "bob"})
+	if err != nil {
+		t.Fatalf("get feed: %v", err)
+	}
+	if len(got.Items) != len(tech)+1 {
+		t.Fatalf("got %d items, want %d", len(got.Items), len(tech)+1)
+	}
+
+	// Responding to a career post ranks career posts first
+	first := got.Items[0]
+	if first.ID != career[0] {
+		t.Fatalf("first item = %s, want the career post %s", first.ID, career[0])
+	}
+	if first.ChatID == nil || *first.ChatID != resp.ChatID {
+		t.Errorf("chatId = %v, want %s", first.ChatID, resp.ChatID)
+	}
+	if first.LastMessage == nil || !first.LastMessage.Deleted || first.LastMessage.Text != chat.DeletedMessageText {
+		t.Errorf("last message = %+v, want a tombstone", first.LastMessage)
+	}
+	if first.Author.ID != "alice" {
+		t.Errorf("author = %+v, want alice", first.Author)
+	}
+	for _, item := range got.Items[1:] {
+		if item.ChatID != nil || item.LastMessage != nil {
+			t.Errorf("item %s has chat state without a chat", item.ID)
+		}
+	}
+}
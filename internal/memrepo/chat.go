@@ -0,0 +1,412 @@
+package memrepo
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/askme/api/internal/chat"
+	"github.com/askme/api/internal/domain"
+	"github.com/askme/api/internal/user"
+)
+
+type chatRepository struct {
+	store *Store
+}
+
+// NewChatRepository creates an in-memory chat repository
+func NewChatRepository(store *Store) chat.Repository {
+	return &chatRepository{store: store}
+}
+
+func (r *chatRepository) GetByID(ctx context.Context, id string) (*chat.Chat, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return clone(r.store.chat(id)), nil
+}
+
+func (r *chatRepository) Create(ctx context.Context, c *chat.Chat) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	doc := *c
+	doc.Key = r.store.nextKey(doc.Key)
+	if r.store.chat(doc.Key) != nil {
+		return "", errDuplicate("chats", doc.Key)
+	}
+	r.store.chats = append(r.store.chats, doc)
+	return doc.Key, nil
+}
+
+func (r *chatRepository) CreateMessage(ctx context.Context, msg *chat.Message) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	doc := *msg
+	doc.Key = r.store.nextKey(doc.Key)
+	if r.store.message(doc.Key) != nil {
+		return "", errDuplicate("messages", doc.Key)
+	}
+	doc.Revisions = slices.Clone(doc.Revisions)
+	r.store.messages = append(r.store.messages, doc)
+	return doc.Key, nil
+}
+
+func (r *chatRepository) GetMessageByID(ctx context.Context, id string) (*chat.Message, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return cloneMessage(r.store.message(id)), nil
+}
+
+func (r *chatRepository) GetMessagesByIDs(ctx context.Context, ids []string) ([]chat.Message, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return r.store.messagesWhere(func(m chat.Message) bool { return slices.Contains(ids, m.Key) }), nil
+}
+
+func (r *chatRepository) GetReplies(ctx context.Context, messageID string, limit, offset int) ([]chat.Message, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	id := fmt.Sprintf("messages/%s", messageID)
+	replies := r.store.messagesWhere(func(m chat.Message) bool { return m.ReplyToID == id })
+	sortByCreatedAt(replies)
+	return page(replies, offset, limit), nil
+}
+
+func (r *chatRepository) EditMessage(ctx context.Context, msgID, text string, editedAt int64, revision chat.MessageRevision) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if m := r.store.message(msgID); m != nil {
+		m.Text = text
+		m.EditedAt = editedAt
+		m.Revisions = append(slices.Clone(m.Revisions), revision)
+	}
+	return nil
+}
+
+func (r *chatRepository) TombstoneMessage(ctx context.Context, msgID string, deletedAt int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if m := r.store.message(msgID); m != nil {
+		m.Text = ""
+		m.Revisions = nil
+		m.DeletedAt = deletedAt
+	}
+	return nil
+}
+
+func (r *chatRepository) GetMessages(ctx context.Context, chatID string, limit, offset int) ([]chat.Message, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	id := fmt.Sprintf("chats/%s", chatID)
+	messages := r.store.messagesWhere(func(m chat.Message) bool { return m.ChatID == id })
+	sortByCreatedAt(messages)
+	return page(messages, offset, limit), nil
+}
+
+func (r *chatRepository) UpdateMessageStatus(ctx context.Context, msgID string, status domain.MessageStatus) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if m := r.store.message(msgID); m != nil {
+		m.Status = status
+	}
+	return nil
+}
+
+func (r *chatRepository) GetUnreadCount(ctx context.Context, chatID, userID string) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return r.store.unreadCount(fmt.Sprintf("chats/%s", chatID), fmt.Sprintf("users/%s", userID)), nil
+}
+
+func (r *chatRepository) CreateParticipation(ctx context.Context, edge *chat.ParticipatesInEdge) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.participates = append(r.store.participates, *edge)
+	return nil
+}
+
+func (r *chatRepository) UpdateParticipation(ctx context.Context, userID, chatID string, status domain.ParticipantStatus, notificationsEnabled bool, joinedAt *int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if e := r.store.participation(fmt.Sprintf("users/%s", userID), fmt.Sprintf("chats/%s", chatID)); e != nil {
+		e.Status = status
+		e.NotificationsEnabled = notificationsEnabled
+		e.JoinedAt = joinedAt
+	}
+	return nil
+}
+
+func (r *chatRepository) GetParticipation(ctx context.Context, userID, chatID string) (*chat.ParticipatesInEdge, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return clone(r.store.participation(fmt.Sprintf("users/%s", userID), fmt.Sprintf("chats/%s", chatID))), nil
+}
+
+func (r *chatRepository) GetParticipants(ctx context.Context, chatID string) ([]chat.Participant, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return r.store.participants(fmt.Sprintf("chats/%s", chatID)), nil
+}
+
+func (r *chatRepository) GetUserChatThreads(ctx context.Context, userID string, limit int, cursor string) ([]chat.ChatThread, string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	id := fmt.Sprintf("users/%s", userID)
+	var threads []chat.ChatThread
+	for _, edge := range r.store.participates {
+		if edge.From != id {
+			continue
+		}
+		c := r.store.chat(keyOf(edge.To))
+		if c == nil {
+			continue
+		}
+		chatID := fmt.Sprintf("chats/%s", c.Key)
+
+		lastMsg := r.store.lastMessage(chatID)
+		if lastMsg == nil {
+			continue
+		}
+
+		thread := chat.ChatThread{
+			ID:   c.Key,
+			Type: c.Type,
+			LastMessage: chat.LastMessage{
+				ID:        lastMsg.Key,
+				Text:      lastMsg.Text,
+				SenderID:  keyOf(lastMsg.SenderID),
+				Deleted:   lastMsg.IsDeleted(),
+				CreatedAt: lastMsg.CreatedAt,
+			},
+		}
+		if p := r.store.post(keyOf(c.PostID)); p != nil {
+			thread.Question = chat.QuestionContext{
+				ID:        p.Key,
+				Text:      p.Text,
+				AuthorID:  keyOf(p.AuthorID),
+				CreatedAt: p.CreatedAt,
+			}
+		}
+		participants := r.store.participants(chatID)
+		for _, member := range participants {
+			if member.ID != userID {
+				thread.Partner = chat.ChatPartner{ID: member.ID, Username: member.Username}
+				break
+			}
+		}
+		if c.Type == domain.ChatTypeGroup {
+			thread.Participants = participants
+		}
+		thread.UnreadCount = r.store.unreadCount(chatID, id)
+		thread.HasUnread = thread.UnreadCount > 0
+		threads = append(threads, thread)
+	}
+
+	slices.SortStableFunc(threads, func(a, b chat.ChatThread) int {
+		return cmp.Compare(b.LastMessage.CreatedAt, a.LastMessage.CreatedAt)
+	})
+	threads = page(threads, 0, limit)
+
+	var nextCursor string
+	if len(threads) == limit && len(threads) > 0 {
+		nextCursor = fmt.Sprintf("%d", threads[len(threads)-1].LastMessage.CreatedAt)
+	}
+	return threads, nextCursor, nil
+}
+
+func (r *chatRepository) GetChatForPostAndUser(ctx context.Context, postID, userID string) (*chat.Chat, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	from := fmt.Sprintf("users/%s", userID)
+	postDocID := fmt.Sprintf("posts/%s", postID)
+	for _, c := range r.store.chats {
+		if c.PostID == postDocID && r.store.participation(from, fmt.Sprintf("chats/%s", c.Key)) != nil {
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *chatRepository) GetReaction(ctx context.Context, userID, messageID string) (*chat.ReactedEdge, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return clone(r.store.reaction(fmt.Sprintf("users/%s", userID), fmt.Sprintf("messages/%s", messageID))), nil
+}
+
+func (r *chatRepository) UpsertReaction(ctx context.Context, edge *chat.ReactedEdge) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if existing := r.store.reaction(edge.From, edge.To); existing != nil {
+		existing.Emoji = edge.Emoji
+		existing.CreatedAt = edge.CreatedAt
+		return nil
+	}
+	r.store.reacted = append(r.store.reacted, *edge)
+	return nil
+}
+
+func (r *chatRepository) DeleteReaction(ctx context.Context, userID, messageID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	from, to := fmt.Sprintf("users/%s", userID), fmt.Sprintf("messages/%s", messageID)
+	r.store.reacted = slices.DeleteFunc(r.store.reacted, func(e chat.ReactedEdge) bool {
+		return e.From == from && e.To == to
+	})
+	return nil
+}
+
+func (r *chatRepository) GetReactionCounts(ctx context.Context, messageIDs []string) ([]chat.MessageReactionCount, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	type group struct{ messageID, emoji string }
+	counts := make(map[group]int)
+	for _, e := range r.store.reacted {
+		if slices.Contains(messageIDs, keyOf(e.To)) && !r.store.unsent(keyOf(e.To)) {
+			counts[group{keyOf(e.To), e.Emoji}]++
+		}
+	}
+
+	// COLLECT returns groups sorted by their keys
+	result := make([]chat.MessageReactionCount, 0, len(counts))
+	for g, count := range counts {
+		result = append(result, chat.MessageReactionCount{MessageID: g.messageID, Emoji: g.emoji, Count: count})
+	}
+	slices.SortFunc(result, func(a, b chat.MessageReactionCount) int {
+		return cmp.Or(strings.Compare(a.MessageID, b.MessageID), strings.Compare(a.Emoji, b.Emoji))
+	})
+	return result, nil
+}
+
+func (r *chatRepository) GetUserReactions(ctx context.Context, userID string, messageIDs []string) ([]chat.ReactedEdge, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	from := fmt.Sprintf("users/%s", userID)
+	var edges []chat.ReactedEdge
+	for _, e := range r.store.reacted {
+		if e.From == from && slices.Contains(messageIDs, keyOf(e.To)) && !r.store.unsent(keyOf(e.To)) {
+			edges = append(edges, e)
+		}
+	}
+	return edges, nil
+}
+
+func (r *chatRepository) GetReactors(ctx context.Context, messageID string) ([]chat.Reactor, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	to := fmt.Sprintf("messages/%s", messageID)
+	var reactors []chat.Reactor
+	for _, e := range r.store.reacted {
+		if e.To != to || r.store.unsent(messageID) {
+			continue
+		}
+		reactor := chat.Reactor{Emoji: e.Emoji, CreatedAt: e.CreatedAt}
+		if u := r.store.user(keyOf(e.From)); u != nil {
+			reactor.UserID = u.Key
+			reactor.Username = u.Username
+		}
+		reactors = append(reactors, reactor)
+	}
+	slices.SortStableFunc(reactors, func(a, b chat.Reactor) int {
+		return cmp.Compare(a.CreatedAt, b.CreatedAt)
+	})
+	return reactors, nil
+}
+
+func (s *Store) chat(key string) *chat.Chat {
+	return find(s.chats, func(c chat.Chat) bool { return c.Key == key })
+}
+
+func (s *Store) message(key string) *chat.Message {
+	return find(s.messages, func(m chat.Message) bool { return m.Key == key })
+}
+
+// unsent reports whether a message has been unsent (tombstoned)
+func (s *Store) unsent(messageKey string) bool {
+	msg := s.message(messageKey)
+	return msg != nil && msg.IsDeleted()
+}
+
+func (s *Store) user(key string) *user.User {
+	return find(s.users, func(u user.User) bool { return u.Key == key })
+}
+
+func (s *Store) participation(from, to string) *chat.ParticipatesInEdge {
+	return find(s.participates, func(e chat.ParticipatesInEdge) bool { return e.From == from && e.To == to })
+}
+
+func (s *Store) reaction(from, to string) *chat.ReactedEdge {
+	return find(s.reacted, func(e chat.ReactedEdge) bool { return e.From == from && e.To == to })
+}
+
+// messagesWhere returns copies of the messages matching keep
+func (s *Store) messagesWhere(keep func(chat.Message) bool) []chat.Message {
+	var messages []chat.Message
+	for i := range s.messages {
+		if keep(s.messages[i]) {
+			messages = append(messages, *cloneMessage(&s.messages[i]))
+		}
+	}
+	return messages
+}
+
+// participants follows the inbound participates_in edges of a chat to its users
+func (s *Store) participants(chatID string) []chat.Participant {
+	var participants []chat.Participant
+	for _, e := range s.participates {
+		if e.To != chatID {
+			continue
+		}
+		u := s.user(keyOf(e.From))
+		if u == nil {
+			continue
+		}
+		participants = append(participants, chat.Participant{
+			ID:       u.Key,
+			Username: u.Username,
+			Role:     e.Role,
+			Status:   e.Status,
+		})
+	}
+	return participants
+}
+
+func (s *Store) unreadCount(chatID, userID string) int {
+	count := 0
+	for _, m := range s.messages {
+		if m.ChatID == chatID && m.SenderID != userID && m.Status != domain.MessageStatusSeen {
+			count++
+		}
+	}
+	return count
+}
+
+func cloneMessage(m *chat.Message) *chat.Message {
+	c := clone(m)
+	if c != nil {
+		c.Revisions = slices.Clone(c.Revisions)
+	}
+	return c
+}
+
+func sortByCreatedAt(messages []chat.Message) {
+	slices.SortStableFunc(messages, func(a, b chat.Message) int {
+		return cmp.Compare(a.CreatedAt, b.CreatedAt)
+	})
+}
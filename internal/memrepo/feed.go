@@ -0,0 +1,208 @@
+package memrepo
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/askme/api/internal/chat"
+	"github.com/askme/api/internal/feed"
+	"github.com/askme/api/internal/post"
+)
+
+type feedRepository struct {
+	store *Store
+}
+
+// NewFeedRepository creates an in-memory feed repository over the posts,
+// chats and tags in store
+func NewFeedRepository(store *Store) feed.Repository {
+	return &feedRepository{store: store}
+}
+
+func (r *feedRepository) GetRecommendedPosts(ctx context.Context, query feed.FeedQuery) ([]feed.FeedItem, string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	userID := fmt.Sprintf("users/%s", query.UserID)
+	responded := r.store.respondedPosts(userID)
+
+	var userTags []string
+	for _, p := range responded {
+		for _, key := range r.store.postTags(fmt.Sprintf("posts/%s", p.Key)) {
+			if !slices.Contains(userTags, key) {
+				userTags = append(userTags, key)
+			}
+		}
+	}
+	userCategories := topByCount(responded, func(p post.Post) string { return string(p.Category) }, 5)
+	now := time.Now().UnixMilli()
+
+	type scored struct {
+		item  feed.FeedItem
+		score float64
+	}
+	var candidates []scored
+	for _, p := range r.store.posts {
+		if p.IsDeleted() {
+			continue
+		}
+		if query.Category != "" && string(p.Category) != query.Category {
+			continue
+		}
+		if query.Depth != "" && string(p.Depth) != query.Depth {
+			continue
+		}
+
+		postID := fmt.Sprintf("posts/%s", p.Key)
+		item := feed.FeedItem{
+			ID:          p.Key,
+			PostType:    p.PostType,
+			Text:        p.Text,
+			PollOptions: slices.Clone(p.PollOptions),
+			Category:    p.Category,
+			Intent:      p.Intent,
+			Depth:       p.Depth,
+			Tags:        r.store.postTags(postID),
+			CreatedAt:   p.CreatedAt,
+		}
+		if author := r.store.author(postID); author != nil {
+			item.Author = feed.FeedAuthor{ID: author.Key, Username: author.Username}
+		}
+		if c := r.store.userChatForPost(userID, postID); c != nil {
+			chatKey := c.Key
+			item.ChatID = &chatKey
+			chatID := fmt.Sprintf("chats/%s", c.Key)
+			if last := r.store.lastMessage(chatID); last != nil {
+				item.LastMessage = &feed.FeedLastMessage{
+					ID:        last.Key,
+					Text:      last.Text,
+					SenderID:  keyOf(last.SenderID),
+					Status:    last.Status,
+					Deleted:   last.IsDeleted(),
+					CreatedAt: last.CreatedAt,
+				}
+				if e := r.store.reaction(userID, fmt.Sprintf("messages/%s", last.Key)); e != nil {
+					emoji := e.Emoji
+					item.LastMessage.MyReaction = &emoji
+				}
+			}
+			item.UnreadCount = r.store.unreadCount(chatID, userID)
+		}
+
+		tagMatch := 0
+		for _, key := range item.Tags {
+			if slices.Contains(userTags, key) {
+				tagMatch++
+			}
+		}
+		categoryMatch := 0
+		if slices.Contains(userCategories, string(p.Category)) {
+			categoryMatch = 1
+		}
+		recency := float64(now-p.CreatedAt) / (1000 * 60 * 60 * 24)
+		w := query.Weights
+		score := float64(categoryMatch)*w.Category + float64(tagMatch)*w.Tag + (100 - min(recency, 100)*w.RecencyDecay)
+
+		candidates = append(candidates, scored{item: item, score: score})
+	}
+
+	slices.SortStableFunc(candidates, func(a, b scored) int {
+		return cmp.Or(cmp.Compare(b.score, a.score), cmp.Compare(b.item.CreatedAt, a.item.CreatedAt))
+	})
+	candidates = page(candidates, 0, query.Limit)
+
+	items := make([]feed.FeedItem, len(candidates))
+	for i, c := range candidates {
+		items[i] = c.item
+	}
+
+	var nextCursor string
+	if len(items) == query.Limit && len(items) > 0 {
+		nextCursor = fmt.Sprintf("%d", items[len(items)-1].CreatedAt)
+	}
+	return items, nextCursor, nil
+}
+
+func (r *feedRepository) GetUserInteractionTags(ctx context.Context, userID string) ([]string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var tags []string
+	for _, p := range r.store.respondedPosts(fmt.Sprintf("users/%s", userID)) {
+		tags = append(tags, r.store.postTags(fmt.Sprintf("posts/%s", p.Key))...)
+	}
+	return topByCount(tags, func(key string) string { return key }, 20), nil
+}
+
+func (r *feedRepository) GetUserCategories(ctx context.Context, userID string) ([]string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	posts := r.store.respondedPosts(fmt.Sprintf("users/%s", userID))
+	return topByCount(posts, func(p post.Post) string { return string(p.Category) }, 5), nil
+}
+
+func (r *feedRepository) GetUserIntents(ctx context.Context, userID string) ([]string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	posts := r.store.respondedPosts(fmt.Sprintf("users/%s", userID))
+	return topByCount(posts, func(p post.Post) string { return p.Intent }, 10), nil
+}
+
+// respondedPosts follows the outbound responded edges of a user to posts
+func (s *Store) respondedPosts(userID string) []post.Post {
+	var posts []post.Post
+	for _, e := range s.responded {
+		if e.From != userID {
+			continue
+		}
+		if p := s.post(keyOf(e.To)); p != nil {
+			posts = append(posts, *p)
+		}
+	}
+	return posts
+}
+
+// userChatForPost finds a chat about postID that userID participates in
+func (s *Store) userChatForPost(userID, postID string) *chat.Chat {
+	for _, e := range s.participates {
+		if e.From != userID {
+			continue
+		}
+		if c := s.chat(keyOf(e.To)); c != nil && c.PostID == postID {
+			return c
+		}
+	}
+	return nil
+}
+
+func (s *Store) lastMessage(chatID string) *chat.Message {
+	var last *chat.Message
+	for i, m := range s.messages {
+		if m.ChatID == chatID && (last == nil || m.CreatedAt >= last.CreatedAt) {
+			last = &s.messages[i]
+		}
+	}
+	return last
+}
+
+// topByCount mirrors COLLECT ... WITH COUNT INTO cnt SORT cnt DESC LIMIT n,
+// breaking ties by key
+func topByCount[T any](items []T, key func(T) string, n int) []string {
+	counts := make(map[string]int)
+	for _, item := range items {
+		counts[key(item)]++
+	}
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		return cmp.Or(cmp.Compare(counts[b], counts[a]), cmp.Compare(a, b))
+	})
+	return page(keys, 0, n)
+}
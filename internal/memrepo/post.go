@@ -0,0 +1,255 @@
+package memrepo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/askme/api/internal/post"
+	"github.com/askme/api/internal/tag"
+	"github.com/askme/api/internal/user"
+)
+
+type postRepository struct {
+	store *Store
+}
+
+// NewPostRepository creates an in-memory post repository
+func NewPostRepository(store *Store) post.Repository {
+	return &postRepository{store: store}
+}
+
+func (r *postRepository) GetByID(ctx context.Context, id string) (*post.Post, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return clone(r.store.post(id)), nil
+}
+
+func (r *postRepository) Create(ctx context.Context, p *post.Post) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	doc := *p
+	doc.Key = r.store.nextKey(doc.Key)
+	if r.store.post(doc.Key) != nil {
+		return "", errDuplicate("posts", doc.Key)
+	}
+	doc.PollOptions = slices.Clone(doc.PollOptions)
+	r.store.posts = append(r.store.posts, doc)
+	return doc.Key, nil
+}
+
+func (r *postRepository) Update(ctx context.Context, p *post.Post) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing := r.store.post(p.Key)
+	if existing == nil {
+		return errMissing("posts", p.Key)
+	}
+	*existing = *p
+	existing.PollOptions = slices.Clone(p.PollOptions)
+	return nil
+}
+
+func (r *postRepository) Delete(ctx context.Context, id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := slices.IndexFunc(r.store.posts, func(p post.Post) bool { return p.Key == id })
+	if i < 0 {
+		return errMissing("posts", id)
+	}
+	r.store.posts = slices.Delete(r.store.posts, i, i+1)
+	return nil
+}
+
+func (r *postRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.store.withTransaction(ctx, fn)
+}
+
+func (r *postRepository) CreateCreatedEdge(ctx context.Context, userID, postID string, createdAt int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.created = append(r.store.created, post.CreatedEdge{
+		From:      fmt.Sprintf("users/%s", userID),
+		To:        fmt.Sprintf("posts/%s", postID),
+		CreatedAt: createdAt,
+	})
+	return nil
+}
+
+func (r *postRepository) CreateRespondedEdge(ctx context.Context, userID, postID, chatID string, createdAt int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	edge := post.RespondedEdge{
+		From:      fmt.Sprintf("users/%s", userID),
+		To:        fmt.Sprintf("posts/%s", postID),
+		ChatID:    chatID,
+		CreatedAt: createdAt,
+	}
+	if r.store.hasResponded(edge.From, edge.To) {
+		return errDuplicate("responded", edge.From+"->"+edge.To)
+	}
+	r.store.responded = append(r.store.responded, edge)
+	return nil
+}
+
+func (r *postRepository) CreatePostHasTagEdge(ctx context.Context, postID, tagKey string, confidence float64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.postHasTag = append(r.store.postHasTag, post.PostHasTagEdge{
+		From:       fmt.Sprintf("posts/%s", postID),
+		To:         fmt.Sprintf("tags/%s", tagKey),
+		Confidence: confidence,
+		Source:     "ai",
+	})
+	return nil
+}
+
+func (r *postRepository) DeletePostHasTagEdges(ctx context.Context, postID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	id := fmt.Sprintf("posts/%s", postID)
+	r.store.postHasTag = slices.DeleteFunc(r.store.postHasTag, func(e post.PostHasTagEdge) bool {
+		return e.From == id
+	})
+	return nil
+}
+
+func (r *postRepository) CreateRevision(ctx context.Context, rev *post.PostRevision) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	doc := *rev
+	doc.Key = r.store.nextKey(doc.Key)
+	r.store.revisions = append(r.store.revisions, doc)
+	return nil
+}
+
+func (r *postRepository) GetPostTags(ctx context.Context, postID string) ([]string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return r.store.postTags(fmt.Sprintf("posts/%s", postID)), nil
+}
+
+func (r *postRepository) UpsertVote(ctx context.Context, userID, postID string, optionIDs []string, now int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	from, to := fmt.Sprintf("users/%s", userID), fmt.Sprintf("posts/%s", postID)
+	if edge := find(r.store.voted, func(e post.VotedEdge) bool { return e.From == from && e.To == to }); edge != nil {
+		edge.OptionIDs = slices.Clone(optionIDs)
+		edge.UpdatedAt = now
+		return nil
+	}
+	r.store.voted = append(r.store.voted, post.VotedEdge{
+		From:      from,
+		To:        to,
+		OptionIDs: slices.Clone(optionIDs),
+		CreatedAt: now,
+	})
+	return nil
+}
+
+func (r *postRepository) DeleteVote(ctx context.Context, userID, postID string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	from, to := fmt.Sprintf("users/%s", userID), fmt.Sprintf("posts/%s", postID)
+	before := len(r.store.voted)
+	r.store.voted = slices.DeleteFunc(r.store.voted, func(e post.VotedEdge) bool {
+		return e.From == from && e.To == to
+	})
+	return len(r.store.voted) < before, nil
+}
+
+func (r *postRepository) GetUserVote(ctx context.Context, userID, postID string) (*post.VotedEdge, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	from, to := fmt.Sprintf("users/%s", userID), fmt.Sprintf("posts/%s", postID)
+	edge := clone(find(r.store.voted, func(e post.VotedEdge) bool { return e.From == from && e.To == to }))
+	if edge != nil {
+		edge.OptionIDs = slices.Clone(edge.OptionIDs)
+	}
+	return edge, nil
+}
+
+func (r *postRepository) GetVotes(ctx context.Context, postID string) (*post.VoteTally, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	id := fmt.Sprintf("posts/%s", postID)
+	tally := &post.VoteTally{Counts: make(map[string]int)}
+	for _, e := range r.store.voted {
+		if e.To != id {
+			continue
+		}
+		tally.TotalVoters++
+		for _, optionID := range e.OptionIDs {
+			tally.Counts[optionID]++
+		}
+	}
+	return tally, nil
+}
+
+func (r *postRepository) HasUserResponded(ctx context.Context, userID, postID string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return r.store.hasResponded(fmt.Sprintf("users/%s", userID), fmt.Sprintf("posts/%s", postID)), nil
+}
+
+func (r *postRepository) GetAuthor(ctx context.Context, postID string) (*post.PostAuthor, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	u := r.store.author(fmt.Sprintf("posts/%s", postID))
+	if u == nil {
+		return nil, nil
+	}
+	return &post.PostAuthor{ID: u.Key, Username: u.Username}, nil
+}
+
+func (s *Store) post(key string) *post.Post {
+	return find(s.posts, func(p post.Post) bool { return p.Key == key })
+}
+
+func (s *Store) hasResponded(from, to string) bool {
+	return slices.ContainsFunc(s.responded, func(e post.RespondedEdge) bool {
+		return e.From == from && e.To == to
+	})
+}
+
+// author follows the inbound created edge of a post to its user
+func (s *Store) author(postID string) *user.User {
+	for _, e := range s.created {
+		if e.To != postID {
+			continue
+		}
+		key := keyOf(e.From)
+		if u := find(s.users, func(u user.User) bool { return u.Key == key }); u != nil {
+			return u
+		}
+	}
+	return nil
+}
+
+// postTags returns the keys of existing tags linked to a post
+func (s *Store) postTags(postID string) []string {
+	var keys []string
+	for _, e := range s.postHasTag {
+		if e.From != postID {
+			continue
+		}
+		key := keyOf(e.To)
+		if find(s.tags, func(t tag.Tag) bool { return t.Key == key }) != nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
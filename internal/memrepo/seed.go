@@ -0,0 +1,64 @@
+package memrepo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/askme/api/internal/chat"
+	"github.com/askme/api/internal/feed"
+	"github.com/askme/api/internal/post"
+	"github.com/askme/api/internal/tag"
+	"github.com/askme/api/internal/user"
+	"github.com/askme/api/pkg/events"
+)
+
+// SeedUsers are the users every Fixture starts with. Their keys double as
+// usernames.
+var SeedUsers = []string{"alice", "bob", "carol"}
+
+// Fixture wires the repositories and services of every module over one
+// Store, for service tests
+type Fixture struct {
+	Store *Store
+
+	UserRepo user.Repository
+	PostRepo post.Repository
+	ChatRepo chat.Repository
+	TagRepo  tag.Repository
+	FeedRepo feed.Repository
+
+	Posts post.Service
+	Chats chat.Service
+	Tags  tag.Service
+	Feed  feed.Service
+}
+
+// Seed builds a Fixture over a new Store holding SeedUsers
+func Seed(tb testing.TB) *Fixture {
+	tb.Helper()
+
+	store := NewStore()
+	users := NewUserRepository(store)
+	for _, key := range SeedUsers {
+		if _, err := users.Create(context.Background(), &user.User{Key: key, Username: key}); err != nil {
+			tb.Fatalf("create user %s: %v", key, err)
+		}
+	}
+
+	bus := events.NewBus()
+	tb.Cleanup(bus.Close)
+
+	f := &Fixture{
+		Store:    store,
+		UserRepo: users,
+		PostRepo: NewPostRepository(store),
+		ChatRepo: NewChatRepository(store),
+		TagRepo:  NewTagRepository(store),
+		FeedRepo: NewFeedRepository(store),
+	}
+	f.Chats = chat.NewService(f.ChatRepo, bus)
+	f.Tags = tag.NewService(f.TagRepo)
+	f.Posts = post.NewService(f.PostRepo, f.Tags, f.Chats)
+	f.Feed = feed.NewService(f.FeedRepo, f.PostRepo, f.ChatRepo, feed.Weights{Category: 40, Tag: 20, RecencyDecay: 0.1})
+	return f
+}
@@ -0,0 +1,187 @@
+// Package memrepo provides in-memory implementations of the feature
+// repositories for tests. All repositories built on one Store share its
+// data, the way the ArangoDB repositories share a database, so
+// cross-module reads such as the feed see posts, chats and tags written
+// through the other repositories.
+//
+// The implementations mirror the AQL in each module's aql.go, including
+// the unique (_from, _to) edge indexes and soft-delete filters. Seed wires
+// every module's services over one store for service tests.
+package memrepo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/askme/api/internal/chat"
+	"github.com/askme/api/internal/domain"
+	"github.com/askme/api/internal/post"
+	"github.com/askme/api/internal/tag"
+	"github.com/askme/api/internal/user"
+)
+
+// Store holds every collection in memory. Documents are kept in insertion
+// order so queries sorted on equal timestamps stay deterministic.
+type Store struct {
+	mu  sync.Mutex
+	seq int
+
+	users     []user.User
+	posts     []post.Post
+	revisions []post.PostRevision
+	tags      []tag.Tag
+	chats     []chat.Chat
+	messages  []chat.Message
+
+	created      []post.CreatedEdge
+	responded    []post.RespondedEdge
+	postHasTag   []post.PostHasTagEdge
+	voted        []post.VotedEdge
+	follows      []user.FollowsEdge
+	participates []chat.ParticipatesInEdge
+	reacted      []chat.ReactedEdge
+}
+
+// NewStore creates an empty store
+func NewStore() *Store {
+	return &Store{}
+}
+
+// nextKey returns key if set, otherwise a fresh numeric key like ArangoDB's
+// default key generator. Callers must hold s.mu.
+func (s *Store) nextKey(key string) string {
+	if key != "" {
+		return key
+	}
+	s.seq++
+	return strconv.Itoa(s.seq)
+}
+
+// snapshot is a copy of every collection, used to roll back transactions
+type snapshot struct {
+	seq          int
+	users        []user.User
+	posts        []post.Post
+	revisions    []post.PostRevision
+	tags         []tag.Tag
+	chats        []chat.Chat
+	messages     []chat.Message
+	created      []post.CreatedEdge
+	responded    []post.RespondedEdge
+	postHasTag   []post.PostHasTagEdge
+	voted        []post.VotedEdge
+	follows      []user.FollowsEdge
+	participates []chat.ParticipatesInEdge
+	reacted      []chat.ReactedEdge
+}
+
+func (s *Store) snapshot() snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return snapshot{
+		seq:          s.seq,
+		users:        slices.Clone(s.users),
+		posts:        slices.Clone(s.posts),
+		revisions:    slices.Clone(s.revisions),
+		tags:         slices.Clone(s.tags),
+		chats:        slices.Clone(s.chats),
+		messages:     slices.Clone(s.messages),
+		created:      slices.Clone(s.created),
+		responded:    slices.Clone(s.responded),
+		postHasTag:   slices.Clone(s.postHasTag),
+		voted:        slices.Clone(s.voted),
+		follows:      slices.Clone(s.follows),
+		participates: slices.Clone(s.participates),
+		reacted:      slices.Clone(s.reacted),
+	}
+}
+
+func (s *Store) restore(snap snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq = snap.seq
+	s.users = snap.users
+	s.posts = snap.posts
+	s.revisions = snap.revisions
+	s.tags = snap.tags
+	s.chats = snap.chats
+	s.messages = snap.messages
+	s.created = snap.created
+	s.responded = snap.responded
+	s.postHasTag = snap.postHasTag
+	s.voted = snap.voted
+	s.follows = snap.follows
+	s.participates = snap.participates
+	s.reacted = snap.reacted
+}
+
+type txContextKey struct{}
+
+// withTransaction runs fn and rolls every collection back if it fails.
+// Nested calls join the outer transaction. Unlike a stream transaction it
+// does not isolate concurrent writers, which tests do not rely on.
+func (s *Store) withTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txContextKey{}) != nil {
+		return fn(ctx)
+	}
+
+	snap := s.snapshot()
+	if err := fn(context.WithValue(ctx, txContextKey{}, true)); err != nil {
+		s.restore(snap)
+		return err
+	}
+	return nil
+}
+
+// errDuplicate mirrors a unique constraint violation from ArangoDB
+func errDuplicate(collection, id string) error {
+	return fmt.Errorf("%w: unique constraint violated in %s for %s", domain.ErrAlreadyExists, collection, id)
+}
+
+// errMissing mirrors a document-not-found error from ArangoDB
+func errMissing(collection, key string) error {
+	return fmt.Errorf("%w: %s/%s", domain.ErrNotFound, collection, key)
+}
+
+// keyOf strips the collection prefix from a document ID
+func keyOf(id string) string {
+	if i := strings.IndexByte(id, '/'); i >= 0 {
+		return id[i+1:]
+	}
+	return id
+}
+
+// page applies LIMIT offset, limit to an already sorted slice
+func page[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if limit >= 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// find returns a pointer into items for the first element matching fn
+func find[T any](items []T, fn func(T) bool) *T {
+	for i := range items {
+		if fn(items[i]) {
+			return &items[i]
+		}
+	}
+	return nil
+}
+
+// clone returns a copy of v so callers cannot mutate stored documents
+func clone[T any](v *T) *T {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
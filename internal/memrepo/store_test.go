@@ -0,0 +1,43 @@
+package memrepo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/askme/api/internal/domain"
+	"github.com/askme/api/internal/post"
+)
+
+func TestWithTransactionRollsBack(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPostRepository(NewStore())
+
+	if err := repo.CreateRespondedEdge(ctx, "bob", "p1", "c1", 1); err != nil {
+		t.Fatalf("create responded edge: %v", err)
+	}
+
+	var created string
+	err := repo.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		created, err = repo.Create(ctx, &post.Post{Text: "rolled back"})
+		if err != nil {
+			return err
+		}
+		// Nested calls join the outer transaction
+		return repo.WithTransaction(ctx, func(ctx context.Context) error {
+			return repo.CreateRespondedEdge(ctx, "bob", "p1", "c2", 2)
+		})
+	})
+	if !errors.Is(err, domain.ErrAlreadyExists) {
+		t.Fatalf("got %v, want ErrAlreadyExists from the unique edge", err)
+	}
+
+	p, err := repo.GetByID(ctx, created)
+	if err != nil {
+		t.Fatalf("get post: %v", err)
+	}
+	if p != nil {
+		t.Errorf("post %s survived the rolled back transaction", created)
+	}
+}
@@ -0,0 +1,99 @@
+package memrepo
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/askme/api/internal/tag"
+)
+
+type tagRepository struct {
+	store *Store
+}
+
+// NewTagRepository creates an in-memory tag repository
+func NewTagRepository(store *Store) tag.Repository {
+	return &tagRepository{store: store}
+}
+
+func (r *tagRepository) GetByID(ctx context.Context, id string) (*tag.Tag, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return clone(r.store.tag(id)), nil
+}
+
+func (r *tagRepository) GetByAlias(ctx context.Context, alias string) (*tag.Tag, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return clone(find(r.store.tags, func(t tag.Tag) bool {
+		return slices.Contains(t.Aliases, alias) || strings.EqualFold(t.Label, alias)
+	})), nil
+}
+
+func (r *tagRepository) Create(ctx context.Context, t *tag.Tag) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	doc := *t
+	doc.Key = r.store.nextKey(doc.Key)
+	if r.store.tag(doc.Key) != nil {
+		return "", errDuplicate("tags", doc.Key)
+	}
+	doc.Aliases = slices.Clone(doc.Aliases)
+	r.store.tags = append(r.store.tags, doc)
+	return doc.Key, nil
+}
+
+func (r *tagRepository) IncrementUsageCount(ctx context.Context, id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if t := r.store.tag(id); t != nil {
+		t.UsageCount++
+	}
+	return nil
+}
+
+func (r *tagRepository) List(ctx context.Context, limit, offset int) ([]tag.Tag, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return page(r.store.tagsByUsage(func(tag.Tag) bool { return true }), offset, limit), nil
+}
+
+func (r *tagRepository) Search(ctx context.Context, query string, limit int) ([]tag.Tag, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	query = strings.ToLower(query)
+	matches := r.store.tagsByUsage(func(t tag.Tag) bool {
+		if strings.Contains(strings.ToLower(t.Label), query) {
+			return true
+		}
+		return slices.ContainsFunc(t.Aliases, func(alias string) bool {
+			return strings.Contains(strings.ToLower(alias), query)
+		})
+	})
+	return page(matches, 0, limit), nil
+}
+
+func (s *Store) tag(key string) *tag.Tag {
+	return find(s.tags, func(t tag.Tag) bool { return t.Key == key })
+}
+
+// tagsByUsage returns copies of the tags matching keep, most used first
+func (s *Store) tagsByUsage(keep func(tag.Tag) bool) []tag.Tag {
+	var tags []tag.Tag
+	for _, t := range s.tags {
+		if keep(t) {
+			t.Aliases = slices.Clone(t.Aliases)
+			tags = append(tags, t)
+		}
+	}
+	slices.SortStableFunc(tags, func(a, b tag.Tag) int {
+		return cmp.Compare(b.UsageCount, a.UsageCount)
+	})
+	return tags
+}
@@ -0,0 +1,135 @@
+package memrepo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/askme/api/internal/user"
+)
+
+type userRepository struct {
+	store *Store
+}
+
+// NewUserRepository creates an in-memory user repository
+func NewUserRepository(store *Store) user.Repository {
+	return &userRepository{store: store}
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id string) (*user.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return clone(find(r.store.users, func(u user.User) bool { return u.Key == id })), nil
+}
+
+func (r *userRepository) Create(ctx context.Context, u *user.User) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	doc := *u
+	doc.Key = r.store.nextKey(doc.Key)
+	if find(r.store.users, func(u user.User) bool { return u.Key == doc.Key }) != nil {
+		return "", errDuplicate("users", doc.Key)
+	}
+	r.store.users = append(r.store.users, doc)
+	return doc.Key, nil
+}
+
+func (r *userRepository) Update(ctx context.Context, u *user.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing := find(r.store.users, func(e user.User) bool { return e.Key == u.Key })
+	if existing == nil {
+		return errMissing("users", u.Key)
+	}
+	*existing = *u
+	return nil
+}
+
+func (r *userRepository) Delete(ctx context.Context, id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := slices.IndexFunc(r.store.users, func(u user.User) bool { return u.Key == id })
+	if i < 0 {
+		return errMissing("users", id)
+	}
+	r.store.users = slices.Delete(r.store.users, i, i+1)
+	return nil
+}
+
+func (r *userRepository) CreateFollow(ctx context.Context, followerID, followeeID string) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	edge := user.FollowsEdge{
+		From: fmt.Sprintf("users/%s", followerID),
+		To:   fmt.Sprintf("users/%s", followeeID),
+	}
+	if r.store.isFollowing(edge.From, edge.To) {
+		return "", errDuplicate("follows", edge.From+"->"+edge.To)
+	}
+	r.store.follows = append(r.store.follows, edge)
+	return r.store.nextKey(""), nil
+}
+
+func (r *userRepository) DeleteFollow(ctx context.Context, followerID, followeeID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	from, to := fmt.Sprintf("users/%s", followerID), fmt.Sprintf("users/%s", followeeID)
+	r.store.follows = slices.DeleteFunc(r.store.follows, func(e user.FollowsEdge) bool {
+		return e.From == from && e.To == to
+	})
+	return nil
+}
+
+func (r *userRepository) IsFollowing(ctx context.Context, followerID, followeeID string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return r.store.isFollowing(fmt.Sprintf("users/%s", followerID), fmt.Sprintf("users/%s", followeeID)), nil
+}
+
+func (r *userRepository) AreMutualFollowers(ctx context.Context, userID1, userID2 string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user1, user2 := fmt.Sprintf("users/%s", userID1), fmt.Sprintf("users/%s", userID2)
+	return r.store.isFollowing(user1, user2) && r.store.isFollowing(user2, user1), nil
+}
+
+func (r *userRepository) GetFollowerCount(ctx context.Context, userID string) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	id := fmt.Sprintf("users/%s", userID)
+	count := 0
+	for _, e := range r.store.follows {
+		if e.To == id {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *userRepository) GetFollowingCount(ctx context.Context, userID string) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	id := fmt.Sprintf("users/%s", userID)
+	count := 0
+	for _, e := range r.store.follows {
+		if e.From == id {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *Store) isFollowing(from, to string) bool {
+	return slices.ContainsFunc(s.follows, func(e user.FollowsEdge) bool {
+		return e.From == from && e.To == to
+	})
+}
@@ -1,5 +1,7 @@
 package post
 
+import "github.com/askme/api/pkg/arango"
+
 // AQL queries for post operations
 const (
 	// GetPostByID retrieves a post by its key
@@ -18,19 +20,53 @@ const (
 		RETURN tag._key
 	`
 
-	// GetPollVotes aggregates votes for a poll
+	// DeletePostTags removes all tag edges of a post
+	DeletePostTags = `
+		FOR edge IN post_has_tag
+		FILTER edge._from == @postId
+		REMOVE edge IN post_has_tag
+	`
+
+	// GetPollVotes aggregates votes per option ID for a poll
 	GetPollVotes = `
+		LET selections = (
+			FOR edge IN voted
+			FILTER edge._to == @postId
+			RETURN edge.optionIds
+		)
+		RETURN {
+			totalVoters: LENGTH(selections),
+			counts: (
+				FOR optionIds IN selections
+				FOR optionId IN optionIds
+				COLLECT id = optionId WITH COUNT INTO count
+				RETURN { optionId: id, count }
+			)
+		}
+	`
+
+	// GetUserVote retrieves a user's vote on a poll
+	GetUserVote = `
 		FOR edge IN voted
-		FILTER edge._to == @postId
-		COLLECT option = edge.option WITH COUNT INTO count
-		RETURN { option, count }
+		FILTER edge._from == @userId AND edge._to == @postId
+		RETURN edge
 	`
 
-	// CheckUserVoted checks if a user has voted on a poll
-	CheckUserVoted = `
+	// UpsertVote creates or replaces a user's vote on a poll
+	UpsertVote = `
+		UPSERT { _from: @from, _to: @to }
+		INSERT { _from: @from, _to: @to, optionIds: @optionIds, createdAt: @now }
+		UPDATE { optionIds: @optionIds, updatedAt: @now }
+		IN voted
+		RETURN NEW
+	`
+
+	// DeleteVote removes a user's vote on a poll
+	DeleteVote = `
 		FOR edge IN voted
 		FILTER edge._from == @userId AND edge._to == @postId
-		RETURN true
+		REMOVE edge IN voted
+		RETURN OLD
 	`
 
 	// CheckUserResponded checks if a user has responded to a post
@@ -42,10 +78,8 @@ const (
 
 	// GetPostAuthor retrieves the author of a post
 	GetPostAuthor = `
-		FOR edge IN created
-		FILTER edge._to == @postId
-		FOR user IN users
-		FILTER user._id == edge._from
+		FOR user IN 1..1 INBOUND @postId created
+		LIMIT 1
 		RETURN {
 			id: user._key,
 			username: user.username,
@@ -53,3 +87,17 @@ const (
 		}
 	`
 )
+
+func init() {
+	arango.RegisterQueries("post", map[string]string{
+		"GetPostByID":        GetPostByID,
+		"GetPostTags":        GetPostTags,
+		"DeletePostTags":     DeletePostTags,
+		"GetPollVotes":       GetPollVotes,
+		"GetUserVote":        GetUserVote,
+		"UpsertVote":         UpsertVote,
+		"DeleteVote":         DeleteVote,
+		"CheckUserResponded": CheckUserResponded,
+		"GetPostAuthor":      GetPostAuthor,
+	})
+}
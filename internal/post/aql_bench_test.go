@@ -0,0 +1,46 @@
+package post
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/askme/api/pkg/arango"
+	"github.com/askme/api/pkg/arango/arangotest"
+)
+
+func BenchmarkGetPostAuthor(b *testing.B) {
+	db := arangotest.DB(b)
+	ctx := context.Background()
+	bindVars := map[string]any{"postId": fmt.Sprintf("posts/%s", arangotest.PostKey)}
+
+	b.Run("join", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := arango.QueryOne[PostAuthor](ctx, db, legacyGetPostAuthor, bindVars); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("traversal", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := arango.QueryOne[PostAuthor](ctx, db, GetPostAuthor, bindVars); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// legacyGetPostAuthor is the hand-joined query GetPostAuthor replaced,
+// kept as a baseline
+const legacyGetPostAuthor = `
+		FOR edge IN created
+		FILTER edge._to == @postId
+		FOR user IN users
+		FILTER user._id == edge._from
+		RETURN {
+			id: user._key,
+			username: user.username,
+			avatarUrl: user.avatarUrl
+		}
+`
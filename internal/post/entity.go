@@ -1,19 +1,94 @@
 package post
 
-import "github.com/askme/api/internal/domain"
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/askme/api/internal/domain"
+	"github.com/askme/api/pkg/validate"
+)
 
 // Post represents a post document in ArangoDB
 type Post struct {
-	Key         string              `json:"_key,omitempty"`
-	AuthorID    string              `json:"authorId"`
-	PostType    domain.PostType     `json:"postType"`
-	Text        string              `json:"text"`
-	PollOptions []string            `json:"pollOptions,omitempty"`
-	Category    domain.PostCategory `json:"category"`
-	Intent      string              `json:"intent"`
-	Depth       domain.PostDepth    `json:"depth"`
-	AIRaw       domain.AIRawData    `json:"aiRaw,omitempty"`
-	CreatedAt   int64               `json:"createdAt"`
+	Key               string                       `json:"_key,omitempty"`
+	AuthorID          string                       `json:"authorId"`
+	PostType          domain.PostType              `json:"postType"`
+	Text              string                       `json:"text"`
+	PollOptions       []PollOption                 `json:"pollOptions,omitempty"`
+	ClosesAt          int64                        `json:"closesAt,omitempty"`
+	MaxSelections     int                          `json:"maxSelections,omitempty"`
+	ResultsVisibility domain.PollResultsVisibility `json:"resultsVisibility,omitempty"`
+	Category          domain.PostCategory          `json:"category"`
+	Intent            string                       `json:"intent"`
+	Depth             domain.PostDepth             `json:"depth"`
+	AIRaw             domain.AIRawData             `json:"aiRaw,omitempty"`
+	CreatedAt         int64                        `json:"createdAt"`
+	UpdatedAt         int64                        `json:"updatedAt,omitempty"`
+	DeletedAt         int64                        `json:"deletedAt,omitempty"`
+}
+
+// PollOption is a poll choice with an identity that survives text edits
+type PollOption struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+// UnmarshalJSON also accepts the legacy bare-string form, which carries no
+// ID until the poll option migration has run
+func (o *PollOption) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*o = PollOption{Text: text}
+		return nil
+	}
+	type plain PollOption
+	return json.Unmarshal(data, (*plain)(o))
+}
+
+// IsDeleted reports whether the post has been soft-deleted
+func (p *Post) IsDeleted() bool {
+	return p.DeletedAt != 0
+}
+
+// AuthorKey returns the author's user key regardless of how authorId is stored
+func (p *Post) AuthorKey() string {
+	return strings.TrimPrefix(p.AuthorID, "users/")
+}
+
+// IsClosed reports whether a poll has passed its deadline
+func (p *Post) IsClosed(now int64) bool {
+	return p.ClosesAt != 0 && now >= p.ClosesAt
+}
+
+// HasOption reports whether the poll has an option with the given ID
+func (p *Post) HasOption(id string) bool {
+	for _, o := range p.PollOptions {
+		if o.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectionLimit returns how many options a voter may pick
+func (p *Post) SelectionLimit() int {
+	if p.MaxSelections <= 0 {
+		return 1
+	}
+	return p.MaxSelections
+}
+
+// PostRevision is a prior version of a post's text, kept for moderation
+type PostRevision struct {
+	Key       string              `json:"_key,omitempty"`
+	PostID    string              `json:"postId"`
+	EditorID  string              `json:"editorId"`
+	Text      string              `json:"text"`
+	Category  domain.PostCategory `json:"category"`
+	Intent    string              `json:"intent"`
+	Depth     domain.PostDepth    `json:"depth"`
+	AIRaw     domain.AIRawData    `json:"aiRaw,omitempty"`
+	CreatedAt int64               `json:"createdAt"`
 }
 
 // PostAuthor represents author information for API responses
@@ -38,12 +113,20 @@ type RespondedEdge struct {
 	CreatedAt int64  `json:"createdAt"`
 }
 
-// VotedEdge represents a poll vote
+// VotedEdge represents a user's poll vote. Each voter has a single edge
+// holding the IDs of every option they selected.
 type VotedEdge struct {
-	From      string `json:"_from"`
-	To        string `json:"_to"`
-	Option    string `json:"option"`
-	CreatedAt int64  `json:"createdAt"`
+	From      string   `json:"_from"`
+	To        string   `json:"_to"`
+	OptionIDs []string `json:"optionIds"`
+	CreatedAt int64    `json:"createdAt"`
+	UpdatedAt int64    `json:"updatedAt,omitempty"`
+}
+
+// VoteTally is the aggregated vote count of a poll, keyed by option ID
+type VoteTally struct {
+	TotalVoters int
+	Counts      map[string]int
 }
 
 // PostHasTagEdge represents the post-tag relationship
@@ -56,11 +139,14 @@ type PostHasTagEdge struct {
 
 // CreatePostRequest is the request payload for creating a post
 type CreatePostRequest struct {
-	AuthorID    string           `json:"authorId"`
-	PostType    domain.PostType  `json:"postType"`
-	Text        string           `json:"text"`
-	PollOptions []string         `json:"pollOptions,omitempty"`
-	AIRaw       domain.AIRawData `json:"aiRaw,omitempty"`
+	AuthorID          string                       `json:"authorId" openapi:"-"`
+	PostType          domain.PostType              `json:"postType" openapi:"-"`
+	Text              string                       `json:"text" validate:"required,max=2000"`
+	PollOptions       []string                     `json:"pollOptions,omitempty" validate:"max=10"`
+	ClosesAt          int64                        `json:"closesAt,omitempty"`
+	MaxSelections     int                          `json:"maxSelections,omitempty"`
+	ResultsVisibility domain.PollResultsVisibility `json:"resultsVisibility,omitempty" validate:"oneof=always after_vote after_close"`
+	AIRaw             domain.AIRawData             `json:"aiRaw,omitempty"`
 }
 
 // CreatePostResponse is the response payload for creating a post
@@ -71,11 +157,32 @@ type CreatePostResponse struct {
 	CreatedAt int64               `json:"createdAt"`
 }
 
+// UpdatePostRequest is the request payload for editing a post
+type UpdatePostRequest struct {
+	AuthorID string           `json:"authorId" openapi:"-"`
+	Text     string           `json:"text" validate:"required,max=2000"`
+	AIRaw    domain.AIRawData `json:"aiRaw,omitempty"`
+}
+
+// UpdatePostResponse is the response payload for editing a post
+type UpdatePostResponse struct {
+	Key       string              `json:"_key"`
+	Category  domain.PostCategory `json:"category"`
+	Tags      []string            `json:"tags"`
+	UpdatedAt int64               `json:"updatedAt"`
+}
+
+// DeletePostResponse is the response payload for deleting a post
+type DeletePostResponse struct {
+	Success bool   `json:"success"`
+	PostID  string `json:"postId"`
+}
+
 // RespondToPostRequest is the request payload for responding to a post
 type RespondToPostRequest struct {
-	UserID   string          `json:"userId"`
-	Text     string          `json:"text"`
-	ChatType domain.ChatType `json:"chatType,omitempty"`
+	UserID   string          `json:"userId" openapi:"-"`
+	Text     string          `json:"text" validate:"required,max=4000"`
+	ChatType domain.ChatType `json:"chatType,omitempty" validate:"oneof=direct group"`
 }
 
 // RespondToPostResponse is the response for responding to a post
@@ -85,17 +192,46 @@ type RespondToPostResponse struct {
 	CreatedAt int64  `json:"createdAt"`
 }
 
-// VoteRequest is the request payload for voting on a poll
+// VoteRequest is the request payload for voting on a poll. Single-choice
+// clients may send optionId; multi-choice clients send optionIds.
 type VoteRequest struct {
-	UserID string `json:"userId"`
-	Option string `json:"option"`
+	UserID    string   `json:"userId" openapi:"-"`
+	OptionID  string   `json:"optionId,omitempty"`
+	OptionIDs []string `json:"optionIds,omitempty" validate:"max=10"`
+}
+
+// Validate requires a selection in either form
+func (r *VoteRequest) Validate() error {
+	if r.OptionID == "" && len(r.OptionIDs) == 0 {
+		return validate.Field("optionId", "required", "optionId is required")
+	}
+	return nil
 }
 
 // VoteResponse is the response for voting on a poll
 type VoteResponse struct {
-	PostID string         `json:"postId"`
-	Option string         `json:"option"`
-	Votes  map[string]int `json:"votes"`
+	PostID string       `json:"postId"`
+	Poll   *PollResults `json:"poll"`
+}
+
+// PollResults describes a poll's state and tallies as seen by the caller
+type PollResults struct {
+	ClosesAt          int64                        `json:"closesAt,omitempty"`
+	Closed            bool                         `json:"closed"`
+	MaxSelections     int                          `json:"maxSelections"`
+	ResultsVisibility domain.PollResultsVisibility `json:"resultsVisibility"`
+	ResultsVisible    bool                         `json:"resultsVisible"`
+	TotalVoters       int                          `json:"totalVoters"`
+	Tallies           []PollTally                  `json:"tallies,omitempty"`
+	MySelection       []string                     `json:"mySelection,omitempty"`
+}
+
+// PollTally is the vote count of a single poll option
+type PollTally struct {
+	OptionID   string  `json:"optionId"`
+	Text       string  `json:"text"`
+	Votes      int     `json:"votes"`
+	Percentage float64 `json:"percentage"`
 }
 
 // GetPostResponse is the response for getting a post
@@ -104,11 +240,13 @@ type GetPostResponse struct {
 	AuthorID    string              `json:"authorId"`
 	PostType    domain.PostType     `json:"postType"`
 	Text        string              `json:"text"`
-	PollOptions []string            `json:"pollOptions,omitempty"`
+	PollOptions []PollOption        `json:"pollOptions,omitempty"`
 	Category    domain.PostCategory `json:"category"`
 	Intent      string              `json:"intent"`
 	Depth       domain.PostDepth    `json:"depth"`
 	AIRaw       domain.AIRawData    `json:"aiRaw,omitempty"`
 	Tags        []string            `json:"tags"`
+	Poll        *PollResults        `json:"poll,omitempty"`
 	CreatedAt   int64               `json:"createdAt"`
+	UpdatedAt   int64               `json:"updatedAt,omitempty"`
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/askme/api/pkg/httputil"
 	"github.com/askme/api/pkg/middleware"
+	"github.com/askme/api/pkg/validate"
 )
 
 type handler struct {
@@ -24,7 +25,10 @@ func (h *handler) GetPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	post, err := h.service.GetPost(r.Context(), postID)
+	// Caller is optional here; it only personalizes poll results
+	currentUserID := middleware.GetUserID(r.Context())
+
+	post, err := h.service.GetPost(r.Context(), postID, currentUserID)
 	if err != nil {
 		httputil.ErrorFromDomain(w, err)
 		return
@@ -44,12 +48,7 @@ func (h *handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 
 	req, err := httputil.DecodeJSON[CreatePostRequest](r)
 	if err != nil {
-		httputil.Error(w, http.StatusBadRequest, "invalid request body")
-		return
-	}
-
-	if req.Text == "" {
-		httputil.Error(w, http.StatusBadRequest, "text is required")
+		httputil.InvalidRequest(w, err)
 		return
 	}
 
@@ -76,16 +75,13 @@ func (h *handler) CreatePoll(w http.ResponseWriter, r *http.Request) {
 
 	req, err := httputil.DecodeJSON[CreatePostRequest](r)
 	if err != nil {
-		httputil.Error(w, http.StatusBadRequest, "invalid request body")
+		httputil.InvalidRequest(w, err)
 		return
 	}
 
-	if req.Text == "" {
-		httputil.Error(w, http.StatusBadRequest, "text is required")
-		return
-	}
+	// Text posts share the request type, so the poll minimum is checked here
 	if len(req.PollOptions) < 2 {
-		httputil.Error(w, http.StatusBadRequest, "at least 2 poll options are required")
+		httputil.InvalidRequest(w, validate.Field("pollOptions", "min", "at least 2 poll options are required"))
 		return
 	}
 
@@ -101,8 +97,8 @@ func (h *handler) CreatePoll(w http.ResponseWriter, r *http.Request) {
 	httputil.JSON(w, http.StatusCreated, resp)
 }
 
-// RespondToPost handles POST /posts/{postId}/respond
-func (h *handler) RespondToPost(w http.ResponseWriter, r *http.Request) {
+// UpdatePost handles PATCH /posts/{postId}
+func (h *handler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	// Get current user from auth context
 	currentUserID := middleware.GetUserID(r.Context())
 	if currentUserID == "" {
@@ -116,14 +112,66 @@ func (h *handler) RespondToPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	req, err := httputil.DecodeJSON[RespondToPostRequest](r)
+	req, err := httputil.DecodeJSON[UpdatePostRequest](r)
 	if err != nil {
-		httputil.Error(w, http.StatusBadRequest, "invalid request body")
+		httputil.InvalidRequest(w, err)
+		return
+	}
+
+	// Set author from authenticated user
+	req.AuthorID = currentUserID
+
+	resp, err := h.service.UpdatePost(r.Context(), postID, req)
+	if err != nil {
+		httputil.ErrorFromDomain(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, resp)
+}
+
+// DeletePost handles DELETE /posts/{postId}
+func (h *handler) DeletePost(w http.ResponseWriter, r *http.Request) {
+	// Get current user from auth context
+	currentUserID := middleware.GetUserID(r.Context())
+	if currentUserID == "" {
+		httputil.Error(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
-	if req.Text == "" {
-		httputil.Error(w, http.StatusBadRequest, "text is required")
+	postID := httputil.PathValue(r, "postId")
+	if postID == "" {
+		httputil.Error(w, http.StatusBadRequest, "postId is required")
+		return
+	}
+
+	resp, err := h.service.DeletePost(r.Context(), postID, currentUserID)
+	if err != nil {
+		httputil.ErrorFromDomain(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, resp)
+}
+
+// RespondToPost handles POST /posts/{postId}/respond
+func (h *handler) RespondToPost(w http.ResponseWriter, r *http.Request) {
+	// Get current user from auth context
+	currentUserID := middleware.GetUserID(r.Context())
+	if currentUserID == "" {
+		httputil.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	postID := httputil.PathValue(r, "postId")
+	if postID == "" {
+		httputil.Error(w, http.StatusBadRequest, "postId is required")
+		return
+	}
+
+	req, err := httputil.DecodeJSON[RespondToPostRequest](r)
+	if err != nil {
+		httputil.InvalidRequest(w, err)
 		return
 	}
 
@@ -156,12 +204,7 @@ func (h *handler) Vote(w http.ResponseWriter, r *http.Request) {
 
 	req, err := httputil.DecodeJSON[VoteRequest](r)
 	if err != nil {
-		httputil.Error(w, http.StatusBadRequest, "invalid request body")
-		return
-	}
-
-	if req.Option == "" {
-		httputil.Error(w, http.StatusBadRequest, "option is required")
+		httputil.InvalidRequest(w, err)
 		return
 	}
 
@@ -176,3 +219,27 @@ func (h *handler) Vote(w http.ResponseWriter, r *http.Request) {
 
 	httputil.JSON(w, http.StatusOK, resp)
 }
+
+// RetractVote handles DELETE /posts/{postId}/vote
+func (h *handler) RetractVote(w http.ResponseWriter, r *http.Request) {
+	// Get current user from auth context
+	currentUserID := middleware.GetUserID(r.Context())
+	if currentUserID == "" {
+		httputil.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	postID := httputil.PathValue(r, "postId")
+	if postID == "" {
+		httputil.Error(w, http.StatusBadRequest, "postId is required")
+		return
+	}
+
+	resp, err := h.service.RetractVote(r.Context(), postID, currentUserID)
+	if err != nil {
+		httputil.ErrorFromDomain(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, resp)
+}
@@ -12,27 +12,40 @@ type Repository interface {
 	Update(ctx context.Context, post *Post) error
 	Delete(ctx context.Context, id string) error
 
+	// WithTransaction runs fn atomically across the collections touched when
+	// creating, editing or responding to a post (posts, edges, chats and messages)
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+
 	// Edge operations
 	CreateCreatedEdge(ctx context.Context, userID, postID string, createdAt int64) error
 	CreateRespondedEdge(ctx context.Context, userID, postID, chatID string, createdAt int64) error
-	CreateVotedEdge(ctx context.Context, userID, postID, option string, createdAt int64) error
 	CreatePostHasTagEdge(ctx context.Context, postID, tagKey string, confidence float64) error
+	DeletePostHasTagEdges(ctx context.Context, postID string) error
+
+	// Revision operations
+	CreateRevision(ctx context.Context, rev *PostRevision) error
 
 	// Query operations
 	GetPostTags(ctx context.Context, postID string) ([]string, error)
-	GetVotes(ctx context.Context, postID string) (map[string]int, error)
-	HasUserVoted(ctx context.Context, userID, postID string) (bool, error)
+	// Vote operations
+	UpsertVote(ctx context.Context, userID, postID string, optionIDs []string, now int64) error
+	DeleteVote(ctx context.Context, userID, postID string) (bool, error)
+	GetUserVote(ctx context.Context, userID, postID string) (*VotedEdge, error)
+	GetVotes(ctx context.Context, postID string) (*VoteTally, error)
 	HasUserResponded(ctx context.Context, userID, postID string) (bool, error)
 	GetAuthor(ctx context.Context, postID string) (*PostAuthor, error)
 }
 
 // Service defines the interface for post business logic
 type Service interface {
-	GetPost(ctx context.Context, id string) (*GetPostResponse, error)
+	GetPost(ctx context.Context, id, userID string) (*GetPostResponse, error)
 	CreatePost(ctx context.Context, req *CreatePostRequest) (*CreatePostResponse, error)
 	CreatePoll(ctx context.Context, req *CreatePostRequest) (*CreatePostResponse, error)
+	UpdatePost(ctx context.Context, postID string, req *UpdatePostRequest) (*UpdatePostResponse, error)
+	DeletePost(ctx context.Context, postID, userID string) (*DeletePostResponse, error)
 	RespondToPost(ctx context.Context, postID string, req *RespondToPostRequest) (*RespondToPostResponse, error)
 	Vote(ctx context.Context, postID string, req *VoteRequest) (*VoteResponse, error)
+	RetractVote(ctx context.Context, postID, userID string) (*VoteResponse, error)
 }
 
 // Handler defines the interface for post HTTP handlers
@@ -40,6 +53,9 @@ type Handler interface {
 	GetPost(w http.ResponseWriter, r *http.Request)
 	CreatePost(w http.ResponseWriter, r *http.Request)
 	CreatePoll(w http.ResponseWriter, r *http.Request)
+	UpdatePost(w http.ResponseWriter, r *http.Request)
+	DeletePost(w http.ResponseWriter, r *http.Request)
 	RespondToPost(w http.ResponseWriter, r *http.Request)
 	Vote(w http.ResponseWriter, r *http.Request)
+	RetractVote(w http.ResponseWriter, r *http.Request)
 }
@@ -32,6 +32,22 @@ func (r *repository) Delete(ctx context.Context, id string) error {
 	return arango.DeleteDocument(ctx, r.db, arango.CollectionPosts, id)
 }
 
+// txCollections lists every collection written by post creation, edits and responses
+var txCollections = []arango.Collection{
+	arango.CollectionPosts,
+	arango.CollectionChats,
+	arango.CollectionMessages,
+	arango.CollectionPostRevisions,
+	arango.EdgeCreated,
+	arango.EdgePostHasTag,
+	arango.EdgeParticipatesIn,
+	arango.EdgeResponded,
+}
+
+func (r *repository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.db.WithTransaction(ctx, txCollections, fn)
+}
+
 func (r *repository) CreateCreatedEdge(ctx context.Context, userID, postID string, createdAt int64) error {
 	edge := CreatedEdge{
 		From:      fmt.Sprintf("users/%s", userID),
@@ -53,17 +69,6 @@ func (r *repository) CreateRespondedEdge(ctx context.Context, userID, postID, ch
 	return err
 }
 
-func (r *repository) CreateVotedEdge(ctx context.Context, userID, postID, option string, createdAt int64) error {
-	edge := VotedEdge{
-		From:      fmt.Sprintf("users/%s", userID),
-		To:        fmt.Sprintf("posts/%s", postID),
-		Option:    option,
-		CreatedAt: createdAt,
-	}
-	_, err := arango.InsertDocument(ctx, r.db, arango.EdgeVoted, edge)
-	return err
-}
-
 func (r *repository) CreatePostHasTagEdge(ctx context.Context, postID, tagKey string, confidence float64) error {
 	edge := PostHasTagEdge{
 		From:       fmt.Sprintf("posts/%s", postID),
@@ -75,40 +80,77 @@ func (r *repository) CreatePostHasTagEdge(ctx context.Context, postID, tagKey st
 	return err
 }
 
+func (r *repository) DeletePostHasTagEdges(ctx context.Context, postID string) error {
+	_, err := arango.Query[any](ctx, r.db, DeletePostTags, map[string]any{
+		"postId": fmt.Sprintf("posts/%s", postID),
+	})
+	return err
+}
+
+func (r *repository) CreateRevision(ctx context.Context, rev *PostRevision) error {
+	_, err := arango.InsertDocument(ctx, r.db, arango.CollectionPostRevisions, rev)
+	return err
+}
+
 func (r *repository) GetPostTags(ctx context.Context, postID string) ([]string, error) {
 	return arango.Query[string](ctx, r.db, GetPostTags, map[string]any{
 		"postId": fmt.Sprintf("posts/%s", postID),
 	})
 }
 
-func (r *repository) GetVotes(ctx context.Context, postID string) (map[string]int, error) {
-	type voteCount struct {
-		Option string `json:"option"`
-		Count  int    `json:"count"`
-	}
-	results, err := arango.Query[voteCount](ctx, r.db, GetPollVotes, map[string]any{
+func (r *repository) UpsertVote(ctx context.Context, userID, postID string, optionIDs []string, now int64) error {
+	_, err := arango.Query[VotedEdge](ctx, r.db, UpsertVote, map[string]any{
+		"from":      fmt.Sprintf("users/%s", userID),
+		"to":        fmt.Sprintf("posts/%s", postID),
+		"optionIds": optionIDs,
+		"now":       now,
+	})
+	return err
+}
+
+func (r *repository) DeleteVote(ctx context.Context, userID, postID string) (bool, error) {
+	removed, err := arango.Query[VotedEdge](ctx, r.db, DeleteVote, map[string]any{
+		"userId": fmt.Sprintf("users/%s", userID),
 		"postId": fmt.Sprintf("posts/%s", postID),
 	})
 	if err != nil {
-		return nil, err
-	}
-
-	votes := make(map[string]int)
-	for _, v := range results {
-		votes[v.Option] = v.Count
+		return false, err
 	}
-	return votes, nil
+	return len(removed) > 0, nil
 }
 
-func (r *repository) HasUserVoted(ctx context.Context, userID, postID string) (bool, error) {
-	results, err := arango.Query[bool](ctx, r.db, CheckUserVoted, map[string]any{
+func (r *repository) GetUserVote(ctx context.Context, userID, postID string) (*VotedEdge, error) {
+	return arango.QueryOne[VotedEdge](ctx, r.db, GetUserVote, map[string]any{
 		"userId": fmt.Sprintf("users/%s", userID),
 		"postId": fmt.Sprintf("posts/%s", postID),
 	})
+}
+
+func (r *repository) GetVotes(ctx context.Context, postID string) (*VoteTally, error) {
+	type voteCount struct {
+		OptionID string `json:"optionId"`
+		Count    int    `json:"count"`
+	}
+	type voteSummary struct {
+		TotalVoters int         `json:"totalVoters"`
+		Counts      []voteCount `json:"counts"`
+	}
+	result, err := arango.QueryOne[voteSummary](ctx, r.db, GetPollVotes, map[string]any{
+		"postId": fmt.Sprintf("posts/%s", postID),
+	})
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	return len(results) > 0, nil
+
+	tally := &VoteTally{Counts: make(map[string]int)}
+	if result == nil {
+		return tally, nil
+	}
+	tally.TotalVoters = result.TotalVoters
+	for _, v := range result.Counts {
+		tally.Counts[v.OptionID] = v.Count
+	}
+	return tally, nil
 }
 
 func (r *repository) HasUserResponded(ctx context.Context, userID, postID string) (bool, error) {
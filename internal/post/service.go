@@ -2,16 +2,31 @@ package post
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
 	"time"
-
-	"golang.org/x/sync/errgroup"
+	"unicode/utf8"
 
 	"github.com/askme/api/internal/chat"
 	"github.com/askme/api/internal/domain"
 	"github.com/askme/api/internal/tag"
+	"github.com/askme/api/pkg/trace"
+)
+
+// Poll option limits
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 10
+	maxPollOptionLength = 80
 )
 
+// editWindow is how long after creation an author may still edit a post
+const editWindow = 15 * time.Minute
+
 type service struct {
 	repo        Repository
 	tagService  tag.Service
@@ -27,12 +42,15 @@ func NewService(repo Repository, tagService tag.Service, chatService chat.Servic
 	}
 }
 
-func (s *service) GetPost(ctx context.Context, id string) (*GetPostResponse, error) {
+func (s *service) GetPost(ctx context.Context, id, userID string) (*GetPostResponse, error) {
+	ctx, span := trace.Start(ctx, "post.Service.GetPost")
+	defer span.End()
+
 	post, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("get post: %w", err)
 	}
-	if post == nil {
+	if post == nil || post.IsDeleted() {
 		return nil, domain.ErrNotFound
 	}
 
@@ -41,6 +59,14 @@ func (s *service) GetPost(ctx context.Context, id string) (*GetPostResponse, err
 		return nil, fmt.Errorf("get post tags: %w", err)
 	}
 
+	var poll *PollResults
+	if post.PostType == domain.PostTypePoll {
+		poll, err = s.pollResults(ctx, post, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &GetPostResponse{
 		Key:         post.Key,
 		AuthorID:    post.AuthorID,
@@ -52,17 +78,42 @@ func (s *service) GetPost(ctx context.Context, id string) (*GetPostResponse, err
 		Depth:       post.Depth,
 		AIRaw:       post.AIRaw,
 		Tags:        tags,
+		Poll:        poll,
 		CreatedAt:   post.CreatedAt,
+		UpdatedAt:   post.UpdatedAt,
 	}, nil
 }
 
 func (s *service) CreatePost(ctx context.Context, req *CreatePostRequest) (*CreatePostResponse, error) {
+	ctx, span := trace.Start(ctx, "post.Service.CreatePost")
+	defer span.End()
+
 	return s.createPostInternal(ctx, req, domain.PostTypeText)
 }
 
 func (s *service) CreatePoll(ctx context.Context, req *CreatePostRequest) (*CreatePostResponse, error) {
-	if len(req.PollOptions) < 2 {
-		return nil, fmt.Errorf("%w: poll requires at least 2 options", domain.ErrInvalidInput)
+	ctx, span := trace.Start(ctx, "post.Service.CreatePoll")
+	defer span.End()
+
+	if err := validatePollOptions(req.PollOptions); err != nil {
+		return nil, err
+	}
+	if req.ClosesAt != 0 && req.ClosesAt <= time.Now().UnixMilli() {
+		return nil, fmt.Errorf("%w: closesAt must be in the future", domain.ErrInvalidInput)
+	}
+	if req.MaxSelections < 0 || req.MaxSelections > len(req.PollOptions) {
+		return nil, fmt.Errorf("%w: maxSelections must be at most the number of options, or 0 for one", domain.ErrInvalidInput)
+	}
+	if req.ResultsVisibility == "" {
+		req.ResultsVisibility = domain.ResultsAlways
+	}
+	if !domain.ValidResultsVisibility(string(req.ResultsVisibility)) {
+		return nil, fmt.Errorf("%w: invalid resultsVisibility", domain.ErrInvalidInput)
+	}
+	// Without a deadline the poll never closes and only its author would
+	// ever see the results
+	if req.ResultsVisibility == domain.ResultsAfterClose && req.ClosesAt == 0 {
+		return nil, fmt.Errorf("%w: resultsVisibility after_close requires closesAt", domain.ErrInvalidInput)
 	}
 	return s.createPostInternal(ctx, req, domain.PostTypePoll)
 }
@@ -75,52 +126,56 @@ func (s *service) createPostInternal(ctx context.Context, req *CreatePostRequest
 	depth := domain.NormalizeDepth(req.AIRaw.Depth)
 
 	post := &Post{
-		AuthorID:    req.AuthorID,
-		PostType:    postType,
-		Text:        req.Text,
-		PollOptions: req.PollOptions,
-		Category:    category,
-		Intent:      req.AIRaw.Intent,
-		Depth:       depth,
-		AIRaw:       req.AIRaw,
-		CreatedAt:   now,
+		AuthorID:  req.AuthorID,
+		PostType:  postType,
+		Text:      req.Text,
+		Category:  category,
+		Intent:    req.AIRaw.Intent,
+		Depth:     depth,
+		AIRaw:     req.AIRaw,
+		CreatedAt: now,
+	}
+	if postType == domain.PostTypePoll {
+		post.PollOptions = newPollOptions(req.PollOptions)
+		post.ClosesAt = req.ClosesAt
+		post.MaxSelections = req.MaxSelections
+		post.ResultsVisibility = req.ResultsVisibility
 	}
 
-	postKey, err := s.repo.Create(ctx, post)
+	// Normalize tags up front: canonical tags are shared across posts, so
+	// creating them outside the transaction is harmless if the post fails
+	normalizedTags, err := s.tagService.NormalizeTags(ctx, req.AIRaw.Tags)
 	if err != nil {
-		return nil, fmt.Errorf("create post: %w", err)
+		return nil, fmt.Errorf("normalize tags: %w", err)
 	}
 
-	// Create edges and normalize tags in parallel
-	g, gCtx := errgroup.WithContext(ctx)
-
-	// Create authorship edge
-	g.Go(func() error {
-		return s.repo.CreateCreatedEdge(gCtx, req.AuthorID, postKey, now)
-	})
+	// Insert the post and its edges atomically. Stream transactions do not
+	// allow concurrent operations, so writes run sequentially.
+	var postKey string
+	err = s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		postKey, err = s.repo.Create(ctx, post)
+		if err != nil {
+			return fmt.Errorf("create post: %w", err)
+		}
 
-	// Normalize and create tag edges
-	var normalizedTags []string
-	g.Go(func() error {
-		var tagErr error
-		normalizedTags, tagErr = s.tagService.NormalizeTags(gCtx, req.AIRaw.Tags)
-		if tagErr != nil {
-			return tagErr
+		if err := s.repo.CreateCreatedEdge(ctx, req.AuthorID, postKey, now); err != nil {
+			return fmt.Errorf("create created edge: %w", err)
 		}
 
-		// Create tag edges (can be further parallelized if needed)
 		for _, tagKey := range normalizedTags {
-			if err := s.repo.CreatePostHasTagEdge(gCtx, postKey, tagKey, req.AIRaw.Confidence); err != nil {
-				return err
+			if err := s.repo.CreatePostHasTagEdge(ctx, postKey, tagKey, req.AIRaw.Confidence); err != nil {
+				return fmt.Errorf("create tag edge: %w", err)
 			}
 		}
 		return nil
 	})
-
-	if err := g.Wait(); err != nil {
-		return nil, fmt.Errorf("post creation edges: %w", err)
+	if err != nil {
+		return nil, err
 	}
 
+	slog.InfoContext(ctx, "post created", "postId", postKey, "postType", postType, "tags", len(normalizedTags))
+
 	return &CreatePostResponse{
 		Key:       postKey,
 		Category:  category,
@@ -129,23 +184,144 @@ func (s *service) createPostInternal(ctx context.Context, req *CreatePostRequest
 	}, nil
 }
 
+func (s *service) UpdatePost(ctx context.Context, postID string, req *UpdatePostRequest) (*UpdatePostResponse, error) {
+	ctx, span := trace.Start(ctx, "post.Service.UpdatePost")
+	defer span.End()
+
+	post, err := s.repo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, fmt.Errorf("get post: %w", err)
+	}
+	if post == nil || post.IsDeleted() {
+		return nil, domain.ErrNotFound
+	}
+	if err := s.requireAuthor(ctx, postID, req.AuthorID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if now.Sub(time.UnixMilli(post.CreatedAt)) > editWindow {
+		return nil, domain.ErrEditWindowClosed.With("editWindowSeconds", int(editWindow.Seconds()))
+	}
+
+	// Keep the current version before overwriting it
+	revision := &PostRevision{
+		PostID:    fmt.Sprintf("posts/%s", postID),
+		EditorID:  fmt.Sprintf("users/%s", req.AuthorID),
+		Text:      post.Text,
+		Category:  post.Category,
+		Intent:    post.Intent,
+		Depth:     post.Depth,
+		AIRaw:     post.AIRaw,
+		CreatedAt: now.UnixMilli(),
+	}
+
+	// Re-run classification for the new text
+	post.Text = req.Text
+	post.Category = domain.NormalizeCategory(req.AIRaw.Category)
+	post.Depth = domain.NormalizeDepth(req.AIRaw.Depth)
+	post.Intent = req.AIRaw.Intent
+	post.AIRaw = req.AIRaw
+	post.UpdatedAt = now.UnixMilli()
+
+	normalizedTags, err := s.tagService.NormalizeTags(ctx, req.AIRaw.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("normalize tags: %w", err)
+	}
+
+	err = s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateRevision(ctx, revision); err != nil {
+			return fmt.Errorf("create revision: %w", err)
+		}
+
+		if err := s.repo.Update(ctx, post); err != nil {
+			return fmt.Errorf("update post: %w", err)
+		}
+
+		// Re-link tags
+		if err := s.repo.DeletePostHasTagEdges(ctx, postID); err != nil {
+			return fmt.Errorf("delete tag edges: %w", err)
+		}
+		for _, tagKey := range normalizedTags {
+			if err := s.repo.CreatePostHasTagEdge(ctx, postID, tagKey, req.AIRaw.Confidence); err != nil {
+				return fmt.Errorf("create tag edge: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &UpdatePostResponse{
+		Key:       postID,
+		Category:  post.Category,
+		Tags:      normalizedTags,
+		UpdatedAt: post.UpdatedAt,
+	}, nil
+}
+
+// DeletePost soft-deletes a post. The document stays in place so existing
+// chats keep their question context, but it disappears from feeds and lookups.
+func (s *service) DeletePost(ctx context.Context, postID, userID string) (*DeletePostResponse, error) {
+	ctx, span := trace.Start(ctx, "post.Service.DeletePost")
+	defer span.End()
+
+	post, err := s.repo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, fmt.Errorf("get post: %w", err)
+	}
+	if post == nil || post.IsDeleted() {
+		return nil, domain.ErrNotFound
+	}
+	if err := s.requireAuthor(ctx, postID, userID); err != nil {
+		return nil, err
+	}
+
+	post.DeletedAt = time.Now().UnixMilli()
+	if err := s.repo.Update(ctx, post); err != nil {
+		return nil, fmt.Errorf("delete post: %w", err)
+	}
+
+	return &DeletePostResponse{
+		Success: true,
+		PostID:  postID,
+	}, nil
+}
+
+// requireAuthor returns ErrForbidden unless userID created the post
+func (s *service) requireAuthor(ctx context.Context, postID, userID string) error {
+	author, err := s.repo.GetAuthor(ctx, postID)
+	if err != nil {
+		return fmt.Errorf("get post author: %w", err)
+	}
+	if author == nil || author.ID != userID {
+		return domain.ErrNotAuthor
+	}
+	return nil
+}
+
 func (s *service) RespondToPost(ctx context.Context, postID string, req *RespondToPostRequest) (*RespondToPostResponse, error) {
+	ctx, span := trace.Start(ctx, "post.Service.RespondToPost")
+	defer span.End()
+
 	// Check if post exists
 	post, err := s.repo.GetByID(ctx, postID)
 	if err != nil {
 		return nil, fmt.Errorf("get post: %w", err)
 	}
-	if post == nil {
+	if post == nil || post.IsDeleted() {
 		return nil, domain.ErrNotFound
 	}
 
-	// Check if user has already responded
+	// Fast path for repeat responses; the unique index on responded rejects
+	// concurrent ones, which aborts the transaction below
 	hasResponded, err := s.repo.HasUserResponded(ctx, req.UserID, postID)
 	if err != nil {
 		return nil, fmt.Errorf("check responded: %w", err)
 	}
 	if hasResponded {
-		return nil, domain.ErrAlreadyExists
+		return nil, domain.ErrAlreadyResponded
 	}
 
 	// Get post author
@@ -159,7 +335,7 @@ func (s *service) RespondToPost(ctx context.Context, postID string, req *Respond
 
 	// Don't allow responding to your own post
 	if author.ID == req.UserID {
-		return nil, fmt.Errorf("%w: cannot respond to your own post", domain.ErrInvalidInput)
+		return nil, domain.ErrOwnPost
 	}
 
 	// Determine chat type (default to direct)
@@ -168,87 +344,251 @@ func (s *service) RespondToPost(ctx context.Context, postID string, req *Respond
 		chatType = req.ChatType
 	}
 
-	// Create chat with author first (for proper role assignment), then responder
-	// In CreateChat: participants[0] = author role, rest = responder role
-	participants := []string{author.ID, req.UserID}
-	chatID, err := s.chatService.CreateChat(ctx, postID, chatType, participants)
+	now := time.Now().UnixMilli()
+
+	// Chat, participation, message and responded edge are written atomically
+	var chatID, messageID string
+	respond := func(ctx context.Context) error {
+		// Create chat with author first (for proper role assignment), then responder
+		// In CreateChat: participants[0] = author role, rest = responder role
+		participants := []string{author.ID, req.UserID}
+		var err error
+		chatID, err = s.chatService.CreateChat(ctx, postID, chatType, participants)
+		if err != nil {
+			return fmt.Errorf("create chat: %w", err)
+		}
+
+		// Send the response message
+		msgResp, err := s.chatService.SendMessage(ctx, chatID, &chat.SendMessageRequest{
+			SenderID: req.UserID,
+			Text:     req.Text,
+		})
+		if err != nil {
+			return fmt.Errorf("send message: %w", err)
+		}
+		messageID = msgResp.MessageID
+
+		// Create responded edge
+		if err := s.repo.CreateRespondedEdge(ctx, req.UserID, postID, chatID, now); err != nil {
+			return fmt.Errorf("create responded edge: %w", err)
+		}
+		return nil
+	}
+
+	// A write-write conflict aborts the whole transaction, so it is safe to
+	// run again; if a concurrent response committed, the retry fails on the
+	// responded index instead
+	err = s.repo.WithTransaction(ctx, respond)
+	if errors.Is(err, domain.ErrConflict) {
+		err = s.repo.WithTransaction(ctx, respond)
+	}
+	if errors.Is(err, domain.ErrAlreadyExists) {
+		// A concurrent response won the race on the responded index
+		return nil, domain.ErrAlreadyResponded
+	}
 	if err != nil {
-		return nil, fmt.Errorf("create chat: %w", err)
+		return nil, err
 	}
 
-	// Send the response message
-	msgResp, err := s.chatService.SendMessage(ctx, chatID, &chat.SendMessageRequest{
-		SenderID: req.UserID,
-		Text:     req.Text,
-	})
+	return &RespondToPostResponse{
+		ChatID:    chatID,
+		MessageID: messageID,
+		CreatedAt: now,
+	}, nil
+}
+
+func (s *service) Vote(ctx context.Context, postID string, req *VoteRequest) (*VoteResponse, error) {
+	ctx, span := trace.Start(ctx, "post.Service.Vote")
+	defer span.End()
+
+	post, err := s.getOpenPoll(ctx, postID)
 	if err != nil {
-		return nil, fmt.Errorf("send message: %w", err)
+		return nil, err
+	}
+
+	// Accept either a single option or a list, without duplicates
+	selection := req.OptionIDs
+	if len(selection) == 0 && req.OptionID != "" {
+		selection = []string{req.OptionID}
+	}
+	selection = slices.Compact(slices.Sorted(slices.Values(selection)))
+	if len(selection) == 0 {
+		return nil, fmt.Errorf("%w: at least one option is required", domain.ErrInvalidInput)
+	}
+	if len(selection) > post.SelectionLimit() {
+		return nil, domain.ErrTooManyOptions.With("maxSelections", post.SelectionLimit())
+	}
+	for _, optionID := range selection {
+		if !post.HasOption(optionID) {
+			return nil, domain.ErrInvalidOption.With("optionId", optionID)
+		}
 	}
 
+	// Create or replace the vote. Two concurrent first votes race on the
+	// unique voted index, and concurrent updates of one edge conflict; the
+	// loser retries once and updates the winner's edge.
 	now := time.Now().UnixMilli()
+	err = s.repo.UpsertVote(ctx, req.UserID, postID, selection, now)
+	if errors.Is(err, domain.ErrAlreadyExists) || errors.Is(err, domain.ErrConflict) {
+		err = s.repo.UpsertVote(ctx, req.UserID, postID, selection, now)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("upsert vote: %w", err)
+	}
 
-	// Create responded edge
-	if err := s.repo.CreateRespondedEdge(ctx, req.UserID, postID, chatID, now); err != nil {
-		return nil, fmt.Errorf("create responded edge: %w", err)
+	results, err := s.pollResults(ctx, post, req.UserID)
+	if err != nil {
+		return nil, err
 	}
 
-	return &RespondToPostResponse{
-		ChatID:    chatID,
-		MessageID: msgResp.MessageID,
-		CreatedAt: now,
+	return &VoteResponse{
+		PostID: postID,
+		Poll:   results,
 	}, nil
 }
 
-func (s *service) Vote(ctx context.Context, postID string, req *VoteRequest) (*VoteResponse, error) {
-	// Check if post exists and is a poll
+func (s *service) RetractVote(ctx context.Context, postID, userID string) (*VoteResponse, error) {
+	ctx, span := trace.Start(ctx, "post.Service.RetractVote")
+	defer span.End()
+
+	post, err := s.getOpenPoll(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	removed, err := s.repo.DeleteVote(ctx, userID, postID)
+	if err != nil {
+		return nil, fmt.Errorf("delete vote: %w", err)
+	}
+	if !removed {
+		return nil, domain.ErrNotFound
+	}
+
+	results, err := s.pollResults(ctx, post, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VoteResponse{
+		PostID: postID,
+		Poll:   results,
+	}, nil
+}
+
+// getOpenPoll loads a poll that still accepts votes
+func (s *service) getOpenPoll(ctx context.Context, postID string) (*Post, error) {
 	post, err := s.repo.GetByID(ctx, postID)
 	if err != nil {
 		return nil, fmt.Errorf("get post: %w", err)
 	}
-	if post == nil {
+	if post == nil || post.IsDeleted() {
 		return nil, domain.ErrNotFound
 	}
 	if post.PostType != domain.PostTypePoll {
-		return nil, fmt.Errorf("%w: post is not a poll", domain.ErrInvalidInput)
+		return nil, domain.ErrNotAPoll
+	}
+	if post.IsClosed(time.Now().UnixMilli()) {
+		return nil, domain.ErrPollClosed.With("closesAt", post.ClosesAt)
+	}
+	return post, nil
+}
+
+// pollResults builds the poll state seen by userID, hiding tallies until the
+// author's visibility setting allows them
+func (s *service) pollResults(ctx context.Context, post *Post, userID string) (*PollResults, error) {
+	tally, err := s.repo.GetVotes(ctx, post.Key)
+	if err != nil {
+		return nil, fmt.Errorf("get votes: %w", err)
 	}
 
-	// Validate option
-	validOption := false
-	for _, opt := range post.PollOptions {
-		if opt == req.Option {
-			validOption = true
-			break
+	var mySelection []string
+	if userID != "" {
+		vote, err := s.repo.GetUserVote(ctx, userID, post.Key)
+		if err != nil {
+			return nil, fmt.Errorf("get user vote: %w", err)
 		}
+		if vote != nil {
+			mySelection = vote.OptionIDs
+		}
+	}
+
+	visibility := post.ResultsVisibility
+	if visibility == "" {
+		visibility = domain.ResultsAlways
 	}
-	if !validOption {
-		return nil, fmt.Errorf("%w: invalid poll option", domain.ErrInvalidInput)
+	closed := post.IsClosed(time.Now().UnixMilli())
+
+	visible := closed || post.AuthorKey() == userID
+	switch visibility {
+	case domain.ResultsAlways:
+		visible = true
+	case domain.ResultsAfterVote:
+		visible = visible || len(mySelection) > 0
 	}
 
-	// Check if user has already voted
-	hasVoted, err := s.repo.HasUserVoted(ctx, req.UserID, postID)
-	if err != nil {
-		return nil, fmt.Errorf("check voted: %w", err)
+	results := &PollResults{
+		ClosesAt:          post.ClosesAt,
+		Closed:            closed,
+		MaxSelections:     post.SelectionLimit(),
+		ResultsVisibility: visibility,
+		ResultsVisible:    visible,
+		TotalVoters:       tally.TotalVoters,
+		MySelection:       mySelection,
 	}
-	if hasVoted {
-		return nil, domain.ErrAlreadyExists
+
+	if visible {
+		results.Tallies = make([]PollTally, len(post.PollOptions))
+		for i, option := range post.PollOptions {
+			votes := tally.Counts[option.ID]
+			var pct float64
+			if tally.TotalVoters > 0 {
+				pct = math.Round(float64(votes)*1000/float64(tally.TotalVoters)) / 10
+			}
+			results.Tallies[i] = PollTally{OptionID: option.ID, Text: option.Text, Votes: votes, Percentage: pct}
+		}
 	}
 
-	now := time.Now().UnixMilli()
+	return results, nil
+}
 
-	// Create vote edge
-	if err := s.repo.CreateVotedEdge(ctx, req.UserID, postID, req.Option, now); err != nil {
-		return nil, fmt.Errorf("create vote: %w", err)
+// validatePollOptions enforces option count, length and uniqueness. Options
+// that differ only in case or whitespace count as duplicates.
+func validatePollOptions(options []string) error {
+	if len(options) < minPollOptions || len(options) > maxPollOptions {
+		return fmt.Errorf("%w: poll requires between %d and %d options", domain.ErrInvalidInput, minPollOptions, maxPollOptions)
 	}
 
-	// Get updated vote counts
-	votes, err := s.repo.GetVotes(ctx, postID)
-	if err != nil {
-		return nil, fmt.Errorf("get votes: %w", err)
+	seen := make(map[string]bool, len(options))
+	for _, option := range options {
+		text := normalizeOptionText(option)
+		if text == "" {
+			return fmt.Errorf("%w: poll options cannot be empty", domain.ErrInvalidInput)
+		}
+		if utf8.RuneCountInString(text) > maxPollOptionLength {
+			return fmt.Errorf("%w: poll options must be at most %d characters", domain.ErrInvalidInput, maxPollOptionLength)
+		}
+		key := strings.ToLower(text)
+		if seen[key] {
+			return fmt.Errorf("%w: duplicate poll option %q", domain.ErrInvalidInput, text)
+		}
+		seen[key] = true
 	}
+	return nil
+}
 
-	return &VoteResponse{
-		PostID: postID,
-		Option: req.Option,
-		Votes:  votes,
-	}, nil
+// newPollOptions assigns stable positional IDs to validated option texts
+func newPollOptions(texts []string) []PollOption {
+	options := make([]PollOption, len(texts))
+	for i, text := range texts {
+		options[i] = PollOption{
+			ID:   fmt.Sprintf("o%d", i+1),
+			Text: normalizeOptionText(text),
+		}
+	}
+	return options
+}
+
+// normalizeOptionText trims and collapses internal whitespace
+func normalizeOptionText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
 }
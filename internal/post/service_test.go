@@ -0,0 +1,498 @@
+package post_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/askme/api/internal/domain"
+	"github.com/askme/api/internal/memrepo"
+	"github.com/askme/api/internal/post"
+)
+
+// fixture adds post helpers to the shared seeded fixture
+type fixture struct {
+	*memrepo.Fixture
+}
+
+func newFixture(t *testing.T) *fixture {
+	return &fixture{memrepo.Seed(t)}
+}
+
+func (f *fixture) createPost(t *testing.T, authorID string) string {
+	t.Helper()
+	resp, err := f.Posts.CreatePost(context.Background(), &post.CreatePostRequest{
+		AuthorID: authorID,
+		Text:     "How do I switch careers into tech?",
+		AIRaw:    domain.AIRawData{Category: "career", Intent: "advice", Tags: []string{"career change"}},
+	})
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	return resp.Key
+}
+
+func (f *fixture) createPoll(t *testing.T, req *post.CreatePostRequest) string {
+	t.Helper()
+	if req.AuthorID == "" {
+		req.AuthorID = "alice"
+	}
+	if req.Text == "" {
+		req.Text = "Tabs or spaces?"
+	}
+	if req.PollOptions == nil {
+		req.PollOptions = []string{"Tabs", "Spaces", "Both"}
+	}
+	resp, err := f.Posts.CreatePoll(context.Background(), req)
+	if err != nil {
+		t.Fatalf("create poll: %v", err)
+	}
+	return resp.Key
+}
+
+// backdate moves a post's creation and close times into the past
+func (f *fixture) backdate(t *testing.T, postID string, by time.Duration) {
+	t.Helper()
+	ctx := context.Background()
+	p, err := f.PostRepo.GetByID(ctx, postID)
+	if err != nil || p == nil {
+		t.Fatalf("get post %s: %v", postID, err)
+	}
+	p.CreatedAt -= by.Milliseconds()
+	if p.ClosesAt != 0 {
+		p.ClosesAt -= by.Milliseconds()
+	}
+	if err := f.PostRepo.Update(ctx, p); err != nil {
+		t.Fatalf("update post: %v", err)
+	}
+}
+
+func TestCreatePollValidation(t *testing.T) {
+	future := time.Now().Add(time.Hour).UnixMilli()
+	past := time.Now().Add(-time.Hour).UnixMilli()
+	long := strings.Repeat("x", 81)
+
+	tests := []struct {
+		name string
+		req  post.CreatePostRequest
+	}{
+		{"one option", post.CreatePostRequest{PollOptions: []string{"Yes"}}},
+		{"eleven options", post.CreatePostRequest{PollOptions: []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11"}}},
+		{"blank option", post.CreatePostRequest{PollOptions: []string{"Yes", "   "}}},
+		{"option too long", post.CreatePostRequest{PollOptions: []string{"Yes", long}}},
+		{"duplicate ignoring case and spacing", post.CreatePostRequest{PollOptions: []string{"Dark mode", " dark   MODE "}}},
+		{"closes in the past", post.CreatePostRequest{PollOptions: []string{"Yes", "No"}, ClosesAt: past}},
+		{"negative maxSelections", post.CreatePostRequest{PollOptions: []string{"Yes", "No"}, MaxSelections: -1}},
+		{"maxSelections above options", post.CreatePostRequest{PollOptions: []string{"Yes", "No"}, MaxSelections: 3}},
+		{"unknown visibility", post.CreatePostRequest{PollOptions: []string{"Yes", "No"}, ClosesAt: future, ResultsVisibility: "never"}},
+		{"after_close without closesAt", post.CreatePostRequest{PollOptions: []string{"Yes", "No"}, ResultsVisibility: domain.ResultsAfterClose}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			req := tt.req
+			req.AuthorID = "alice"
+			req.Text = "Poll"
+			_, err := f.Posts.CreatePoll(context.Background(), &req)
+			if !errors.Is(err, domain.ErrInvalidInput) {
+				t.Fatalf("got %v, want ErrInvalidInput", err)
+			}
+		})
+	}
+}
+
+func TestCreatePollAssignsOptionIDs(t *testing.T) {
+	f := newFixture(t)
+	postID := f.createPoll(t, &post.CreatePostRequest{PollOptions: []string{"  Tabs ", "Spaces  please"}})
+
+	got, err := f.Posts.GetPost(context.Background(), postID, "bob")
+	if err != nil {
+		t.Fatalf("get poll: %v", err)
+	}
+	want := []post.PollOption{{ID: "o1", Text: "Tabs"}, {ID: "o2", Text: "Spaces please"}}
+	if !slices.Equal(got.PollOptions, want) {
+		t.Errorf("options = %v, want %v", got.PollOptions, want)
+	}
+	if got.Poll == nil || got.Poll.ResultsVisibility != domain.ResultsAlways || got.Poll.MaxSelections != 1 {
+		t.Errorf("poll = %+v, want always-visible single choice", got.Poll)
+	}
+}
+
+func TestVote(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("single choice", func(t *testing.T) {
+		f := newFixture(t)
+		postID := f.createPoll(t, &post.CreatePostRequest{})
+
+		resp, err := f.Posts.Vote(ctx, postID, &post.VoteRequest{UserID: "bob", OptionID: "o2"})
+		if err != nil {
+			t.Fatalf("vote: %v", err)
+		}
+		if resp.Poll.TotalVoters != 1 || resp.Poll.Tallies[1].Votes != 1 || resp.Poll.Tallies[1].Percentage != 100 {
+			t.Errorf("poll = %+v, want one vote for o2", resp.Poll)
+		}
+		if !slices.Equal(resp.Poll.MySelection, []string{"o2"}) {
+			t.Errorf("mySelection = %v, want [o2]", resp.Poll.MySelection)
+		}
+	})
+
+	t.Run("revote replaces selection", func(t *testing.T) {
+		f := newFixture(t)
+		postID := f.createPoll(t, &post.CreatePostRequest{})
+
+		if _, err := f.Posts.Vote(ctx, postID, &post.VoteRequest{UserID: "bob", OptionID: "o1"}); err != nil {
+			t.Fatalf("first vote: %v", err)
+		}
+		resp, err := f.Posts.Vote(ctx, postID, &post.VoteRequest{UserID: "bob", OptionID: "o3"})
+		if err != nil {
+			t.Fatalf("second vote: %v", err)
+		}
+		if resp.Poll.TotalVoters != 1 || resp.Poll.Tallies[0].Votes != 0 || resp.Poll.Tallies[2].Votes != 1 {
+			t.Errorf("poll = %+v, want the vote moved to o3", resp.Poll)
+		}
+	})
+
+	t.Run("duplicate selections collapse", func(t *testing.T) {
+		f := newFixture(t)
+		postID := f.createPoll(t, &post.CreatePostRequest{})
+
+		resp, err := f.Posts.Vote(ctx, postID, &post.VoteRequest{UserID: "bob", OptionIDs: []string{"o1", "o1"}})
+		if err != nil {
+			t.Fatalf("vote: %v", err)
+		}
+		if !slices.Equal(resp.Poll.MySelection, []string{"o1"}) {
+			t.Errorf("mySelection = %v, want [o1]", resp.Poll.MySelection)
+		}
+	})
+
+	t.Run("multi choice within limit", func(t *testing.T) {
+		f := newFixture(t)
+		postID := f.createPoll(t, &post.CreatePostRequest{MaxSelections: 2})
+
+		resp, err := f.Posts.Vote(ctx, postID, &post.VoteRequest{UserID: "bob", OptionIDs: []string{"o3", "o1"}})
+		if err != nil {
+			t.Fatalf("vote: %v", err)
+		}
+		if !slices.Equal(resp.Poll.MySelection, []string{"o1", "o3"}) {
+			t.Errorf("mySelection = %v, want [o1 o3]", resp.Poll.MySelection)
+		}
+	})
+
+	rejected := []struct {
+		name string
+		poll post.CreatePostRequest
+		vote post.VoteRequest
+	}{
+		{"no selection", post.CreatePostRequest{}, post.VoteRequest{UserID: "bob"}},
+		{"too many selections", post.CreatePostRequest{}, post.VoteRequest{UserID: "bob", OptionIDs: []string{"o1", "o2"}}},
+		{"over maxSelections", post.CreatePostRequest{MaxSelections: 2}, post.VoteRequest{UserID: "bob", OptionIDs: []string{"o1", "o2", "o3"}}},
+		{"unknown option", post.CreatePostRequest{}, post.VoteRequest{UserID: "bob", OptionID: "o9"}},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			postID := f.createPoll(t, &tt.poll)
+			_, err := f.Posts.Vote(ctx, postID, &tt.vote)
+			if !errors.Is(err, domain.ErrInvalidInput) {
+				t.Fatalf("got %v, want ErrInvalidInput", err)
+			}
+		})
+	}
+
+	t.Run("closed poll", func(t *testing.T) {
+		f := newFixture(t)
+		postID := f.createPoll(t, &post.CreatePostRequest{ClosesAt: time.Now().Add(time.Hour).UnixMilli()})
+		f.backdate(t, postID, 2*time.Hour)
+
+		_, err := f.Posts.Vote(ctx, postID, &post.VoteRequest{UserID: "bob", OptionID: "o1"})
+		if !errors.Is(err, domain.ErrPollClosed) {
+			t.Fatalf("got %v, want ErrPollClosed", err)
+		}
+	})
+
+	t.Run("not a poll", func(t *testing.T) {
+		f := newFixture(t)
+		postID := f.createPost(t, "alice")
+
+		_, err := f.Posts.Vote(ctx, postID, &post.VoteRequest{UserID: "bob", OptionID: "o1"})
+		if !errors.Is(err, domain.ErrInvalidInput) {
+			t.Fatalf("got %v, want ErrInvalidInput", err)
+		}
+	})
+
+	t.Run("missing post", func(t *testing.T) {
+		f := newFixture(t)
+		_, err := f.Posts.Vote(ctx, "nope", &post.VoteRequest{UserID: "bob", OptionID: "o1"})
+		if !errors.Is(err, domain.ErrNotFound) {
+			t.Fatalf("got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestRetractVote(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	postID := f.createPoll(t, &post.CreatePostRequest{})
+
+	if _, err := f.Posts.RetractVote(ctx, postID, "bob"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("retract without vote: got %v, want ErrNotFound", err)
+	}
+
+	if _, err := f.Posts.Vote(ctx, postID, &post.VoteRequest{UserID: "bob", OptionID: "o1"}); err != nil {
+		t.Fatalf("vote: %v", err)
+	}
+	resp, err := f.Posts.RetractVote(ctx, postID, "bob")
+	if err != nil {
+		t.Fatalf("retract: %v", err)
+	}
+	if resp.Poll.TotalVoters != 0 || len(resp.Poll.MySelection) != 0 {
+		t.Errorf("poll = %+v, want no votes", resp.Poll)
+	}
+}
+
+func TestPollResultsVisibility(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	postID := f.createPoll(t, &post.CreatePostRequest{ResultsVisibility: domain.ResultsAfterVote})
+
+	got, err := f.Posts.GetPost(ctx, postID, "bob")
+	if err != nil {
+		t.Fatalf("get poll: %v", err)
+	}
+	if got.Poll.ResultsVisible || got.Poll.Tallies != nil {
+		t.Errorf("results visible before voting: %+v", got.Poll)
+	}
+
+	author, err := f.Posts.GetPost(ctx, postID, "alice")
+	if err != nil {
+		t.Fatalf("get poll as author: %v", err)
+	}
+	if !author.Poll.ResultsVisible {
+		t.Error("results hidden from the author")
+	}
+
+	resp, err := f.Posts.Vote(ctx, postID, &post.VoteRequest{UserID: "bob", OptionID: "o1"})
+	if err != nil {
+		t.Fatalf("vote: %v", err)
+	}
+	if !resp.Poll.ResultsVisible || len(resp.Poll.Tallies) != 3 {
+		t.Errorf("results hidden after voting: %+v", resp.Poll)
+	}
+}
+
+func TestRespondToPost(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	postID := f.createPost(t, "alice")
+
+	resp, err := f.Posts.RespondToPost(ctx, postID, &post.RespondToPostRequest{UserID: "bob", Text: "Start with a bootcamp"})
+	if err != nil {
+		t.Fatalf("respond: %v", err)
+	}
+
+	participants, err := f.ChatRepo.GetParticipants(ctx, resp.ChatID)
+	if err != nil {
+		t.Fatalf("get participants: %v", err)
+	}
+	if len(participants) != 2 || participants[0].ID != "alice" || participants[0].Role != domain.RoleAuthor ||
+		participants[1].ID != "bob" || participants[1].Role != domain.RoleResponder {
+		t.Errorf("participants = %+v, want alice as author and bob as responder", participants)
+	}
+
+	c, err := f.ChatRepo.GetByID(ctx, resp.ChatID)
+	if err != nil || c == nil {
+		t.Fatalf("get chat: %v", err)
+	}
+	if c.Type != domain.ChatTypeDirect {
+		t.Errorf("chat type = %s, want direct", c.Type)
+	}
+
+	msg, err := f.ChatRepo.GetMessageByID(ctx, resp.MessageID)
+	if err != nil || msg == nil {
+		t.Fatalf("get message: %v", err)
+	}
+	if msg.Text != "Start with a bootcamp" || msg.SenderID != "users/bob" {
+		t.Errorf("message = %+v, want bob's response", msg)
+	}
+
+	responded, err := f.PostRepo.HasUserResponded(ctx, "bob", postID)
+	if err != nil || !responded {
+		t.Errorf("responded = %v, %v, want true", responded, err)
+	}
+
+	t.Run("twice", func(t *testing.T) {
+		_, err := f.Posts.RespondToPost(ctx, postID, &post.RespondToPostRequest{UserID: "bob", Text: "Again"})
+		if !errors.Is(err, domain.ErrAlreadyExists) {
+			t.Fatalf("got %v, want ErrAlreadyExists", err)
+		}
+	})
+
+	t.Run("own post", func(t *testing.T) {
+		_, err := f.Posts.RespondToPost(ctx, postID, &post.RespondToPostRequest{UserID: "alice", Text: "Bump"})
+		if !errors.Is(err, domain.ErrInvalidInput) {
+			t.Fatalf("got %v, want ErrInvalidInput", err)
+		}
+	})
+
+	t.Run("deleted post", func(t *testing.T) {
+		deleted := f.createPost(t, "alice")
+		if _, err := f.Posts.DeletePost(ctx, deleted, "alice"); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+		_, err := f.Posts.RespondToPost(ctx, deleted, &post.RespondToPostRequest{UserID: "carol", Text: "Hi"})
+		if !errors.Is(err, domain.ErrNotFound) {
+			t.Fatalf("got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+// conflictingRepository fails the next writes with a write-write conflict,
+// as a concurrent transaction on the same edge would
+type conflictingRepository struct {
+	post.Repository
+	conflicts int
+}
+
+func (r *conflictingRepository) conflict() bool {
+	if r.conflicts == 0 {
+		return false
+	}
+	r.conflicts--
+	return true
+}
+
+func (r *conflictingRepository) UpsertVote(ctx context.Context, userID, postID string, optionIDs []string, now int64) error {
+	if r.conflict() {
+		return domain.ErrConflict
+	}
+	return r.Repository.UpsertVote(ctx, userID, postID, optionIDs, now)
+}
+
+func (r *conflictingRepository) CreateRespondedEdge(ctx context.Context, userID, postID, chatID string, createdAt int64) error {
+	if r.conflict() {
+		return domain.ErrConflict
+	}
+	return r.Repository.CreateRespondedEdge(ctx, userID, postID, chatID, createdAt)
+}
+
+func TestWriteConflicts(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("vote retried", func(t *testing.T) {
+		f := newFixture(t)
+		postID := f.createPoll(t, &post.CreatePostRequest{})
+		posts := post.NewService(&conflictingRepository{Repository: f.PostRepo, conflicts: 1}, f.Tags, f.Chats)
+
+		resp, err := posts.Vote(ctx, postID, &post.VoteRequest{UserID: "bob", OptionID: "o2"})
+		if err != nil {
+			t.Fatalf("vote: %v", err)
+		}
+		if resp.Poll.TotalVoters != 1 {
+			t.Errorf("poll = %+v, want one voter", resp.Poll)
+		}
+	})
+
+	t.Run("vote conflicts again", func(t *testing.T) {
+		f := newFixture(t)
+		postID := f.createPoll(t, &post.CreatePostRequest{})
+		posts := post.NewService(&conflictingRepository{Repository: f.PostRepo, conflicts: 2}, f.Tags, f.Chats)
+
+		_, err := posts.Vote(ctx, postID, &post.VoteRequest{UserID: "bob", OptionID: "o2"})
+		if !errors.Is(err, domain.ErrConflict) {
+			t.Fatalf("got %v, want ErrConflict", err)
+		}
+	})
+
+	t.Run("respond retried", func(t *testing.T) {
+		f := newFixture(t)
+		postID := f.createPost(t, "alice")
+		posts := post.NewService(&conflictingRepository{Repository: f.PostRepo, conflicts: 1}, f.Tags, f.Chats)
+
+		resp, err := posts.RespondToPost(ctx, postID, &post.RespondToPostRequest{UserID: "bob", Text: "Start with a bootcamp"})
+		if err != nil {
+			t.Fatalf("respond: %v", err)
+		}
+
+		// The aborted attempt left no chat behind
+		chats, err := f.Chats.GetUserChats(ctx, "bob", 10, "")
+		if err != nil {
+			t.Fatalf("get chats: %v", err)
+		}
+		if len(chats.Threads) != 1 || chats.Threads[0].ID != resp.ChatID {
+			t.Errorf("threads = %+v, want only chat %s", chats.Threads, resp.ChatID)
+		}
+	})
+}
+
+func TestUpdatePost(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("author within window", func(t *testing.T) {
+		f := newFixture(t)
+		postID := f.createPost(t, "alice")
+
+		resp, err := f.Posts.UpdatePost(ctx, postID, &post.UpdatePostRequest{
+			AuthorID: "alice",
+			Text:     "How do I get into backend development?",
+			AIRaw:    domain.AIRawData{Category: "tech", Tags: []string{"backend dev"}},
+		})
+		if err != nil {
+			t.Fatalf("update: %v", err)
+		}
+		if resp.Category != domain.CategoryTech || !slices.Equal(resp.Tags, []string{"backend-dev"}) {
+			t.Errorf("response = %+v, want tech with backend-dev", resp)
+		}
+
+		tags, err := f.PostRepo.GetPostTags(ctx, postID)
+		if err != nil {
+			t.Fatalf("get tags: %v", err)
+		}
+		if !slices.Equal(tags, []string{"backend-dev"}) {
+			t.Errorf("tags = %v, want only the re-linked tag", tags)
+		}
+	})
+
+	t.Run("not the author", func(t *testing.T) {
+		f := newFixture(t)
+		postID := f.createPost(t, "alice")
+
+		_, err := f.Posts.UpdatePost(ctx, postID, &post.UpdatePostRequest{AuthorID: "bob", Text: "Mine now"})
+		if !errors.Is(err, domain.ErrForbidden) {
+			t.Fatalf("got %v, want ErrForbidden", err)
+		}
+	})
+
+	t.Run("edit window expired", func(t *testing.T) {
+		f := newFixture(t)
+		postID := f.createPost(t, "alice")
+		f.backdate(t, postID, 16*time.Minute)
+
+		_, err := f.Posts.UpdatePost(ctx, postID, &post.UpdatePostRequest{AuthorID: "alice", Text: "Too late"})
+		if !errors.Is(err, domain.ErrForbidden) {
+			t.Fatalf("got %v, want ErrForbidden", err)
+		}
+	})
+}
+
+func TestDeletePost(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	postID := f.createPost(t, "alice")
+
+	if _, err := f.Posts.DeletePost(ctx, postID, "bob"); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("delete by other user: got %v, want ErrForbidden", err)
+	}
+	if _, err := f.Posts.DeletePost(ctx, postID, "alice"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := f.Posts.GetPost(ctx, postID, "alice"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("get deleted post: got %v, want ErrNotFound", err)
+	}
+	if _, err := f.Posts.DeletePost(ctx, postID, "alice"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("delete twice: got %v, want ErrNotFound", err)
+	}
+}
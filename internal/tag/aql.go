@@ -1,5 +1,7 @@
 package tag
 
+import "github.com/askme/api/pkg/arango"
+
 // AQL queries for tag operations
 const (
 	// GetTagByID retrieves a tag by its key
@@ -45,3 +47,13 @@ const (
 		RETURN t
 	`
 )
+
+func init() {
+	arango.RegisterQueries("tag", map[string]string{
+		"GetTagByID":             GetTagByID,
+		"GetTagByAlias":          GetTagByAlias,
+		"IncrementTagUsageCount": IncrementTagUsageCount,
+		"ListTagsByUsage":        ListTagsByUsage,
+		"SearchTags":             SearchTags,
+	})
+}
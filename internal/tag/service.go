@@ -3,6 +3,7 @@ package tag
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"regexp"
 	"strings"
 	"time"
@@ -10,6 +11,8 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/askme/api/internal/domain"
+	"github.com/askme/api/pkg/slogutil"
+	"github.com/askme/api/pkg/trace"
 )
 
 type service struct {
@@ -22,6 +25,9 @@ func NewService(repo Repository) Service {
 }
 
 func (s *service) GetTag(ctx context.Context, id string) (*Tag, error) {
+	ctx, span := trace.Start(ctx, "tag.Service.GetTag")
+	defer span.End()
+
 	tag, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("get tag: %w", err)
@@ -33,6 +39,9 @@ func (s *service) GetTag(ctx context.Context, id string) (*Tag, error) {
 }
 
 func (s *service) ListTags(ctx context.Context, limit, offset int) ([]Tag, error) {
+	ctx, span := trace.Start(ctx, "tag.Service.ListTags")
+	defer span.End()
+
 	if limit <= 0 {
 		limit = 50
 	}
@@ -43,6 +52,9 @@ func (s *service) ListTags(ctx context.Context, limit, offset int) ([]Tag, error
 }
 
 func (s *service) SearchTags(ctx context.Context, query string, limit int) ([]Tag, error) {
+	ctx, span := trace.Start(ctx, "tag.Service.SearchTags")
+	defer span.End()
+
 	if limit <= 0 {
 		limit = 20
 	}
@@ -52,6 +64,9 @@ func (s *service) SearchTags(ctx context.Context, query string, limit int) ([]Ta
 // NormalizeTags converts raw AI tags to canonical tag keys
 // It creates new tags if they don't exist
 func (s *service) NormalizeTags(ctx context.Context, rawTags []string) ([]string, error) {
+	ctx, span := trace.Start(ctx, "tag.Service.NormalizeTags")
+	defer span.End()
+
 	if len(rawTags) == 0 {
 		return nil, nil
 	}
@@ -96,9 +111,13 @@ func (s *service) normalizeTag(ctx context.Context, rawTag string) (string, erro
 	}
 
 	if existingTag != nil {
-		// Increment usage count asynchronously (fire-and-forget)
+		// Increment usage count asynchronously (fire-and-forget), keeping
+		// only the request's log fields
+		bgCtx := slogutil.WithFields(context.Background(), slogutil.Fields(ctx)...)
 		go func() {
-			_ = s.repo.IncrementUsageCount(context.Background(), existingTag.Key)
+			if err := s.repo.IncrementUsageCount(bgCtx, existingTag.Key); err != nil {
+				slog.WarnContext(bgCtx, "increment tag usage failed", "tag", existingTag.Key, "error", err)
+			}
 		}()
 		return existingTag.Key, nil
 	}
@@ -0,0 +1,149 @@
+package tag_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/askme/api/internal/domain"
+	"github.com/askme/api/internal/memrepo"
+	"github.com/askme/api/internal/tag"
+)
+
+func newService(t *testing.T, tags ...tag.Tag) tag.Service {
+	t.Helper()
+	repo := memrepo.NewTagRepository(memrepo.NewStore())
+	for _, tg := range tags {
+		if _, err := repo.Create(context.Background(), &tg); err != nil {
+			t.Fatalf("create tag %s: %v", tg.Key, err)
+		}
+	}
+	return tag.NewService(repo)
+}
+
+func TestNormalizeTags(t *testing.T) {
+	ctx := context.Background()
+	golang := tag.Tag{Key: "golang", Label: "Go", Aliases: []string{"golang", "go-lang"}, UsageCount: 5}
+
+	tests := []struct {
+		name string
+		raw  []string
+		want []string
+	}{
+		{"empty", nil, nil},
+		{"existing alias", []string{"go-lang"}, []string{"golang"}},
+		{"existing label ignoring case", []string{"GO"}, []string{"golang"}},
+		{"new tag", []string{"Career Change"}, []string{"career-change"}},
+		{"punctuation and separators", []string{"  back_end -- Dev!! "}, []string{"back-end-dev"}},
+		{"spellings of one tag collapse", []string{"Career Change", "career_change", "career-change!"}, []string{"career-change"}},
+		{"order kept after dedupe", []string{"remote work", "go-lang", "Remote Work"}, []string{"remote-work", "golang"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newService(t, golang)
+			got, err := svc.NormalizeTags(ctx, tt.raw)
+			if err != nil {
+				t.Fatalf("normalize: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeTagsCreatesCanonicalTag(t *testing.T) {
+	ctx := context.Background()
+	svc := newService(t)
+
+	if _, err := svc.NormalizeTags(ctx, []string{"machine_learning"}); err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	got, err := svc.GetTag(ctx, "machine-learning")
+	if err != nil {
+		t.Fatalf("get tag: %v", err)
+	}
+	if got.Label != "Machine Learning" || !slices.Equal(got.Aliases, []string{"machine_learning"}) || got.UsageCount != 1 {
+		t.Errorf("tag = %+v, want label Machine Learning with the raw alias", got)
+	}
+
+	// The raw spelling now resolves through the alias
+	keys, err := svc.NormalizeTags(ctx, []string{"machine_learning"})
+	if err != nil {
+		t.Fatalf("normalize again: %v", err)
+	}
+	if !slices.Equal(keys, []string{"machine-learning"}) {
+		t.Errorf("got %v, want [machine-learning]", keys)
+	}
+}
+
+func TestGetTagNotFound(t *testing.T) {
+	svc := newService(t)
+	if _, err := svc.GetTag(context.Background(), "nope"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("got %v, want ErrNotFound", err)
+	}
+}
+
+func TestListTags(t *testing.T) {
+	ctx := context.Background()
+	var tags []tag.Tag
+	for i := range 120 {
+		tags = append(tags, tag.Tag{Key: fmt.Sprintf("t%03d", i), Label: fmt.Sprintf("Tag %d", i), UsageCount: i})
+	}
+	svc := newService(t, tags...)
+
+	tests := []struct {
+		name          string
+		limit, offset int
+		wantLen       int
+		wantFirst     string
+	}{
+		{"default limit", 0, 0, 50, "t119"},
+		{"negative limit", -5, 0, 50, "t119"},
+		{"capped limit", 500, 0, 100, "t119"},
+		{"offset", 10, 100, 10, "t019"},
+		{"past the end", 10, 200, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.ListTags(ctx, tt.limit, tt.offset)
+			if err != nil {
+				t.Fatalf("list: %v", err)
+			}
+			if len(got) != tt.wantLen {
+				t.Fatalf("len = %d, want %d", len(got), tt.wantLen)
+			}
+			if len(got) > 0 && got[0].Key != tt.wantFirst {
+				t.Errorf("first = %s, want %s", got[0].Key, tt.wantFirst)
+			}
+		})
+	}
+}
+
+func TestSearchTags(t *testing.T) {
+	ctx := context.Background()
+	var tags []tag.Tag
+	for i := range 30 {
+		tags = append(tags, tag.Tag{Key: fmt.Sprintf("dev-%d", i), Label: fmt.Sprintf("Dev %d", i), UsageCount: i})
+	}
+	tags = append(tags, tag.Tag{Key: "golang", Label: "Go", Aliases: []string{"golang"}, UsageCount: 100})
+	svc := newService(t, tags...)
+
+	got, err := svc.SearchTags(ctx, "DEV", 0)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(got) != 20 || got[0].Key != "dev-29" {
+		t.Errorf("got %d tags starting at %v, want 20 starting at dev-29", len(got), got[0].Key)
+	}
+
+	got, err = svc.SearchTags(ctx, "lang", 5)
+	if err != nil {
+		t.Fatalf("search alias: %v", err)
+	}
+	if len(got) != 1 || got[0].Key != "golang" {
+		t.Errorf("got %v, want golang matched by alias", got)
+	}
+}
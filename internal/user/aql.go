@@ -1,5 +1,7 @@
 package user
 
+import "github.com/askme/api/pkg/arango"
+
 // AQL queries for user operations
 const (
 	// GetUserByID retrieves a user by their key
@@ -56,3 +58,14 @@ const (
 		)
 	`
 )
+
+func init() {
+	arango.RegisterQueries("user", map[string]string{
+		"GetUserByID":          GetUserByID,
+		"DeleteFollowEdge":     DeleteFollowEdge,
+		"CheckIsFollowing":     CheckIsFollowing,
+		"CheckMutualFollowers": CheckMutualFollowers,
+		"GetFollowerCount":     GetFollowerCount,
+		"GetFollowingCount":    GetFollowingCount,
+	})
+}
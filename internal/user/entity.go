@@ -30,8 +30,8 @@ type FollowsEdge struct {
 
 // CreateUserRequest is the request payload for creating a user
 type CreateUserRequest struct {
-	Username  string       `json:"username"`
-	Interests []string     `json:"interests,omitempty"`
+	Username  string       `json:"username" validate:"required,max=32"`
+	Interests []string     `json:"interests,omitempty" validate:"max=20"`
 	Settings  UserSettings `json:"settings,omitempty"`
 }
 
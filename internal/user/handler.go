@@ -37,12 +37,7 @@ func (h *handler) GetUser(w http.ResponseWriter, r *http.Request) {
 func (h *handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	req, err := httputil.DecodeJSON[CreateUserRequest](r)
 	if err != nil {
-		httputil.Error(w, http.StatusBadRequest, "invalid request body")
-		return
-	}
-
-	if req.Username == "" {
-		httputil.Error(w, http.StatusBadRequest, "username is required")
+		httputil.InvalidRequest(w, err)
 		return
 	}
 
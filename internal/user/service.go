@@ -2,10 +2,13 @@ package user
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/askme/api/internal/domain"
+	"github.com/askme/api/pkg/trace"
 )
 
 type service struct {
@@ -18,6 +21,9 @@ func NewService(repo Repository) Service {
 }
 
 func (s *service) GetUser(ctx context.Context, id string) (*User, error) {
+	ctx, span := trace.Start(ctx, "user.Service.GetUser")
+	defer span.End()
+
 	user, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("get user: %w", err)
@@ -29,6 +35,9 @@ func (s *service) GetUser(ctx context.Context, id string) (*User, error) {
 }
 
 func (s *service) CreateUser(ctx context.Context, req *CreateUserRequest) (*CreateUserResponse, error) {
+	ctx, span := trace.Start(ctx, "user.Service.CreateUser")
+	defer span.End()
+
 	now := time.Now().UnixMilli()
 
 	user := &User{
@@ -47,6 +56,8 @@ func (s *service) CreateUser(ctx context.Context, req *CreateUserRequest) (*Crea
 		return nil, fmt.Errorf("create user: %w", err)
 	}
 
+	slog.InfoContext(ctx, "user created", "newUserId", key)
+
 	return &CreateUserResponse{
 		Key:       key,
 		CreatedAt: now,
@@ -54,13 +65,17 @@ func (s *service) CreateUser(ctx context.Context, req *CreateUserRequest) (*Crea
 }
 
 func (s *service) FollowUser(ctx context.Context, followerID, followeeID string) (*FollowUserResponse, error) {
-	// Check if already following
+	ctx, span := trace.Start(ctx, "user.Service.FollowUser")
+	defer span.End()
+
+	// Fast path for repeat follows; the unique index on follows rejects
+	// concurrent ones with domain.ErrAlreadyExists
 	isFollowing, err := s.repo.IsFollowing(ctx, followerID, followeeID)
 	if err != nil {
 		return nil, fmt.Errorf("check following: %w", err)
 	}
 	if isFollowing {
-		return nil, domain.ErrAlreadyExists
+		return nil, domain.ErrAlreadyFollowing
 	}
 
 	// Verify both users exist (can be parallelized with errgroup)
@@ -75,6 +90,9 @@ func (s *service) FollowUser(ctx context.Context, followerID, followeeID string)
 	}
 
 	followID, err := s.repo.CreateFollow(ctx, followerID, followeeID)
+	if errors.Is(err, domain.ErrAlreadyExists) {
+		return nil, domain.ErrAlreadyFollowing
+	}
 	if err != nil {
 		return nil, fmt.Errorf("create follow: %w", err)
 	}
@@ -86,9 +104,15 @@ func (s *service) FollowUser(ctx context.Context, followerID, followeeID string)
 }
 
 func (s *service) UnfollowUser(ctx context.Context, followerID, followeeID string) error {
+	ctx, span := trace.Start(ctx, "user.Service.UnfollowUser")
+	defer span.End()
+
 	return s.repo.DeleteFollow(ctx, followerID, followeeID)
 }
 
 func (s *service) AreMutualFollowers(ctx context.Context, userID1, userID2 string) (bool, error) {
+	ctx, span := trace.Start(ctx, "user.Service.AreMutualFollowers")
+	defer span.End()
+
 	return s.repo.AreMutualFollowers(ctx, userID1, userID2)
 }
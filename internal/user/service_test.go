@@ -0,0 +1,52 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/askme/api/internal/domain"
+	"github.com/askme/api/internal/memrepo"
+	"github.com/askme/api/internal/user"
+)
+
+func TestFollowUser(t *testing.T) {
+	ctx := context.Background()
+	svc := user.NewService(memrepo.NewUserRepository(memrepo.NewStore()))
+
+	alice, err := svc.CreateUser(ctx, &user.CreateUserRequest{Username: "alice"})
+	if err != nil {
+		t.Fatalf("create alice: %v", err)
+	}
+	bob, err := svc.CreateUser(ctx, &user.CreateUserRequest{Username: "bob"})
+	if err != nil {
+		t.Fatalf("create bob: %v", err)
+	}
+
+	if _, err := svc.FollowUser(ctx, alice.Key, bob.Key); err != nil {
+		t.Fatalf("follow: %v", err)
+	}
+	if _, err := svc.FollowUser(ctx, alice.Key, bob.Key); !errors.Is(err, domain.ErrAlreadyExists) {
+		t.Fatalf("follow twice: got %v, want ErrAlreadyExists", err)
+	}
+
+	mutual, err := svc.AreMutualFollowers(ctx, alice.Key, bob.Key)
+	if err != nil || mutual {
+		t.Fatalf("mutual after one follow = %v, %v, want false", mutual, err)
+	}
+	if _, err := svc.FollowUser(ctx, bob.Key, alice.Key); err != nil {
+		t.Fatalf("follow back: %v", err)
+	}
+	mutual, err = svc.AreMutualFollowers(ctx, alice.Key, bob.Key)
+	if err != nil || !mutual {
+		t.Fatalf("mutual after follow back = %v, %v, want true", mutual, err)
+	}
+
+	if err := svc.UnfollowUser(ctx, alice.Key, bob.Key); err != nil {
+		t.Fatalf("unfollow: %v", err)
+	}
+	mutual, err = svc.AreMutualFollowers(ctx, alice.Key, bob.Key)
+	if err != nil || mutual {
+		t.Fatalf("mutual after unfollow = %v, %v, want false", mutual, err)
+	}
+}
@@ -0,0 +1,95 @@
+// Package arangotest provides a seeded ArangoDB database for benchmarks and
+// tests that need a real server.
+//
+// The database is named after ARANGO_DATABASE with a "_bench" suffix, is
+// migrated to the latest schema and seeded once; later runs reuse it.
+// Benchmarks and tests are skipped when no ArangoDB is configured or reachable.
+package arangotest
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/askme/api/internal/config"
+	"github.com/askme/api/pkg/arango"
+	"github.com/askme/api/pkg/arango/migrate"
+)
+
+// Dataset sizes. Every user authors Posts/Users posts; every other post has
+// a direct chat with MessagesPerChat messages.
+const (
+	Users           = 500
+	Posts           = 5000
+	Tags            = 20
+	MessagesPerChat = 5
+)
+
+// Well-known keys in the seeded dataset
+const (
+	UserKey = "u-1"
+	PostKey = "p-2"
+	ChatKey = "c-2"
+)
+
+var (
+	once    sync.Once
+	client  *arango.Client
+	openErr error
+)
+
+// DB returns a client for the seeded benchmark database
+func DB(tb testing.TB) *arango.Client {
+	tb.Helper()
+
+	once.Do(func() {
+		client, openErr = open(context.Background())
+	})
+	if openErr != nil {
+		tb.Skipf("benchmark database unavailable: %v", openErr)
+	}
+	return client
+}
+
+func open(ctx context.Context) (*arango.Client, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	cfg.ArangoDB.Database += "_bench"
+
+	if err := arango.EnsureDatabase(ctx, cfg.ArangoDB); err != nil {
+		return nil, err
+	}
+
+	db, err := arango.NewClient(cfg.ArangoDB)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := migrate.NewRunner(db, migrate.Migrations).Up(ctx, false); err != nil {
+		return nil, err
+	}
+
+	if err := seed(ctx, db); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func seed(ctx context.Context, db *arango.Client) error {
+	count, err := arango.QueryOne[int](ctx, db, countUsers, nil)
+	if err != nil {
+		return err
+	}
+	if count != nil && *count > 0 {
+		return nil
+	}
+
+	for _, sq := range seedQueries {
+		if _, err := arango.Query[int](ctx, db, sq.query, sq.bindVars); err != nil {
+			return err
+		}
+	}
+	return nil
+}
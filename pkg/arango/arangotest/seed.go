@@ -0,0 +1,96 @@
+package arangotest
+
+const countUsers = `RETURN LENGTH(users)`
+
+// seedQuery is one bulk insert with its own bind variables, since ArangoDB
+// rejects bind variables a query does not use
+type seedQuery struct {
+	query    string
+	bindVars map[string]any
+}
+
+// Post i is authored by user 1 + i % Users. Even posts have a chat c-i
+// with a responder 1 + (i/2 + 1) % Users, so UserKey both authors and
+// responds to posts.
+var seedQueries = []seedQuery{
+	{
+		query: `
+			FOR i IN 1..@users
+			INSERT {
+				_key: CONCAT("u-", i),
+				username: CONCAT("user", i),
+				avatarUrl: "",
+				createdAt: DATE_NOW()
+			} INTO users
+			RETURN 1
+		`,
+		bindVars: map[string]any{"users": Users},
+	},
+	{
+		query: `
+			FOR i IN 1..@tags
+			INSERT {
+				_key: CONCAT("t-", i),
+				label: CONCAT("Tag ", i),
+				aliases: [],
+				usageCount: 0,
+				createdAt: DATE_NOW()
+			} INTO tags
+			RETURN 1
+		`,
+		bindVars: map[string]any{"tags": Tags},
+	},
+	{
+		query: `
+			LET categories = ["career", "tech", "health", "relationships", "lifestyle"]
+			FOR i IN 1..@posts
+			LET author = CONCAT("users/u-", 1 + i % @users)
+			INSERT {
+				_key: CONCAT("p-", i),
+				authorId: author,
+				postType: "text",
+				text: CONCAT("Benchmark question ", i),
+				category: categories[i % LENGTH(categories)],
+				intent: "seeking-advice",
+				depth: i % 2 == 0 ? "casual" : "serious",
+				createdAt: DATE_NOW() - i * 60000
+			} INTO posts
+			INSERT { _from: author, _to: CONCAT("posts/p-", i), createdAt: DATE_NOW() } INTO created
+			INSERT { _from: CONCAT("posts/p-", i), _to: CONCAT("tags/t-", 1 + i % @tags) } INTO post_has_tag
+			RETURN 1
+		`,
+		bindVars: map[string]any{"users": Users, "posts": Posts, "tags": Tags},
+	},
+	{
+		query: `
+			FOR i IN 2..@posts STEP 2
+			LET chatId = CONCAT("chats/c-", i)
+			LET author = CONCAT("users/u-", 1 + i % @users)
+			LET responder = CONCAT("users/u-", 1 + (i / 2 + 1) % @users)
+			INSERT { _key: CONCAT("c-", i), postId: CONCAT("posts/p-", i), type: "direct", createdAt: DATE_NOW() } INTO chats
+			INSERT { _from: author, _to: chatId, role: "author", status: "active", joinedAt: DATE_NOW() } INTO participates_in
+			INSERT { _from: responder, _to: chatId, role: "responder", status: "active", joinedAt: DATE_NOW() } INTO participates_in
+			INSERT { _from: responder, _to: CONCAT("posts/p-", i), chatId, createdAt: DATE_NOW() } INTO responded
+			RETURN 1
+		`,
+		bindVars: map[string]any{"users": Users, "posts": Posts},
+	},
+	{
+		query: `
+			FOR i IN 2..@posts STEP 2
+			FOR j IN 1..@messages
+			INSERT {
+				_key: CONCAT("m-", i, "-", j),
+				chatId: CONCAT("chats/c-", i),
+				senderId: j % 2 == 1
+					? CONCAT("users/u-", 1 + (i / 2 + 1) % @users)
+					: CONCAT("users/u-", 1 + i % @users),
+				text: CONCAT("Message ", j),
+				status: j == @messages ? "sent" : "seen",
+				createdAt: DATE_NOW() - (@messages - j) * 1000
+			} INTO messages
+			RETURN 1
+		`,
+		bindVars: map[string]any{"users": Users, "posts": Posts, "messages": MessagesPerChat},
+	},
+}
@@ -2,31 +2,27 @@ package arango
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/arangodb/go-driver/v2/arangodb"
 	"github.com/arangodb/go-driver/v2/arangodb/shared"
 	"github.com/arangodb/go-driver/v2/connection"
 
 	"github.com/askme/api/internal/config"
+	"github.com/askme/api/internal/domain"
 )
 
 // Client wraps the ArangoDB connection
 type Client struct {
-	db arangodb.Database
+	db        arangodb.Database
+	conn      connection.Connection
+	slowQuery time.Duration
 }
 
 // NewClient creates a new ArangoDB client
 func NewClient(cfg config.ArangoDBConfig) (*Client, error) {
-	endpoint := connection.NewRoundRobinEndpoints([]string{cfg.Endpoint})
-	auth := connection.NewBasicAuth(cfg.Username, cfg.Password)
-
-	conn := connection.NewHttpConnection(connection.HttpConfiguration{
-		Endpoint:       endpoint,
-		Authentication: auth,
-	})
-
+	conn := newConnection(cfg)
 	client := arangodb.NewClient(conn)
 
 	db, err := client.Database(context.Background(), cfg.Database)
@@ -34,7 +30,45 @@ func NewClient(cfg config.ArangoDBConfig) (*Client, error) {
 		return nil, fmt.Errorf("get database: %w", err)
 	}
 
-	return &Client{db: db}, nil
+	return &Client{db: db, conn: conn, slowQuery: cfg.SlowQueryThreshold}, nil
+}
+
+// DatabaseExists reports whether the configured database has been created
+func DatabaseExists(ctx context.Context, cfg config.ArangoDBConfig) (bool, error) {
+	client := arangodb.NewClient(newConnection(cfg))
+
+	exists, err := client.DatabaseExists(ctx, cfg.Database)
+	if err != nil {
+		return false, fmt.Errorf("check database: %w", err)
+	}
+	return exists, nil
+}
+
+// EnsureDatabase creates the configured database if it does not exist yet
+func EnsureDatabase(ctx context.Context, cfg config.ArangoDBConfig) error {
+	exists, err := DatabaseExists(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	client := arangodb.NewClient(newConnection(cfg))
+	if _, err := client.CreateDatabase(ctx, cfg.Database, nil); err != nil {
+		return fmt.Errorf("create database: %w", err)
+	}
+	return nil
+}
+
+func newConnection(cfg config.ArangoDBConfig) connection.Connection {
+	endpoint := connection.NewRoundRobinEndpoints([]string{cfg.Endpoint})
+	auth := connection.NewBasicAuth(cfg.Username, cfg.Password)
+
+	return connection.NewHttpConnection(connection.HttpConfiguration{
+		Endpoint:       endpoint,
+		Authentication: auth,
+	})
 }
 
 // Database returns the underlying database
@@ -42,6 +76,21 @@ func (c *Client) Database() arangodb.Database {
 	return c.db
 }
 
+// Ping checks that the database is reachable with the configured
+// credentials, e.g. for readiness probes
+func (c *Client) Ping(ctx context.Context) error {
+	if _, err := c.db.Info(ctx); err != nil {
+		return fmt.Errorf("ping database: %w", err)
+	}
+	return nil
+}
+
+// Connection returns the underlying connection, for endpoints the driver
+// does not wrap
+func (c *Client) Connection() connection.Connection {
+	return c.conn
+}
+
 // Collection represents an ArangoDB collection
 type Collection string
 
@@ -51,6 +100,8 @@ const (
 	CollectionTags     Collection = "tags"
 	CollectionChats    Collection = "chats"
 	CollectionMessages Collection = "messages"
+
+	CollectionPostRevisions Collection = "post_revisions"
 )
 
 // Edge collection names
@@ -65,31 +116,41 @@ const (
 	EdgeReacted        Collection = "reacted"
 )
 
-// Query executes an AQL query and returns results
-func Query[T any](ctx context.Context, client *Client, query string, bindVars map[string]any) ([]T, error) {
-	cursor, err := client.db.Query(ctx, query, &arangodb.QueryOptions{
+// GraphName is the named graph spanning every edge collection. It exists
+// for tooling: the web UI's graph viewer and ad-hoc GRAPH "askme" queries.
+// Application queries traverse the one edge collection they need instead,
+// since a graph traversal would follow every edge collection of a vertex.
+const GraphName = "askme"
+
+// Query executes an AQL query and returns all results. Every call is timed
+// and traced under the query's registered name; see RegisterQueries. Use
+// Stream for results too large to buffer.
+func Query[T any](ctx context.Context, client *Client, query string, bindVars map[string]any) (results []T, err error) {
+	ctx, span := startSpan(ctx, "arango.Query", query)
+	start := time.Now()
+	var stats arangodb.CursorStats
+	defer func() {
+		client.observe(ctx, query, bindVars, time.Since(start), newQueryStats(stats, len(results)), err)
+		endSpan(span, len(results), err)
+	}()
+
+	cursor, err := client.executor(ctx).Query(ctx, query, &arangodb.QueryOptions{
 		BindVars: bindVars,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("query failed: %w", err)
+		return nil, fmt.Errorf("query failed: %w", translateError(err))
 	}
 	defer cursor.Close()
 
-	var results []T
 	for cursor.HasMore() {
-		var raw json.RawMessage
-		_, err := cursor.ReadDocument(ctx, &raw)
-		if err != nil {
-			return nil, fmt.Errorf("read document failed: %w", err)
-		}
-
 		var doc T
-		if err := json.Unmarshal(raw, &doc); err != nil {
-			return nil, fmt.Errorf("unmarshal document failed: %w", err)
+		if _, err := cursor.ReadDocument(ctx, &doc); err != nil {
+			return nil, fmt.Errorf("read document failed: %w", err)
 		}
 		results = append(results, doc)
 	}
 
+	stats = cursor.Statistics()
 	return results, nil
 }
 
@@ -107,14 +168,14 @@ func QueryOne[T any](ctx context.Context, client *Client, query string, bindVars
 
 // InsertDocument inserts a document into a collection
 func InsertDocument[T any](ctx context.Context, client *Client, collection Collection, doc T) (string, error) {
-	col, err := client.db.Collection(ctx, string(collection))
+	col, err := client.executor(ctx).Collection(ctx, string(collection))
 	if err != nil {
 		return "", fmt.Errorf("get collection: %w", err)
 	}
 
 	meta, err := col.CreateDocument(ctx, doc)
 	if err != nil {
-		return "", fmt.Errorf("create document: %w", err)
+		return "", fmt.Errorf("create document: %w", translateError(err))
 	}
 
 	return meta.Key, nil
@@ -122,14 +183,14 @@ func InsertDocument[T any](ctx context.Context, client *Client, collection Colle
 
 // UpdateDocument updates a document in a collection
 func UpdateDocument[T any](ctx context.Context, client *Client, collection Collection, key string, doc T) error {
-	col, err := client.db.Collection(ctx, string(collection))
+	col, err := client.executor(ctx).Collection(ctx, string(collection))
 	if err != nil {
 		return fmt.Errorf("get collection: %w", err)
 	}
 
 	_, err = col.UpdateDocument(ctx, key, doc)
 	if err != nil {
-		return fmt.Errorf("update document: %w", err)
+		return fmt.Errorf("update document: %w", translateError(err))
 	}
 
 	return nil
@@ -137,7 +198,7 @@ func UpdateDocument[T any](ctx context.Context, client *Client, collection Colle
 
 // GetDocument retrieves a document by key
 func GetDocument[T any](ctx context.Context, client *Client, collection Collection, key string) (*T, error) {
-	col, err := client.db.Collection(ctx, string(collection))
+	col, err := client.executor(ctx).Collection(ctx, string(collection))
 	if err != nil {
 		return nil, fmt.Errorf("get collection: %w", err)
 	}
@@ -156,15 +217,30 @@ func GetDocument[T any](ctx context.Context, client *Client, collection Collecti
 
 // DeleteDocument removes a document by key
 func DeleteDocument(ctx context.Context, client *Client, collection Collection, key string) error {
-	col, err := client.db.Collection(ctx, string(collection))
+	col, err := client.executor(ctx).Collection(ctx, string(collection))
 	if err != nil {
 		return fmt.Errorf("get collection: %w", err)
 	}
 
 	_, err = col.DeleteDocument(ctx, key)
 	if err != nil {
-		return fmt.Errorf("delete document: %w", err)
+		return fmt.Errorf("delete document: %w", translateError(err))
 	}
 
 	return nil
 }
+
+// translateError maps driver errors onto domain errors where one applies.
+// Unique index violations become domain.ErrAlreadyExists so services can
+// rely on indexes instead of racy check-then-insert sequences. Write-write
+// conflicts between concurrent transactions become domain.ErrConflict,
+// which services may retry.
+func translateError(err error) error {
+	switch {
+	case shared.IsArangoErrorWithErrorNum(err, shared.ErrArangoUniqueConstraintViolated):
+		return fmt.Errorf("%w: %v", domain.ErrAlreadyExists, err)
+	case shared.IsArangoErrorWithErrorNum(err, shared.ErrArangoConflict):
+		return fmt.Errorf("%w: %v", domain.ErrConflict, err)
+	}
+	return err
+}
@@ -0,0 +1,31 @@
+package arango
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/arangodb/go-driver/v2/arangodb/shared"
+
+	"github.com/askme/api/internal/domain"
+)
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		errorNum int
+		want     error
+	}{
+		{shared.ErrArangoUniqueConstraintViolated, domain.ErrAlreadyExists},
+		{shared.ErrArangoConflict, domain.ErrConflict},
+	}
+	for _, tt := range tests {
+		err := translateError(shared.ArangoError{HasError: true, ErrorNum: tt.errorNum})
+		if !errors.Is(err, tt.want) {
+			t.Errorf("translateError(%d) = %v, want %v", tt.errorNum, err, tt.want)
+		}
+	}
+
+	other := shared.ArangoError{HasError: true, ErrorNum: shared.ErrArangoDocumentNotFound}
+	if err := translateError(other); err != error(other) {
+		t.Errorf("translateError(%d) = %v, want it unchanged", other.ErrorNum, err)
+	}
+}
@@ -0,0 +1,183 @@
+// Package migrate applies versioned schema migrations to ArangoDB.
+//
+// Migrations are ordered by version and every step is idempotent, so a
+// migration interrupted halfway can simply be run again. Applied versions
+// are recorded in the _migrations collection.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/arangodb/go-driver/v2/arangodb"
+
+	"github.com/askme/api/pkg/arango"
+)
+
+// CollectionMigrations records applied migration versions
+const CollectionMigrations arango.Collection = "_migrations"
+
+const getAppliedMigrations = `
+	FOR m IN _migrations
+	SORT m.version ASC
+	RETURN m
+`
+
+// Migration is a versioned, ordered set of steps
+type Migration struct {
+	Version int
+	Name    string
+	Steps   []Step
+}
+
+// Step is a single idempotent schema change
+type Step interface {
+	// Describe returns a one-line summary for status and dry-run output
+	Describe() string
+	// Apply performs the change; it must be a no-op when already applied
+	Apply(ctx context.Context, db *arango.Client) error
+}
+
+// Record is the document stored in _migrations for an applied version
+type Record struct {
+	Key       string `json:"_key"`
+	Version   int    `json:"version"`
+	Name      string `json:"name"`
+	AppliedAt int64  `json:"appliedAt"`
+}
+
+// Status describes one known migration and whether it has been applied
+type Status struct {
+	Version   int    `json:"version"`
+	Name      string `json:"name"`
+	Applied   bool   `json:"applied"`
+	AppliedAt int64  `json:"appliedAt,omitempty"`
+}
+
+// Runner applies migrations against a database
+type Runner struct {
+	db         *arango.Client
+	migrations []Migration
+}
+
+// NewRunner creates a runner for the given migrations, sorted by version.
+// A nil db stands for a database that does not exist yet: every migration
+// is pending and only dry runs can be made.
+func NewRunner(db *arango.Client, migrations []Migration) *Runner {
+	sorted := slices.Clone(migrations)
+	slices.SortFunc(sorted, func(a, b Migration) int { return a.Version - b.Version })
+	return &Runner{db: db, migrations: sorted}
+}
+
+// Status reports every known migration with its applied state
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(r.migrations))
+	for _, m := range r.migrations {
+		status := Status{Version: m.Version, Name: m.Name}
+		if rec, ok := applied[m.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = rec.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations that have not been applied yet
+func (r *Runner) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, m := range r.migrations {
+		if _, ok := applied[m.Version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration in order and returns those applied.
+// With dryRun set nothing is written; the pending migrations are returned.
+func (r *Runner) Up(ctx context.Context, dryRun bool) ([]Migration, error) {
+	pending, err := r.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun || len(pending) == 0 {
+		return pending, nil
+	}
+	if r.db == nil {
+		return nil, fmt.Errorf("database does not exist")
+	}
+
+	if err := (CreateCollection{Name: CollectionMigrations, System: true}).Apply(ctx, r.db); err != nil {
+		return nil, fmt.Errorf("create migrations collection: %w", err)
+	}
+
+	for i, m := range pending {
+		slog.Info("applying migration", "version", m.Version, "name", m.Name)
+		for _, step := range m.Steps {
+			if err := step.Apply(ctx, r.db); err != nil {
+				return pending[:i], fmt.Errorf("migration %d (%s): %s: %w", m.Version, m.Name, step.Describe(), err)
+			}
+		}
+
+		rec := Record{
+			Key:       fmt.Sprintf("%04d", m.Version),
+			Version:   m.Version,
+			Name:      m.Name,
+			AppliedAt: time.Now().UnixMilli(),
+		}
+		if _, err := arango.InsertDocument(ctx, r.db, CollectionMigrations, rec); err != nil {
+			return pending[:i], fmt.Errorf("record migration %d: %w", m.Version, err)
+		}
+	}
+	return pending, nil
+}
+
+// applied returns recorded migrations by version. A missing database or
+// _migrations collection means nothing has been applied yet.
+func (r *Runner) applied(ctx context.Context) (map[int]Record, error) {
+	if r.db == nil {
+		return map[int]Record{}, nil
+	}
+
+	exists, err := r.db.Database().CollectionExists(ctx, string(CollectionMigrations))
+	if err != nil {
+		return nil, fmt.Errorf("check migrations collection: %w", err)
+	}
+	if !exists {
+		return map[int]Record{}, nil
+	}
+
+	records, err := arango.Query[Record](ctx, r.db, getAppliedMigrations, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get applied migrations: %w", err)
+	}
+
+	applied := make(map[int]Record, len(records))
+	for _, rec := range records {
+		applied[rec.Version] = rec
+	}
+	return applied, nil
+}
+
+// collection returns a collection handle, for steps that change indexes
+func collection(ctx context.Context, db *arango.Client, name arango.Collection) (arangodb.Collection, error) {
+	col, err := db.Database().Collection(ctx, string(name))
+	if err != nil {
+		return nil, fmt.Errorf("get collection %s: %w", name, err)
+	}
+	return col, nil
+}
@@ -0,0 +1,27 @@
+package migrate
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRunnerWithoutDatabase(t *testing.T) {
+	ctx := context.Background()
+	runner := NewRunner(nil, []Migration{{Version: 2, Name: "graph"}, {Version: 1, Name: "initial"}})
+
+	statuses, err := runner.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || statuses[0].Version != 1 || statuses[0].Applied || statuses[1].Applied {
+		t.Errorf("statuses = %+v, want both pending in order", statuses)
+	}
+
+	pending, err := runner.Up(ctx, true)
+	if err != nil || len(pending) != 2 {
+		t.Errorf("dry run = %v, %v; want both pending", pending, err)
+	}
+	if _, err := runner.Up(ctx, false); err == nil {
+		t.Error("applying without a database succeeded")
+	}
+}
@@ -0,0 +1,174 @@
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/arangodb/go-driver/v2/arangodb"
+
+	"github.com/askme/api/pkg/arango"
+)
+
+// Migrations is the ask.me schema history. Append new versions; never edit
+// or reorder applied ones.
+var Migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create collections",
+		Steps: []Step{
+			CreateCollection{Name: arango.CollectionUsers},
+			CreateCollection{Name: arango.CollectionPosts},
+			CreateCollection{Name: arango.CollectionTags},
+			CreateCollection{Name: arango.CollectionChats},
+			CreateCollection{Name: arango.CollectionMessages},
+			CreateCollection{Name: arango.CollectionPostRevisions},
+			CreateCollection{Name: arango.EdgeCreated, Edge: true},
+			CreateCollection{Name: arango.EdgeResponded, Edge: true},
+			CreateCollection{Name: arango.EdgePostHasTag, Edge: true},
+			CreateCollection{Name: arango.EdgeFollows, Edge: true},
+			CreateCollection{Name: arango.EdgeParticipatesIn, Edge: true},
+			CreateCollection{Name: arango.EdgeTagged, Edge: true},
+			CreateCollection{Name: arango.EdgeVoted, Edge: true},
+			CreateCollection{Name: arango.EdgeReacted, Edge: true},
+		},
+	},
+	{
+		Version: 2,
+		Name:    "unique edge indexes",
+		Steps: []Step{
+			// Racing writes left duplicate edges behind; the index cannot be
+			// built until they are gone
+			removeDuplicateEdges(arango.EdgeVoted),
+			PersistentIndex{Collection: arango.EdgeVoted, Name: "idx_voted_from_to", Fields: []string{"_from", "_to"}, Unique: true},
+			removeDuplicateEdges(arango.EdgeResponded),
+			PersistentIndex{Collection: arango.EdgeResponded, Name: "idx_responded_from_to", Fields: []string{"_from", "_to"}, Unique: true},
+			removeDuplicateEdges(arango.EdgeFollows),
+			PersistentIndex{Collection: arango.EdgeFollows, Name: "idx_follows_from_to", Fields: []string{"_from", "_to"}, Unique: true},
+			removeDuplicateEdges(arango.EdgeReacted),
+			PersistentIndex{Collection: arango.EdgeReacted, Name: "idx_reacted_from_to", Fields: []string{"_from", "_to"}, Unique: true},
+		},
+	},
+	{
+		Version: 3,
+		Name:    "query indexes",
+		Steps: []Step{
+			PersistentIndex{Collection: arango.CollectionPosts, Name: "idx_posts_created_at", Fields: []string{"createdAt"}},
+			PersistentIndex{Collection: arango.CollectionMessages, Name: "idx_messages_chat_created_at", Fields: []string{"chatId", "createdAt"}},
+			PersistentIndex{Collection: arango.CollectionMessages, Name: "idx_messages_reply_to", Fields: []string{"replyToId"}, Sparse: true},
+			PersistentIndex{Collection: arango.CollectionChats, Name: "idx_chats_post", Fields: []string{"postId"}},
+			FulltextIndex{Collection: arango.CollectionPosts, Name: "idx_posts_text", Field: "text", MinLength: 3},
+		},
+	},
+	{
+		Version: 4,
+		Name:    "poll option ids",
+		Steps: []Step{
+			// Posts first, so edges can resolve option text to IDs
+			AQL{Description: "assign ids to legacy poll options", Query: migratePollOptionIDs},
+			AQL{Description: "point voted edges at option ids", Query: migrateVotedOptionIDs},
+		},
+	},
+	{
+		Version: 5,
+		Name:    "askme graph",
+		Steps: []Step{
+			// For tooling only; application queries traverse edge
+			// collections directly (see arango.GraphName)
+			Graph{
+				Name: arango.GraphName,
+				EdgeDefinitions: []arangodb.EdgeDefinition{
+					edge(arango.EdgeCreated, arango.CollectionUsers, arango.CollectionPosts),
+					edge(arango.EdgeResponded, arango.CollectionUsers, arango.CollectionPosts),
+					edge(arango.EdgeVoted, arango.CollectionUsers, arango.CollectionPosts),
+					edge(arango.EdgePostHasTag, arango.CollectionPosts, arango.CollectionTags),
+					edge(arango.EdgeFollows, arango.CollectionUsers, arango.CollectionUsers),
+					edge(arango.EdgeParticipatesIn, arango.CollectionUsers, arango.CollectionChats),
+					edge(arango.EdgeTagged, arango.CollectionPosts, arango.CollectionUsers),
+					edge(arango.EdgeReacted, arango.CollectionUsers, arango.CollectionMessages),
+				},
+			},
+		},
+	},
+}
+
+// edge builds a graph edge definition from one vertex collection to another
+func edge(collection, from, to arango.Collection) arangodb.EdgeDefinition {
+	return arangodb.EdgeDefinition{
+		Collection: string(collection),
+		From:       []string{string(from)},
+		To:         []string{string(to)},
+	}
+}
+
+// removeDuplicateEdges keeps only the oldest edge per (_from, _to) in an
+// edge collection
+func removeDuplicateEdges(collection arango.Collection) AQL {
+	return AQL{
+		Description: fmt.Sprintf("remove duplicate %s edges", collection),
+		Query:       dedupeEdges,
+		BindVars:    map[string]any{"@collection": string(collection)},
+	}
+}
+
+const (
+	// dedupeEdges removes all but the oldest edge of each (_from, _to) pair.
+	// Edges without createdAt sort first; _key breaks ties.
+	dedupeEdges = `
+		FOR edge IN @@collection
+		COLLECT from = edge._from, to = edge._to INTO edges = edge
+		FILTER LENGTH(edges) > 1
+		LET keep = FIRST(
+			FOR e IN edges
+			SORT e.createdAt, e._key
+			RETURN e._key
+		)
+		FOR e IN edges
+		FILTER e._key != keep
+		REMOVE e IN @@collection
+		RETURN 1
+	`
+
+	// migratePollOptionIDs converts bare-string poll options into
+	// { id, text } objects. IDs are positional and never reassigned.
+	migratePollOptionIDs = `
+		FOR p IN posts
+		FILTER p.postType == "poll"
+		FILTER LENGTH(p.pollOptions) > 0 AND IS_STRING(p.pollOptions[0])
+		UPDATE p WITH {
+			pollOptions: (
+				FOR i IN 0..LENGTH(p.pollOptions) - 1
+				RETURN { id: CONCAT("o", i + 1), text: p.pollOptions[i] }
+			)
+		} IN posts
+		RETURN 1
+	`
+
+	// migrateVotedOptionIDs rewrites voted edges that reference option text
+	// (option or options) to reference option IDs instead
+	migrateVotedOptionIDs = `
+		FOR edge IN voted
+		FILTER edge.optionIds == null
+		LET post = DOCUMENT(edge._to)
+		LET texts = edge.options || [edge.option]
+		LET optionIds = UNIQUE(
+			FOR text IN texts
+			LET id = FIRST(
+				FOR o IN post.pollOptions
+				FILTER o.text == text
+				RETURN o.id
+			)
+			FILTER id != null
+			RETURN id
+		)
+		UPDATE edge WITH { optionIds, options: null, option: null } IN voted OPTIONS { keepNull: false }
+		RETURN 1
+	`
+)
+
+func init() {
+	arango.RegisterQueries("migrate", map[string]string{
+		"dedupeEdges":           dedupeEdges,
+		"getAppliedMigrations":  getAppliedMigrations,
+		"migratePollOptionIDs":  migratePollOptionIDs,
+		"migrateVotedOptionIDs": migrateVotedOptionIDs,
+	})
+}
@@ -0,0 +1,207 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/arangodb/go-driver/v2/arangodb"
+	"github.com/arangodb/go-driver/v2/arangodb/shared"
+	"github.com/arangodb/go-driver/v2/connection"
+
+	"github.com/askme/api/pkg/arango"
+)
+
+// CreateCollection creates a document or edge collection if it is missing
+type CreateCollection struct {
+	Name   arango.Collection
+	Edge   bool
+	System bool
+}
+
+func (s CreateCollection) Describe() string {
+	if s.Edge {
+		return fmt.Sprintf("create edge collection %s", s.Name)
+	}
+	return fmt.Sprintf("create collection %s", s.Name)
+}
+
+func (s CreateCollection) Apply(ctx context.Context, db *arango.Client) error {
+	exists, err := db.Database().CollectionExists(ctx, string(s.Name))
+	if err != nil {
+		return fmt.Errorf("check collection: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	props := &arangodb.CreateCollectionProperties{IsSystem: s.System}
+	if s.Edge {
+		props.Type = arangodb.CollectionTypeEdge
+	}
+	if _, err := db.Database().CreateCollection(ctx, string(s.Name), props); err != nil {
+		return fmt.Errorf("create collection: %w", err)
+	}
+	return nil
+}
+
+// PersistentIndex ensures a persistent index exists
+type PersistentIndex struct {
+	Collection arango.Collection
+	Name       string
+	Fields     []string
+	Unique     bool
+	Sparse     bool
+}
+
+func (s PersistentIndex) Describe() string {
+	kind := "persistent"
+	if s.Unique {
+		kind = "unique persistent"
+	}
+	return fmt.Sprintf("ensure %s index %s on %s [%s]", kind, s.Name, s.Collection, strings.Join(s.Fields, ", "))
+}
+
+func (s PersistentIndex) Apply(ctx context.Context, db *arango.Client) error {
+	col, err := collection(ctx, db, s.Collection)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = col.EnsurePersistentIndex(ctx, s.Fields, &arangodb.CreatePersistentIndexOptions{
+		Name:   s.Name,
+		Unique: &s.Unique,
+		Sparse: &s.Sparse,
+	})
+	if err != nil {
+		return fmt.Errorf("ensure index: %w", err)
+	}
+	return nil
+}
+
+// TTLIndex ensures a TTL index that expires documents ExpireAfter past the
+// timestamp in Field (unix seconds or ISO 8601 date)
+type TTLIndex struct {
+	Collection  arango.Collection
+	Name        string
+	Field       string
+	ExpireAfter time.Duration
+}
+
+func (s TTLIndex) Describe() string {
+	return fmt.Sprintf("ensure ttl index %s on %s [%s] after %s", s.Name, s.Collection, s.Field, s.ExpireAfter)
+}
+
+func (s TTLIndex) Apply(ctx context.Context, db *arango.Client) error {
+	col, err := collection(ctx, db, s.Collection)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = col.EnsureTTLIndex(ctx, []string{s.Field}, int(s.ExpireAfter.Seconds()), &arangodb.CreateTTLIndexOptions{
+		Name: s.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("ensure ttl index: %w", err)
+	}
+	return nil
+}
+
+// FulltextIndex ensures a fulltext index exists. The driver has no helper
+// for this index type, so it is created through the HTTP API.
+type FulltextIndex struct {
+	Collection arango.Collection
+	Name       string
+	Field      string
+	MinLength  int
+}
+
+func (s FulltextIndex) Describe() string {
+	return fmt.Sprintf("ensure fulltext index %s on %s [%s]", s.Name, s.Collection, s.Field)
+}
+
+func (s FulltextIndex) Apply(ctx context.Context, db *arango.Client) error {
+	col, err := collection(ctx, db, s.Collection)
+	if err != nil {
+		return err
+	}
+
+	exists, err := col.IndexExists(ctx, s.Name)
+	if err != nil {
+		return fmt.Errorf("check index: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	body := map[string]any{
+		"type":      arangodb.FullTextIndex,
+		"name":      s.Name,
+		"fields":    []string{s.Field},
+		"minLength": s.MinLength,
+	}
+	var response shared.ResponseStruct
+	url := connection.NewUrl("_db", db.Database().Name(), "_api", "index")
+	resp, err := connection.CallPost(ctx, db.Connection(), url, &response, body,
+		connection.WithQuery("collection", string(s.Collection)))
+	if err != nil {
+		return fmt.Errorf("create fulltext index: %w", err)
+	}
+
+	switch code := resp.Code(); code {
+	case http.StatusOK, http.StatusCreated:
+		return nil
+	default:
+		return fmt.Errorf("create fulltext index: %w", response.AsArangoErrorWithCode(code))
+	}
+}
+
+// Graph creates a named graph if it is missing. An existing graph is left
+// as is; changing edge definitions needs a new migration.
+type Graph struct {
+	Name            string
+	EdgeDefinitions []arangodb.EdgeDefinition
+}
+
+func (s Graph) Describe() string {
+	return fmt.Sprintf("create graph %s (%d edge definitions)", s.Name, len(s.EdgeDefinitions))
+}
+
+func (s Graph) Apply(ctx context.Context, db *arango.Client) error {
+	exists, err := db.Database().GraphExists(ctx, s.Name)
+	if err != nil {
+		return fmt.Errorf("check graph: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	_, err = db.Database().CreateGraph(ctx, s.Name, &arangodb.GraphDefinition{
+		EdgeDefinitions: s.EdgeDefinitions,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("create graph: %w", err)
+	}
+	return nil
+}
+
+// AQL runs a data migration query. The query must only touch documents
+// that still need migrating, so running it twice changes nothing.
+type AQL struct {
+	Description string
+	Query       string
+	BindVars    map[string]any
+}
+
+func (s AQL) Describe() string {
+	return s.Description
+}
+
+func (s AQL) Apply(ctx context.Context, db *arango.Client) error {
+	if _, err := arango.Query[int](ctx, db, s.Query, s.BindVars); err != nil {
+		return fmt.Errorf("run query: %w", err)
+	}
+	return nil
+}
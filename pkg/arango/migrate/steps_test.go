@@ -0,0 +1,119 @@
+package migrate_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/arangodb/go-driver/v2/arangodb"
+
+	"github.com/askme/api/pkg/arango"
+	"github.com/askme/api/pkg/arango/arangotest"
+	"github.com/askme/api/pkg/arango/migrate"
+)
+
+func TestUniqueIndexesRemoveDuplicatesFirst(t *testing.T) {
+	for _, m := range migrate.Migrations {
+		for i, step := range m.Steps {
+			index, ok := step.(migrate.PersistentIndex)
+			if !ok || !index.Unique {
+				continue
+			}
+			var dedupe migrate.AQL
+			if i > 0 {
+				dedupe, _ = m.Steps[i-1].(migrate.AQL)
+			}
+			if dedupe.BindVars["@collection"] != string(index.Collection) {
+				t.Errorf("v%d: %s is not preceded by a dedupe of %s", m.Version, index.Describe(), index.Collection)
+			}
+		}
+	}
+}
+
+func TestRemoveDuplicateEdges(t *testing.T) {
+	db := arangotest.DB(t)
+	ctx := context.Background()
+
+	// A scratch collection, since the migrated ones already have the index
+	const name = "dedupe_test_edges"
+	scratchCollection(t, db, migrate.CreateCollection{Name: name, Edge: true})
+
+	_, err := arango.Query[int](ctx, db, `
+		FOR e IN [
+			{ _key: "newer", _from: "users/u1", _to: "posts/p1", createdAt: 2 },
+			{ _key: "older", _from: "users/u1", _to: "posts/p1", createdAt: 1 },
+			{ _key: "newest", _from: "users/u1", _to: "posts/p1", createdAt: 3 },
+			{ _key: "other", _from: "users/u2", _to: "posts/p1", createdAt: 4 }
+		]
+		INSERT e INTO @@collection
+		RETURN 1
+	`, map[string]any{"@collection": name})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dedupe := migrate.Migrations[1].Steps[0].(migrate.AQL)
+	dedupe.BindVars = map[string]any{"@collection": name}
+	// Running twice changes nothing the second time
+	for range 2 {
+		if err := dedupe.Apply(ctx, db); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys, err := arango.Query[string](ctx, db, `FOR e IN @@collection SORT e._key RETURN e._key`,
+		map[string]any{"@collection": name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(keys, []string{"older", "other"}) {
+		t.Errorf("kept %q, want the oldest edge per pair", keys)
+	}
+}
+
+func TestTTLIndex(t *testing.T) {
+	step := migrate.TTLIndex{Collection: "idempotency_keys", Name: "idx_idempotency_ttl", Field: "createdAt", ExpireAfter: 24 * time.Hour}
+	if got, want := step.Describe(), "ensure ttl index idx_idempotency_ttl on idempotency_keys [createdAt] after 24h0m0s"; got != want {
+		t.Errorf("Describe() = %q, want %q", got, want)
+	}
+
+	db := arangotest.DB(t)
+	ctx := context.Background()
+
+	step.Collection = "ttl_test_docs"
+	scratchCollection(t, db, migrate.CreateCollection{Name: step.Collection})
+	// Ensuring an existing index is a no-op
+	for range 2 {
+		if err := step.Apply(ctx, db); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	col, err := db.Database().Collection(ctx, string(step.Collection))
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := col.Index(ctx, step.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.Type != arangodb.TTLIndexType || index.RegularIndex == nil || index.RegularIndex.ExpireAfter == nil ||
+		*index.RegularIndex.ExpireAfter != 86400 {
+		t.Errorf("index = %+v, want a ttl index expiring after 86400s", index)
+	}
+}
+
+// scratchCollection creates a collection that is dropped when the test ends
+func scratchCollection(t *testing.T, db *arango.Client, step migrate.CreateCollection) {
+	t.Helper()
+	ctx := context.Background()
+	if err := step.Apply(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if col, err := db.Database().Collection(ctx, string(step.Name)); err == nil {
+			col.Remove(ctx)
+		}
+	})
+}
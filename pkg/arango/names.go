@@ -0,0 +1,79 @@
+package arango
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// queryNames maps AQL text to its logical name
+var queryNames sync.Map
+
+// RegisterQueries names AQL constants for logging and profiling. Each query
+// is reported as "<prefix>.<name>", e.g. "post.GetPostTags".
+func RegisterQueries(prefix string, queries map[string]string) {
+	for name, query := range queries {
+		queryNames.Store(query, prefix+"."+name)
+	}
+}
+
+// QueryName returns the registered name of a query, or a stable hash of its
+// text for ad-hoc queries
+func QueryName(query string) string {
+	if name, ok := queryNames.Load(query); ok {
+		return name.(string)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(query))
+	return fmt.Sprintf("aql:%08x", h.Sum32())
+}
+
+// bindVarShapes describes bind variables by type and size, never by value,
+// so they can be logged without leaking user data
+func bindVarShapes(bindVars map[string]any) map[string]string {
+	if len(bindVars) == 0 {
+		return nil
+	}
+	shapes := make(map[string]string, len(bindVars))
+	for k, v := range bindVars {
+		shapes[k] = shapeOf(v)
+	}
+	return shapes
+}
+
+func shapeOf(v any) string {
+	if v == nil {
+		return "null"
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("string(%d)", rv.Len())
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.Slice, reflect.Array:
+		return fmt.Sprintf("array(%d)", rv.Len())
+	case reflect.Map:
+		keys := make([]string, 0, rv.Len())
+		for _, k := range rv.MapKeys() {
+			keys = append(keys, fmt.Sprint(k.Interface()))
+		}
+		sort.Strings(keys)
+		return fmt.Sprintf("object%v", keys)
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return "null"
+		}
+		return shapeOf(rv.Elem().Interface())
+	default:
+		return rv.Kind().String()
+	}
+}
@@ -0,0 +1,82 @@
+package arango
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/askme/api/pkg/metrics"
+	"github.com/askme/api/pkg/slogutil"
+	"github.com/askme/api/pkg/trace"
+)
+
+var (
+	queryDuration = metrics.Default.NewHistogram("arango_query_duration_seconds",
+		"AQL query duration by registered query name.", metrics.DefBuckets, "query")
+	queryErrors = metrics.Default.NewCounter("arango_query_errors_total",
+		"Failed AQL queries by registered query name.", "query")
+)
+
+// startSpan starts the client span of a query, named after the operation
+// with the query's registered name as an attribute
+func startSpan(ctx context.Context, operation, query string) (context.Context, *trace.Span) {
+	return trace.Start(ctx, operation,
+		trace.WithKind(trace.KindClient),
+		trace.WithAttributes(
+			slog.String("db.system", "arangodb"),
+			slog.String("db.query.name", QueryName(query)),
+		),
+	)
+}
+
+// endSpan ends the span of a finished query
+func endSpan(span *trace.Span, rows int, err error) {
+	span.SetAttributes(slog.Int("db.response.returned_rows", rows))
+	span.RecordError(err)
+	span.End()
+}
+
+// observe records a finished query in the query metrics. Queries slower
+// than the client's threshold are logged with their bind-var shapes, and
+// under a profiled context every query is added to the profile with its plan.
+func (c *Client) observe(ctx context.Context, query string, bindVars map[string]any, elapsed time.Duration, stats QueryStats, err error) {
+	name := QueryName(query)
+	queryDuration.Observe(elapsed.Seconds(), name)
+	if err != nil {
+		queryErrors.Inc(name)
+	}
+
+	if c.slowQuery > 0 && elapsed >= c.slowQuery {
+		slogutil.FromContext(ctx).Warn("slow query",
+			"query", name,
+			"durationMs", elapsed.Milliseconds(),
+			"bindVars", bindVarShapes(bindVars),
+			"rows", stats.Rows,
+			"scannedFull", stats.ScannedFull,
+			"scannedIndex", stats.ScannedIndex,
+		)
+	}
+
+	profile := profileFrom(ctx)
+	if profile == nil {
+		return
+	}
+
+	qp := QueryProfile{
+		Name:       name,
+		DurationMs: float64(elapsed.Microseconds()) / 1000,
+		BindVars:   bindVarShapes(bindVars),
+		Rows:       stats.Rows,
+		Stats:      stats,
+	}
+	if err != nil {
+		qp.Error = err.Error()
+	}
+	plan, warnings, explainErr := c.explain(ctx, query, bindVars)
+	if explainErr != nil && qp.Error == "" {
+		qp.Error = "explain: " + explainErr.Error()
+	}
+	qp.Plan = plan
+	qp.Warnings = warnings
+	profile.add(qp)
+}
@@ -0,0 +1,56 @@
+package arango
+
+import (
+	"context"
+	"sync"
+
+	"github.com/arangodb/go-driver/v2/arangodb"
+)
+
+type profileContextKey struct{}
+
+// Profile collects every query run under a profiled context
+type Profile struct {
+	mu      sync.Mutex
+	Queries []QueryProfile `json:"queries"`
+}
+
+// QueryProfile is one profiled query with its execution plan
+type QueryProfile struct {
+	Name       string                           `json:"name"`
+	DurationMs float64                          `json:"durationMs"`
+	BindVars   map[string]string                `json:"bindVars,omitempty"`
+	Rows       int                              `json:"rows"`
+	Stats      QueryStats                       `json:"stats"`
+	Plan       *arangodb.ExplainQueryResultPlan `json:"plan,omitempty"`
+	Warnings   []string                         `json:"warnings,omitempty"`
+	Error      string                           `json:"error,omitempty"`
+}
+
+// WithProfile returns a context under which queries are profiled, and the
+// profile they are recorded into
+func WithProfile(ctx context.Context) (context.Context, *Profile) {
+	p := &Profile{}
+	return context.WithValue(ctx, profileContextKey{}, p), p
+}
+
+func profileFrom(ctx context.Context) *Profile {
+	p, _ := ctx.Value(profileContextKey{}).(*Profile)
+	return p
+}
+
+func (p *Profile) add(q QueryProfile) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Queries = append(p.Queries, q)
+}
+
+// explain fetches the execution plan for a profiled query. Failures are
+// recorded on the profile rather than failing the request.
+func (c *Client) explain(ctx context.Context, query string, bindVars map[string]any) (*arangodb.ExplainQueryResultPlan, []string, error) {
+	result, err := c.executor(ctx).ExplainQuery(ctx, query, bindVars, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &result.Plan, result.Warnings, nil
+}
@@ -0,0 +1,149 @@
+package arango
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"time"
+
+	"github.com/arangodb/go-driver/v2/arangodb"
+
+	"github.com/askme/api/pkg/slogutil"
+)
+
+// StreamOptions tunes a streamed query. The zero value uses server defaults.
+type StreamOptions struct {
+	// BatchSize is the number of documents fetched per round trip
+	BatchSize int
+	// MemoryLimit caps the server memory the query may use, in bytes
+	MemoryLimit int64
+	// TTL is how long the server keeps an idle cursor alive
+	TTL time.Duration
+	// FullCount reports in Stats how many documents matched before the
+	// final LIMIT
+	FullCount bool
+	// Stats, if set, is filled in once iteration finishes
+	Stats *QueryStats
+}
+
+// QueryStats summarizes a finished query
+type QueryStats struct {
+	Rows          int           `json:"rows"`
+	ScannedFull   uint64        `json:"scannedFull"`
+	ScannedIndex  uint64        `json:"scannedIndex"`
+	Filtered      uint64        `json:"filtered"`
+	FullCount     uint64        `json:"fullCount,omitempty"`
+	PeakMemory    uint64        `json:"peakMemory"`
+	ExecutionTime time.Duration `json:"executionTimeNs"`
+	// Partial is set when iteration stopped before the last batch. A
+	// streaming cursor receives the server's stats with its last batch, so
+	// only Rows is then reliable.
+	Partial bool `json:"partial,omitempty"`
+}
+
+// Stream executes an AQL query as a server-side streaming cursor and yields
+// documents batch by batch, without buffering the whole result. Iteration
+// stops at the first error, which is yielded with a zero T. Execution
+// stats are logged to the context logger when iteration ends; breaking
+// out early leaves them partial, see QueryStats.
+func Stream[T any](ctx context.Context, client *Client, query string, bindVars map[string]any, opts *StreamOptions) iter.Seq2[T, error] {
+	if opts == nil {
+		opts = &StreamOptions{}
+	}
+
+	return func(yield func(T, error) bool) {
+		var zero T
+		ctx, span := startSpan(ctx, "arango.Stream", query)
+		start := time.Now()
+
+		cur, err := client.executor(ctx).Query(ctx, query, &arangodb.QueryOptions{
+			BindVars:    bindVars,
+			BatchSize:   opts.BatchSize,
+			MemoryLimit: opts.MemoryLimit,
+			TTL:         opts.TTL.Seconds(),
+			Options: arangodb.QuerySubOptions{
+				Stream:    true,
+				FullCount: opts.FullCount,
+			},
+		})
+		if err != nil {
+			err = fmt.Errorf("query failed: %w", translateError(err))
+			client.observe(ctx, query, bindVars, time.Since(start), QueryStats{}, err)
+			endSpan(span, 0, err)
+			yield(zero, err)
+			return
+		}
+
+		stats, err := drain(ctx, query, cur, yield)
+		if opts.Stats != nil {
+			*opts.Stats = stats
+		}
+		client.observe(ctx, query, bindVars, time.Since(start), stats, err)
+		endSpan(span, stats.Rows, err)
+	}
+}
+
+// cursor is the part of arangodb.Cursor that reading a result needs
+type cursor interface {
+	HasMore() bool
+	ReadDocument(ctx context.Context, result any) (arangodb.DocumentMeta, error)
+	Statistics() arangodb.CursorStats
+	Close() error
+}
+
+// drain yields the cursor's documents until it is exhausted, a read fails
+// or yield returns false, then closes the cursor and logs its stats
+func drain[T any](ctx context.Context, query string, cur cursor, yield func(T, error) bool) (QueryStats, error) {
+	defer cur.Close()
+
+	rows := 0
+	for cur.HasMore() {
+		var doc T
+		if _, err := cur.ReadDocument(ctx, &doc); err != nil {
+			err = fmt.Errorf("read document failed: %w", err)
+			var zero T
+			yield(zero, err)
+			stats := newQueryStats(cur.Statistics(), rows)
+			stats.Partial = true
+			logStats(ctx, query, stats)
+			return stats, err
+		}
+		rows++
+		if !yield(doc, nil) {
+			break
+		}
+	}
+
+	stats := newQueryStats(cur.Statistics(), rows)
+	stats.Partial = cur.HasMore()
+	logStats(ctx, query, stats)
+	return stats, nil
+}
+
+func newQueryStats(s arangodb.CursorStats, rows int) QueryStats {
+	return QueryStats{
+		Rows:          rows,
+		ScannedFull:   s.ScannedFullInt,
+		ScannedIndex:  s.ScannedIndexInt,
+		Filtered:      s.FilteredInt,
+		FullCount:     s.FullCountInt,
+		PeakMemory:    s.PeakMemoryUsage,
+		ExecutionTime: time.Duration(s.ExecutionTimeInt * float64(time.Second)),
+	}
+}
+
+// logStats reports a stream's execution stats at debug level; slow
+// streams are additionally logged by observe
+func logStats(ctx context.Context, query string, stats QueryStats) {
+	slogutil.FromContext(ctx).Debug("aql stream",
+		"query", QueryName(query),
+		"rows", stats.Rows,
+		"scannedFull", stats.ScannedFull,
+		"scannedIndex", stats.ScannedIndex,
+		"filtered", stats.Filtered,
+		"fullCount", stats.FullCount,
+		"peakMemory", stats.PeakMemory,
+		"executionMs", stats.ExecutionTime.Milliseconds(),
+		"partial", stats.Partial,
+	)
+}
@@ -0,0 +1,107 @@
+package arango
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/arangodb/go-driver/v2/arangodb"
+
+	"github.com/askme/api/pkg/slogutil"
+)
+
+// fakeCursor serves JSON documents like a streaming cursor: the server's
+// stats only arrive with the last document
+type fakeCursor struct {
+	docs    []string
+	next    int
+	failAt  int
+	closed  bool
+	scanned uint64
+}
+
+func (c *fakeCursor) HasMore() bool { return c.next < len(c.docs) }
+
+func (c *fakeCursor) ReadDocument(ctx context.Context, result any) (arangodb.DocumentMeta, error) {
+	if c.failAt > 0 && c.next == c.failAt {
+		return arangodb.DocumentMeta{}, errors.New("connection reset")
+	}
+	doc := c.docs[c.next]
+	c.next++
+	return arangodb.DocumentMeta{}, json.Unmarshal([]byte(doc), result)
+}
+
+func (c *fakeCursor) Statistics() arangodb.CursorStats {
+	if c.HasMore() {
+		return arangodb.CursorStats{}
+	}
+	return arangodb.CursorStats{ScannedIndexInt: c.scanned, ExecutionTimeInt: 0.002}
+}
+
+func (c *fakeCursor) Close() error {
+	c.closed = true
+	return nil
+}
+
+type feedRow struct {
+	Key string `json:"_key"`
+}
+
+func TestDrain(t *testing.T) {
+	docs := []string{`{"_key":"p1"}`, `{"_key":"p2"}`, `{"_key":"p3"}`}
+	const query = "FOR p IN posts RETURN p"
+
+	tests := []struct {
+		name    string
+		cursor  *fakeCursor
+		take    int
+		want    QueryStats
+		wantErr bool
+		wantLog string
+	}{
+		{"exhausted", &fakeCursor{docs: docs, scanned: 3}, 3,
+			QueryStats{Rows: 3, ScannedIndex: 3, ExecutionTime: 2e6}, false, "rows=3 scannedFull=0 scannedIndex=3"},
+		{"early break", &fakeCursor{docs: docs, scanned: 3}, 2,
+			QueryStats{Rows: 2, Partial: true}, false, "rows=2 scannedFull=0 scannedIndex=0"},
+		{"read error", &fakeCursor{docs: docs, failAt: 1}, 3,
+			QueryStats{Rows: 1, Partial: true}, true, "rows=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			ctx := slogutil.WithLogger(context.Background(), logger)
+
+			var got []string
+			stats, err := drain(ctx, query, tt.cursor, func(row feedRow, err error) bool {
+				if err == nil {
+					got = append(got, row.Key)
+				}
+				return len(got) < tt.take
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v", err)
+			}
+			if stats != tt.want {
+				t.Errorf("stats = %+v, want %+v", stats, tt.want)
+			}
+			if len(got) != min(tt.take, tt.want.Rows) {
+				t.Errorf("rows = %v", got)
+			}
+			if !tt.cursor.closed {
+				t.Error("cursor not closed")
+			}
+
+			log := buf.String()
+			if !strings.Contains(log, `msg="aql stream"`) || !strings.Contains(log, tt.wantLog) ||
+				strings.Contains(log, "partial=true") != tt.want.Partial {
+				t.Errorf("log = %s", log)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package arango
+
+import (
+	"context"
+
+	"github.com/arangodb/go-driver/v2/arangodb"
+)
+
+// txContextKey is the context key under which the active stream transaction is stored
+type txContextKey struct{}
+
+// executor is the subset of the driver shared by databases and stream transactions
+type executor interface {
+	arangodb.DatabaseCollection
+	arangodb.DatabaseQuery
+}
+
+// WithTransaction runs fn inside an ArangoDB stream transaction that writes to the
+// given collections. The transaction travels in the context passed to fn, so every
+// Query, InsertDocument, UpdateDocument, GetDocument and DeleteDocument call made
+// with that context joins it. The transaction is committed when fn returns nil and
+// aborted otherwise; a commit that loses a write-write conflict returns
+// domain.ErrConflict. Nested calls reuse the outer transaction.
+func (c *Client) WithTransaction(ctx context.Context, collections []Collection, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txContextKey{}).(arangodb.Transaction); ok {
+		return fn(ctx)
+	}
+
+	write := make([]string, len(collections))
+	for i, col := range collections {
+		write[i] = string(col)
+	}
+
+	err := c.db.WithTransaction(ctx, arangodb.TransactionCollections{Write: write}, &arangodb.BeginTransactionOptions{
+		AllowImplicit: true,
+	}, nil, nil, func(ctx context.Context, t arangodb.Transaction) error {
+		return fn(context.WithValue(ctx, txContextKey{}, t))
+	})
+	return translateError(err)
+}
+
+// executor returns the transaction carried by ctx, or the database when there is none
+func (c *Client) executor(ctx context.Context) executor {
+	if t, ok := ctx.Value(txContextKey{}).(arangodb.Transaction); ok {
+		return t
+	}
+	return c.db
+}
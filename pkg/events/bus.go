@@ -0,0 +1,91 @@
+// Package events provides an in-process publish/subscribe bus using only the standard library.
+package events
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// Event is a notification delivered to every subscriber of its topic.
+type Event struct {
+	Type      string `json:"type"`
+	Topic     string `json:"topic"`
+	Payload   any    `json:"payload"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+// Bus fans events out to topic subscribers. Delivery is best-effort: a
+// subscriber whose buffer is full misses the event rather than blocking
+// the publisher.
+type Bus struct {
+	mu     sync.RWMutex
+	subs   map[string]map[chan Event]struct{}
+	closed bool
+}
+
+// NewBus creates an empty event bus.
+func NewBus() *Bus {
+	return &Bus{subs: make(map[string]map[chan Event]struct{})}
+}
+
+// Publish delivers the event to all current subscribers of its topic.
+func (b *Bus) Publish(_ context.Context, e Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subs[e.Topic] {
+		select {
+		case ch <- e:
+		default:
+			slog.Warn("event dropped for slow subscriber", "topic", e.Topic, "type", e.Type)
+		}
+	}
+}
+
+// Subscribe registers a subscriber for a topic. The returned function
+// unsubscribes and closes the channel. After Close the channel is closed
+// right away.
+func (b *Bus) Subscribe(topic string, buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if b.subs[topic] == nil {
+		b.subs[topic] = make(map[chan Event]struct{})
+	}
+	b.subs[topic][ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		// Close may have ended the subscription already
+		if _, ok := b.subs[topic][ch]; !ok {
+			return
+		}
+		delete(b.subs[topic], ch)
+		if len(b.subs[topic]) == 0 {
+			delete(b.subs, topic)
+		}
+		close(ch)
+	}
+}
+
+// Close ends every subscription by closing its channel, so long-lived
+// subscribers such as event streams return, e.g. on shutdown.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, chans := range b.subs {
+		for ch := range chans {
+			close(ch)
+		}
+	}
+	b.subs = make(map[string]map[chan Event]struct{})
+	b.closed = true
+}
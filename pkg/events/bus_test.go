@@ -0,0 +1,54 @@
+package events
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBus(t *testing.T) {
+	ctx := context.Background()
+	bus := NewBus()
+
+	events, unsubscribe := bus.Subscribe("chats/c1", 1)
+	other, _ := bus.Subscribe("chats/c2", 1)
+
+	bus.Publish(ctx, Event{Type: "message.edited", Topic: "chats/c1"})
+	if e := <-events; e.Type != "message.edited" {
+		t.Errorf("event = %+v", e)
+	}
+	select {
+	case e := <-other:
+		t.Errorf("other topic got %+v", e)
+	default:
+	}
+
+	// A full buffer drops the event instead of blocking the publisher
+	bus.Publish(ctx, Event{Type: "a", Topic: "chats/c1"})
+	bus.Publish(ctx, Event{Type: "b", Topic: "chats/c1"})
+	if e := <-events; e.Type != "a" {
+		t.Errorf("event = %+v", e)
+	}
+
+	unsubscribe()
+	unsubscribe()
+	if _, ok := <-events; ok {
+		t.Error("channel open after unsubscribe")
+	}
+}
+
+func TestBusClose(t *testing.T) {
+	bus := NewBus()
+	events, unsubscribe := bus.Subscribe("chats/c1", 1)
+
+	bus.Close()
+	if _, ok := <-events; ok {
+		t.Error("channel open after Close")
+	}
+	// Unsubscribing after Close must not close the channel twice
+	unsubscribe()
+
+	late, _ := bus.Subscribe("chats/c1", 1)
+	if _, ok := <-late; ok {
+		t.Error("subscription after Close is open")
+	}
+}
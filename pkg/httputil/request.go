@@ -2,19 +2,91 @@ package httputil
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+
+	"github.com/askme/api/pkg/validate"
+)
+
+// MaxBodyBytes caps the size of JSON request bodies
+const MaxBodyBytes = 1 << 20
+
+var (
+	// ErrInvalidBody wraps DecodeJSON errors for malformed JSON
+	ErrInvalidBody = errors.New("invalid request body")
+	// ErrBodyTooLarge is returned by DecodeJSON for bodies over MaxBodyBytes
+	ErrBodyTooLarge = fmt.Errorf("request body must be at most %d bytes", MaxBodyBytes)
 )
 
-// DecodeJSON decodes JSON request body into the given struct
+// DecodeJSON decodes a JSON request body into the given struct and validates
+// it. The body must be a single JSON object of at most MaxBodyBytes without
+// unknown fields. Errors are validate.Errors for bad fields, ErrBodyTooLarge,
+// or ErrInvalidBody for malformed JSON; write them with InvalidRequest.
 func DecodeJSON[T any](r *http.Request) (*T, error) {
 	var v T
-	if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
-		return nil, err
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, MaxBodyBytes))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&v); err != nil {
+		return nil, decodeError(err)
+	}
+	// Anything after the object, even a second object, is rejected
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, ErrBodyTooLarge
+		}
+		return nil, fmt.Errorf("%w: expected a single JSON object", ErrInvalidBody)
+	}
+
+	if err := validate.Struct(&v); err != nil {
+		return nil, fmt.Errorf("validating %T: %w", v, err)
 	}
 	return &v, nil
 }
 
+// decodeError turns a json decoding error into a client-facing one
+func decodeError(err error) error {
+	var tooLarge *http.MaxBytesError
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &tooLarge):
+		return ErrBodyTooLarge
+	case errors.Is(err, io.EOF):
+		return fmt.Errorf("%w: body is empty", ErrInvalidBody)
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return validate.Field(typeErr.Field, "type", fmt.Sprintf("%s must be %s", typeErr.Field, jsonType(typeErr.Type.Kind().String())))
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF), errors.As(err, &typeErr):
+		return fmt.Errorf("%w: not a valid JSON object", ErrInvalidBody)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		return validate.Field(field, "unknown", field+" is not a known field")
+	}
+	return ErrInvalidBody
+}
+
+// jsonType names a Go kind the way a JSON client would
+func jsonType(kind string) string {
+	switch {
+	case kind == "string":
+		return "a string"
+	case kind == "bool":
+		return "a boolean"
+	case kind == "slice" || kind == "array":
+		return "an array"
+	case kind == "struct" || kind == "map":
+		return "an object"
+	case strings.HasPrefix(kind, "int") || strings.HasPrefix(kind, "uint") || strings.HasPrefix(kind, "float"):
+		return "a number"
+	}
+	return "a valid value"
+}
+
 // PathValue extracts a path parameter from the request
 func PathValue(r *http.Request, key string) string {
 	return r.PathValue(key)
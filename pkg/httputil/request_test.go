@@ -0,0 +1,56 @@
+package httputil
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/askme/api/pkg/validate"
+)
+
+type decodeTarget struct {
+	Text  string   `json:"text" validate:"required"`
+	Items []string `json:"items,omitempty"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantErr   error
+		wantField string
+	}{
+		{"valid", `{"text":"hi","items":["a"]}`, nil, ""},
+		{"trailing whitespace", "{\"text\":\"hi\"}\n", nil, ""},
+		{"empty", ``, ErrInvalidBody, ""},
+		{"malformed", `{"text":`, ErrInvalidBody, ""},
+		{"not an object", `"hi"`, ErrInvalidBody, ""},
+		{"second object", `{"text":"a"}{"text":"b"}`, ErrInvalidBody, ""},
+		{"trailing garbage", `{"text":"a"} x`, ErrInvalidBody, ""},
+		{"too large", `{"text":"` + strings.Repeat("a", MaxBodyBytes) + `"}`, ErrBodyTooLarge, ""},
+		{"unknown field", `{"text":"hi","extra":1}`, nil, "extra"},
+		{"wrong type", `{"text":"hi","items":"a"}`, nil, "items"},
+		{"failed rule", `{"text":""}`, nil, "text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			v, err := DecodeJSON[decodeTarget](r)
+
+			var errs validate.Errors
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantField != "":
+				if !errors.As(err, &errs) || errs[0].Field != tt.wantField {
+					t.Fatalf("got %v, want a field error on %s", err, tt.wantField)
+				}
+			case err != nil || v.Text != "hi":
+				t.Fatalf("got %+v, %v, want the decoded body", v, err)
+			}
+		})
+	}
+}
@@ -3,9 +3,11 @@ package httputil
 import (
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"net/http"
 
 	"github.com/askme/api/internal/domain"
+	"github.com/askme/api/pkg/validate"
 )
 
 // Response is a standard API response wrapper with generic data type
@@ -15,10 +17,51 @@ type Response[T any] struct {
 	Error   string `json:"error,omitempty"`
 }
 
-// ErrorResponse is used for error responses without data
-type ErrorResponse struct {
+// ProblemContentType is the media type of error responses (RFC 9457)
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypeBase prefixes error codes to form the problem type URI
+var ProblemTypeBase = "urn:askme:problem:"
+
+// Problem is an RFC 9457 problem details body, sent for every error
+type Problem struct {
+	// Success is always false, matching the success envelope
 	Success bool   `json:"success"`
-	Error   string `json:"error"`
+	Type    string `json:"type"`
+	Title   string `json:"title"`
+	Status  int    `json:"status"`
+	Detail  string `json:"detail,omitempty"`
+	// Code is the stable error code clients branch on, e.g. "poll_closed"
+	Code string `json:"code"`
+	// RequestID matches the X-Request-ID header and the server logs
+	RequestID string `json:"requestId,omitempty"`
+	// Details lists the invalid fields of a rejected request
+	Details []validate.FieldError `json:"details,omitempty"`
+	// Meta carries the domain error's metadata, e.g. a poll's closesAt
+	Meta map[string]any `json:"meta,omitempty"`
+}
+
+// Error codes of problems that are not domain errors
+const (
+	CodeBadRequest       = "bad_request"
+	CodeValidationFailed = "validation_failed"
+	CodeBodyTooLarge     = "body_too_large"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeInternal         = "internal"
+)
+
+// statusCodes maps HTTP statuses to the error code sent by Error
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            CodeBadRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusConflict:              CodeConflict,
+	http.StatusRequestEntityTooLarge: CodeBodyTooLarge,
+	http.StatusInternalServerError:   CodeInternal,
 }
 
 // JSON writes a type-safe JSON response
@@ -34,35 +77,71 @@ func JSON[T any](w http.ResponseWriter, status int, data T) {
 	json.NewEncoder(w).Encode(resp)
 }
 
-// Error writes an error response
+// Error writes a problem with the default code of its status
 func Error(w http.ResponseWriter, status int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-
-	resp := ErrorResponse{
-		Success: false,
-		Error:   message,
+	code, ok := statusCodes[status]
+	if !ok {
+		code = CodeBadRequest
+		if status >= 500 {
+			code = CodeInternal
+		}
 	}
-
-	json.NewEncoder(w).Encode(resp)
+	WriteProblem(w, &Problem{Status: status, Code: code, Title: http.StatusText(status), Detail: message})
 }
 
-// ErrorFromDomain maps domain errors to HTTP status codes
-func ErrorFromDomain(w http.ResponseWriter, err error) {
+// InvalidRequest writes the error returned by DecodeJSON. Field errors are
+// listed in details so clients can point at the bad field.
+func InvalidRequest(w http.ResponseWriter, err error) {
+	var fieldErrs validate.Errors
 	switch {
-	case errors.Is(err, domain.ErrNotFound):
-		Error(w, http.StatusNotFound, err.Error())
-	case errors.Is(err, domain.ErrAlreadyExists):
-		Error(w, http.StatusConflict, err.Error())
-	case errors.Is(err, domain.ErrInvalidInput):
+	case errors.As(err, &fieldErrs):
+		WriteProblem(w, &Problem{
+			Status:  http.StatusBadRequest,
+			Code:    CodeValidationFailed,
+			Title:   "request validation failed",
+			Detail:  fieldErrs.Error(),
+			Details: fieldErrs,
+		})
+	case errors.Is(err, ErrBodyTooLarge):
+		Error(w, http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, ErrInvalidBody):
 		Error(w, http.StatusBadRequest, err.Error())
-	case errors.Is(err, domain.ErrUnauthorized):
-		Error(w, http.StatusUnauthorized, err.Error())
-	case errors.Is(err, domain.ErrForbidden):
-		Error(w, http.StatusForbidden, err.Error())
-	case errors.Is(err, domain.ErrMutualFollowRequired):
-		Error(w, http.StatusForbidden, err.Error())
 	default:
+		// A malformed validate tag or Validate method
+		ErrorFromDomain(w, err)
+	}
+}
+
+// ErrorFromDomain writes a domain error as a problem with its code, status
+// and metadata. The detail keeps the wrapped context, e.g. "invalid input:
+// duplicate poll option". Errors that are not domain errors are logged with
+// the request ID and sent as a bare 500.
+func ErrorFromDomain(w http.ResponseWriter, err error) {
+	var de *domain.Error
+	if !errors.As(err, &de) {
+		slog.Error("internal error", "requestId", w.Header().Get(requestIDHeader), "error", err)
 		Error(w, http.StatusInternalServerError, "internal server error")
+		return
 	}
+
+	p := &Problem{Status: de.Status, Code: de.Code, Title: de.Message, Meta: de.Meta}
+	if detail := err.Error(); detail != de.Message {
+		p.Detail = detail
+	}
+	WriteProblem(w, p)
+}
+
+// requestIDHeader is set on the response by middleware.RequestID
+const requestIDHeader = "X-Request-ID"
+
+// WriteProblem writes p as application/problem+json, filling in its type
+// and the request ID
+func WriteProblem(w http.ResponseWriter, p *Problem) {
+	p.Success = false
+	p.Type = ProblemTypeBase + p.Code
+	p.RequestID = w.Header().Get(requestIDHeader)
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
 }
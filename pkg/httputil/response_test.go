@@ -0,0 +1,74 @@
+package httputil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/askme/api/internal/domain"
+)
+
+func TestErrorFromDomain(t *testing.T) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail string
+	}{
+		{"sentinel", domain.ErrNotFound, http.StatusNotFound, "not_found", ""},
+		{"refined", domain.ErrNotAuthor, http.StatusForbidden, "not_author", ""},
+		{"wrapped keeps context", fmt.Errorf("%w: duplicate poll option", domain.ErrInvalidInput),
+			http.StatusBadRequest, "invalid_input", "invalid input: duplicate poll option"},
+		{"with metadata", fmt.Errorf("vote: %w", domain.ErrPollClosed.With("closesAt", 42)),
+			http.StatusConflict, "poll_closed", "vote: poll is closed"},
+		{"not a domain error", errors.New("connection refused"), http.StatusInternalServerError, "internal", "internal server error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rec.Header().Set("X-Request-ID", "req-1")
+			ErrorFromDomain(rec, tt.err)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != ProblemContentType {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			var p Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+				t.Fatalf("decode problem: %v", err)
+			}
+			if p.Code != tt.wantCode || p.Type != ProblemTypeBase+tt.wantCode || p.Status != tt.wantStatus {
+				t.Errorf("problem = %+v, want code %s", p, tt.wantCode)
+			}
+			if p.Detail != tt.wantDetail {
+				t.Errorf("detail = %q, want %q", p.Detail, tt.wantDetail)
+			}
+			if p.RequestID != "req-1" {
+				t.Errorf("requestId = %q, want req-1", p.RequestID)
+			}
+		})
+	}
+}
+
+func TestErrorWithKeepsIdentity(t *testing.T) {
+	err := domain.ErrPollClosed.With("closesAt", 42)
+	if !errors.Is(err, domain.ErrPollClosed) {
+		t.Error("With copy does not match its error")
+	}
+	if !errors.Is(domain.ErrNotAuthor, domain.ErrForbidden) {
+		t.Error("refined error does not match its parent")
+	}
+	if domain.ErrPollClosed.Meta != nil {
+		t.Error("With modified the shared error")
+	}
+}
@@ -0,0 +1,380 @@
+// Package metrics provides counters, gauges and histograms exposed in the
+// Prometheus text format, using only the standard library.
+//
+// Metrics are registered once, usually in package-level variables, and
+// updated with their label values in declaration order:
+//
+//	var requests = metrics.Default.NewCounter("http_requests_total", "HTTP requests.", "route", "status")
+//	requests.Inc("GET /posts/{postId}", "200")
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefBuckets are histogram buckets for durations in seconds, from 5ms to 10s
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// SizeBuckets are histogram buckets for sizes in bytes, from 100B to 10MB
+var SizeBuckets = []float64{100, 1_000, 10_000, 100_000, 1_000_000, 10_000_000}
+
+// Default is the registry served by the API's /metrics endpoint
+var Default = NewRegistry()
+
+// collector is a metric family that writes itself in text format
+type collector interface {
+	name() string
+	collect(w *bufio.Writer)
+}
+
+// Registry holds metric families and writes them in text format
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// register adds c, panicking on a duplicate name as that is a programming
+// error
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.collectors {
+		if existing.name() == c.name() {
+			panic("metrics: duplicate metric " + c.name())
+		}
+	}
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteTo writes every metric family in text format, sorted by name
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	collectors := slices.Clone(r.collectors)
+	r.mu.Unlock()
+	slices.SortFunc(collectors, func(a, b collector) int { return strings.Compare(a.name(), b.name()) })
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, c := range collectors {
+		c.collect(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// Handler serves the registry in text format
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		r.WriteTo(w)
+	})
+}
+
+// vec holds the series of a metric family by label values
+type vec[S any] struct {
+	family string
+	help   string
+	typ    string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*S
+	values map[string][]string
+	newS   func() *S
+}
+
+func newVec[S any](family, help, typ string, labels []string, newS func() *S) *vec[S] {
+	return &vec[S]{
+		family: family,
+		help:   help,
+		typ:    typ,
+		labels: labels,
+		series: make(map[string]*S),
+		values: make(map[string][]string),
+		newS:   newS,
+	}
+}
+
+func (v *vec[S]) name() string {
+	return v.family
+}
+
+// with returns the series of the label values, creating it on first use.
+// The caller must hold v.mu.
+func (v *vec[S]) with(labelValues []string) *S {
+	if len(labelValues) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", v.family, len(v.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = v.newS()
+		v.series[key] = s
+		v.values[key] = slices.Clone(labelValues)
+	}
+	return s
+}
+
+// each calls fn for every series, ordered by label values. The caller must
+// hold v.mu.
+func (v *vec[S]) each(fn func(labels string, s *S)) {
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		fn(formatLabels(v.labels, v.values[k]), v.series[k])
+	}
+}
+
+// Counter is a monotonically increasing value per label set
+type Counter struct {
+	*vec[float64]
+}
+
+// NewCounter registers a counter. By convention its name ends in _total.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{newVec(name, help, "counter", labels, func() *float64 { return new(float64) })}
+	r.register(c)
+	return c
+}
+
+// Inc adds one to the series of the label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds delta, which must not be negative, to the series of the label
+// values
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.with(labelValues) += delta
+}
+
+func (c *Counter) collect(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	writeHeader(w, c.family, c.help, c.typ)
+	c.each(func(labels string, v *float64) {
+		writeSample(w, c.family, labels, *v)
+	})
+}
+
+// Gauge is a value per label set that can go up and down
+type Gauge struct {
+	*vec[float64]
+}
+
+// NewGauge registers a gauge
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{newVec(name, help, "gauge", labels, func() *float64 { return new(float64) })}
+	r.register(g)
+	return g
+}
+
+// Set sets the series of the label values
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	*g.with(labelValues) = value
+}
+
+// Add adds delta, which may be negative, to the series of the label values
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	*g.with(labelValues) += delta
+}
+
+// Inc adds one to the series of the label values
+func (g *Gauge) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+// Dec subtracts one from the series of the label values
+func (g *Gauge) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+func (g *Gauge) collect(w *bufio.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	writeHeader(w, g.family, g.help, g.typ)
+	g.each(func(labels string, v *float64) {
+		writeSample(w, g.family, labels, *v)
+	})
+}
+
+// histogramSeries is the state of one histogram label set
+type histogramSeries struct {
+	// counts holds per-bucket, not cumulative, counts; the last entry is
+	// the +Inf bucket
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Histogram counts observations in buckets per label set
+type Histogram struct {
+	*vec[histogramSeries]
+	buckets []float64
+}
+
+// NewHistogram registers a histogram with the given upper bounds, which must
+// be sorted. A +Inf bucket is added.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if !slices.IsSorted(buckets) {
+		panic("metrics: buckets of " + name + " are not sorted")
+	}
+	h := &Histogram{buckets: slices.Clone(buckets)}
+	h.vec = newVec(name, help, "histogram", labels, func() *histogramSeries {
+		return &histogramSeries{counts: make([]uint64, len(h.buckets)+1)}
+	})
+	r.register(h)
+	return h
+}
+
+// Observe records value in the series of the label values
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	i, _ := slices.BinarySearch(h.buckets, value)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.with(labelValues)
+	s.counts[i]++
+	s.sum += value
+	s.count++
+}
+
+func (h *Histogram) collect(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	writeHeader(w, h.family, h.help, h.typ)
+	h.each(func(labels string, s *histogramSeries) {
+		var cumulative uint64
+		for i, count := range s.counts {
+			cumulative += count
+			le := math.Inf(1)
+			if i < len(h.buckets) {
+				le = h.buckets[i]
+			}
+			writeSample(w, h.family+"_bucket", withLabel(labels, "le", formatFloat(le)), float64(cumulative))
+		}
+		writeSample(w, h.family+"_sum", labels, s.sum)
+		writeSample(w, h.family+"_count", labels, float64(s.count))
+	})
+}
+
+// funcMetric is an unlabeled metric read when collected
+type funcMetric struct {
+	family string
+	help   string
+	typ    string
+	fn     func() float64
+}
+
+func (f *funcMetric) name() string {
+	return f.family
+}
+
+func (f *funcMetric) collect(w *bufio.Writer) {
+	writeHeader(w, f.family, f.help, f.typ)
+	writeSample(w, f.family, "", f.fn())
+}
+
+// NewGaugeFunc registers a gauge whose value is read from fn on every scrape
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{family: name, help: help, typ: "gauge", fn: fn})
+}
+
+// NewCounterFunc registers a counter whose value is read from fn on every
+// scrape; fn must never decrease
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{family: name, help: help, typ: "counter", fn: fn})
+}
+
+func writeHeader(w *bufio.Writer, name, help, typ string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func writeSample(w *bufio.Writer, name, labels string, value float64) {
+	w.WriteString(name)
+	w.WriteString(labels)
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+// labelValueEscaper escapes label values as the text format requires
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels renders a label set as {a="1",b="2"}, or "" when empty
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(labelValueEscaper.Replace(values[i]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// withLabel appends a label to a rendered label set
+func withLabel(labels, name, value string) string {
+	pair := name + `="` + labelValueEscaper.Replace(value) + `"`
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+	return labels[:len(labels)-1] + "," + pair + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// countingWriter counts the bytes written for WriteTo
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
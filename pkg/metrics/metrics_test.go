@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteTo(t *testing.T) {
+	reg := NewRegistry()
+	requests := reg.NewCounter("requests_total", "Requests.", "route", "status")
+	latency := reg.NewHistogram("latency_seconds", "Latency.", []float64{0.1, 1}, "route")
+	inFlight := reg.NewGauge("in_flight", "In flight.")
+	reg.NewGaugeFunc("answer", "The answer.", func() float64 { return 42 })
+
+	requests.Inc("GET /posts/{postId}", "200")
+	requests.Add(2, "GET /posts/{postId}", "200")
+	requests.Inc(`say "hi"`, "404")
+	latency.Observe(0.05, "a")
+	latency.Observe(0.1, "a")
+	latency.Observe(3, "a")
+	inFlight.Inc()
+	inFlight.Inc()
+	inFlight.Dec()
+
+	var b strings.Builder
+	if _, err := reg.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP answer The answer.
+# TYPE answer gauge
+answer 42
+# HELP in_flight In flight.
+# TYPE in_flight gauge
+in_flight 1
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="a",le="0.1"} 2
+latency_seconds_bucket{route="a",le="1"} 2
+latency_seconds_bucket{route="a",le="+Inf"} 3
+latency_seconds_sum{route="a"} 3.15
+latency_seconds_count{route="a"} 3
+# HELP requests_total Requests.
+# TYPE requests_total counter
+requests_total{route="GET /posts/{postId}",status="200"} 3
+requests_total{route="say \"hi\"",status="404"} 1
+`
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestRegisterPanics(t *testing.T) {
+	reg := NewRegistry()
+	c := reg.NewCounter("c_total", "C.", "a")
+
+	for name, fn := range map[string]func(){
+		"duplicate":        func() { reg.NewGauge("c_total", "Again.") },
+		"label count":      func() { c.Inc("x", "y") },
+		"unsorted buckets": func() { reg.NewHistogram("h", "H.", []float64{1, 0.5}) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("did not panic")
+				}
+			}()
+			fn()
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	reg := NewRegistry()
+	reg.RegisterRuntime()
+
+	rec := httptest.NewRecorder()
+	reg.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	for _, name := range []string{"go_goroutines ", "go_memstats_heap_alloc_bytes ", "go_info{version=", "process_start_time_seconds "} {
+		if !strings.Contains(rec.Body.String(), "\n"+name) {
+			t.Errorf("missing %s", name)
+		}
+	}
+}
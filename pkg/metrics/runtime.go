@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// RegisterRuntime registers Go runtime metrics: goroutines, heap and GC
+// stats, the Go version and the process start time.
+func (r *Registry) RegisterRuntime() {
+	start := float64(time.Now().Unix())
+
+	// One ReadMemStats stops the world briefly, so the memory metrics of a
+	// scrape share a reading taken at most a second ago
+	var (
+		mu   sync.Mutex
+		ms   runtime.MemStats
+		read time.Time
+	)
+	memStats := func(fn func(*runtime.MemStats) float64) func() float64 {
+		return func() float64 {
+			mu.Lock()
+			defer mu.Unlock()
+			if time.Since(read) > time.Second {
+				runtime.ReadMemStats(&ms)
+				read = time.Now()
+			}
+			return fn(&ms)
+		}
+	}
+
+	r.NewGaugeFunc("go_goroutines", "Number of goroutines that currently exist.",
+		func() float64 { return float64(runtime.NumGoroutine()) })
+	r.NewGaugeFunc("go_sched_gomaxprocs_threads", "Number of OS threads that may run Go code simultaneously (GOMAXPROCS).",
+		func() float64 { return float64(runtime.GOMAXPROCS(0)) })
+	r.NewGaugeFunc("go_memstats_heap_alloc_bytes", "Bytes of allocated heap objects.",
+		memStats(func(m *runtime.MemStats) float64 { return float64(m.HeapAlloc) }))
+	r.NewGaugeFunc("go_memstats_heap_inuse_bytes", "Bytes in in-use heap spans.",
+		memStats(func(m *runtime.MemStats) float64 { return float64(m.HeapInuse) }))
+	r.NewGaugeFunc("go_memstats_heap_objects", "Number of allocated heap objects.",
+		memStats(func(m *runtime.MemStats) float64 { return float64(m.HeapObjects) }))
+	r.NewGaugeFunc("go_memstats_sys_bytes", "Bytes of memory obtained from the OS.",
+		memStats(func(m *runtime.MemStats) float64 { return float64(m.Sys) }))
+	r.NewCounterFunc("go_memstats_alloc_bytes_total", "Total bytes allocated for heap objects.",
+		memStats(func(m *runtime.MemStats) float64 { return float64(m.TotalAlloc) }))
+	r.NewCounterFunc("go_gc_cycles_total", "Number of completed GC cycles.",
+		memStats(func(m *runtime.MemStats) float64 { return float64(m.NumGC) }))
+	r.NewCounterFunc("go_gc_pause_seconds_total", "Total time spent in GC stop-the-world pauses.",
+		memStats(func(m *runtime.MemStats) float64 { return time.Duration(m.PauseTotalNs).Seconds() }))
+	r.NewGauge("go_info", "Version of the Go runtime.", "version").Set(1, runtime.Version())
+	r.NewGaugeFunc("process_start_time_seconds", "Start time of the process since the Unix epoch in seconds.",
+		func() float64 { return start })
+}
@@ -0,0 +1,233 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// IdempotencyKeyHeader is the request header carrying the client's key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set to "true" on replayed responses
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// MaxIdempotencyKeyLength bounds the keys clients may send
+const MaxIdempotencyKeyLength = 255
+
+// maxFingerprintBody bounds the part of the body that is hashed; handlers
+// reject larger bodies anyway
+const maxFingerprintBody = 1 << 20
+
+// StoredResponse is a response kept for replay
+type StoredResponse struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// IdempotencyRecord is the state of a key. Response is nil while the first
+// request with the key is still running.
+type IdempotencyRecord struct {
+	// Fingerprint identifies the request, so a reused key can be detected
+	Fingerprint string
+	Response    *StoredResponse
+}
+
+// IdempotencyStore holds the responses of requests by key. Implementations
+// must be safe for concurrent use.
+type IdempotencyStore interface {
+	// Start claims key for a request with fingerprint for ttl. If the key is
+	// already claimed it returns the existing record and false instead.
+	Start(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, bool, error)
+	// Finish stores the response of the request that claimed key
+	Finish(ctx context.Context, key string, resp *StoredResponse, ttl time.Duration) error
+	// Release frees a claimed key without storing a response, so the
+	// request can be retried
+	Release(ctx context.Context, key string) error
+}
+
+// idempotencyEntry is a record of MemoryIdempotencyStore
+type idempotencyEntry struct {
+	record  IdempotencyRecord
+	expires time.Time
+}
+
+// MemoryIdempotencyStore keeps idempotency records in process memory.
+// Expired records are dropped on a sweep at most once a minute.
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	entries   map[string]*idempotencyEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryIdempotencyStore creates an empty in-memory store
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: make(map[string]*idempotencyEntry), now: time.Now}
+}
+
+// Start implements IdempotencyStore
+func (s *MemoryIdempotencyStore) Start(_ context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		for k, e := range s.entries {
+			if !now.Before(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+		record := e.record
+		return &record, false, nil
+	}
+	s.entries[key] = &idempotencyEntry{
+		record:  IdempotencyRecord{Fingerprint: fingerprint},
+		expires: now.Add(ttl),
+	}
+	return nil, true, nil
+}
+
+// Finish implements IdempotencyStore
+func (s *MemoryIdempotencyStore) Finish(_ context.Context, key string, resp *StoredResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		e.record.Response = resp
+		e.expires = s.now().Add(ttl)
+	}
+	return nil
+}
+
+// Release implements IdempotencyStore
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// Idempotency replays the stored response when a client retries a request
+// with the same Idempotency-Key header. Keys are scoped to the client, as in
+// RateLimit, and to name, so it must run after auth. A key reused with a
+// different body, or sent again while its first request is still running,
+// gets 409. Server errors and 429s are not stored, so those requests can be
+// retried with the same key. Requests without the header pass through.
+func Idempotency(store IdempotencyStore, name string, ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > MaxIdempotencyKeyLength {
+				writeProblem(w, r, http.StatusBadRequest, "invalid_idempotency_key",
+					"Idempotency-Key must be at most 255 characters")
+				return
+			}
+
+			fingerprint, err := fingerprintRequest(r)
+			if err != nil {
+				writeProblem(w, r, http.StatusBadRequest, "bad_request", "invalid request body: could not be read")
+				return
+			}
+
+			ctx := r.Context()
+			key = name + "|" + clientKey(r) + "|" + key
+			record, started, err := store.Start(ctx, key, fingerprint, ttl)
+			if err != nil {
+				slog.WarnContext(ctx, "idempotency store failed", "route", name, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !started {
+				switch {
+				case record.Fingerprint != fingerprint:
+					writeProblem(w, r, http.StatusConflict, "idempotency_key_reused",
+						"Idempotency-Key was already used for a different request")
+				case record.Response == nil:
+					writeProblem(w, r, http.StatusConflict, "idempotency_key_in_progress",
+						"a request with this Idempotency-Key is still being processed")
+				default:
+					resp := record.Response
+					w.Header().Set("Content-Type", resp.ContentType)
+					w.Header().Set(IdempotentReplayedHeader, "true")
+					w.WriteHeader(resp.Status)
+					w.Write(resp.Body)
+				}
+				return
+			}
+
+			rec := &recordingWriter{responseWriter: newResponseWriter(w)}
+			finished := false
+			defer func() {
+				// Free the key if the handler panicked or failed
+				if !finished {
+					if err := store.Release(context.WithoutCancel(ctx), key); err != nil {
+						slog.WarnContext(ctx, "idempotency store failed", "route", name, "error", err)
+					}
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+
+			if status := rec.statusCode; status >= 500 || status == http.StatusTooManyRequests {
+				return
+			}
+			resp := &StoredResponse{
+				Status:      rec.statusCode,
+				ContentType: w.Header().Get("Content-Type"),
+				Body:        rec.body.Bytes(),
+			}
+			if err := store.Finish(context.WithoutCancel(ctx), key, resp, ttl); err != nil {
+				slog.WarnContext(ctx, "idempotency store failed", "route", name, "error", err)
+				return
+			}
+			finished = true
+		})
+	}
+}
+
+// fingerprintRequest hashes the method, path and body of r, and restores the
+// body for the handler
+func fingerprintRequest(r *http.Request) (string, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxFingerprintBody))
+	if err != nil {
+		return "", err
+	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// recordingWriter keeps a copy of the body written through it
+type recordingWriter struct {
+	*responseWriter
+	body bytes.Buffer
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	rw.body.Write(b)
+	return rw.responseWriter.Write(b)
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotency(t *testing.T) {
+	calls := 0
+	status := http.StatusCreated
+	handler := Idempotency(NewMemoryIdempotencyStore(), "POST /posts", time.Hour)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			w.Write(body)
+		}))
+
+	post := func(userID, key, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/posts", strings.NewReader(body))
+		r = r.WithContext(context.WithValue(r.Context(), UserIDKey, userID))
+		if key != "" {
+			r.Header.Set(IdempotencyKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec
+	}
+
+	if rec := post("u-alice", "k1", `{"text":"a"}`); rec.Code != http.StatusCreated || rec.Body.String() != `{"text":"a"}` {
+		t.Fatalf("first request: %d %s", rec.Code, rec.Body)
+	}
+
+	rec := post("u-alice", "k1", `{"text":"a"}`)
+	if rec.Code != http.StatusCreated || rec.Body.String() != `{"text":"a"}` || rec.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Fatalf("retry: %d %s %v", rec.Code, rec.Body, rec.Header())
+	}
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+
+	if rec := post("u-alice", "k1", `{"text":"b"}`); rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "idempotency_key_reused") {
+		t.Errorf("reused key: %d %s", rec.Code, rec.Body)
+	}
+
+	// Keys are per user, and requests without one always run
+	post("u-bob", "k1", `{"text":"b"}`)
+	post("u-alice", "", `{"text":"a"}`)
+	if calls != 3 {
+		t.Errorf("handler ran %d times, want 3", calls)
+	}
+
+	// Server errors are not stored, so the retry runs again
+	status = http.StatusInternalServerError
+	post("u-alice", "k2", `{}`)
+	status = http.StatusCreated
+	if rec := post("u-alice", "k2", `{}`); rec.Code != http.StatusCreated || calls != 5 {
+		t.Errorf("retry after a server error: %d after %d calls", rec.Code, calls)
+	}
+
+	if rec := post("u-alice", strings.Repeat("k", MaxIdempotencyKeyLength+1), `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("long key: %d", rec.Code)
+	}
+}
+
+func TestIdempotencyAnonymousByIP(t *testing.T) {
+	calls := 0
+	handler := FakeAuth(DefaultFakeAuthConfig())(
+		Idempotency(NewMemoryIdempotencyStore(), "POST /posts", time.Hour)(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(http.StatusCreated)
+			})))
+
+	post := func(addr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/posts", strings.NewReader(`{"text":"a"}`))
+		r.RemoteAddr = addr
+		r.Header.Set(IdempotencyKeyHeader, "k1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec
+	}
+
+	// Anonymous clients choosing the same key do not see each other's
+	// responses, while a retry from the same IP is replayed
+	post("10.0.0.1:1234")
+	if rec := post("10.0.0.2:1234"); rec.Header().Get(IdempotentReplayedHeader) != "" || calls != 2 {
+		t.Errorf("other client replayed: %v after %d calls", rec.Header(), calls)
+	}
+	if rec := post("10.0.0.1:5678"); rec.Header().Get(IdempotentReplayedHeader) != "true" || calls != 2 {
+		t.Errorf("retry not replayed: %v after %d calls", rec.Header(), calls)
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	fingerprint, _ := fingerprintRequest(httptest.NewRequest("POST", "/posts", strings.NewReader(`{}`)))
+	if _, started, _ := store.Start(context.Background(), "POST /posts|ip:192.0.2.1|k1", fingerprint, time.Hour); !started {
+		t.Fatal("could not claim the key")
+	}
+
+	handler := Idempotency(store, "POST /posts", time.Hour)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Error("handler ran while the key was claimed")
+	}))
+	r := httptest.NewRequest("POST", "/posts", strings.NewReader(`{}`))
+	r.Header.Set(IdempotencyKeyHeader, "k1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "idempotency_key_in_progress") {
+		t.Errorf("got %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestMemoryIdempotencyStoreExpiry(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	store := NewMemoryIdempotencyStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	store.Start(ctx, "k", "f", time.Hour)
+	store.Finish(ctx, "k", &StoredResponse{Status: http.StatusCreated}, time.Hour)
+	if rec, started, _ := store.Start(ctx, "k", "f", time.Hour); started || rec.Response == nil {
+		t.Fatal("finished key was not kept")
+	}
+
+	now = now.Add(time.Hour)
+	if _, started, _ := store.Start(ctx, "k", "f", time.Hour); !started {
+		t.Error("expired key was not released")
+	}
+}
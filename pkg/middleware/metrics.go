@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/askme/api/pkg/metrics"
+)
+
+// HTTPMetrics records request counts, latencies, response sizes and
+// in-flight requests per route
+type HTTPMetrics struct {
+	requests *metrics.Counter
+	duration *metrics.Histogram
+	size     *metrics.Histogram
+	inFlight *metrics.Gauge
+}
+
+// NewHTTPMetrics registers the HTTP metrics on reg. Create it once per
+// registry.
+func NewHTTPMetrics(reg *metrics.Registry) *HTTPMetrics {
+	return &HTTPMetrics{
+		requests: reg.NewCounter("http_requests_total", "HTTP requests by route and status code.", "route", "status"),
+		duration: reg.NewHistogram("http_request_duration_seconds", "HTTP request latency by route.", metrics.DefBuckets, "route"),
+		size:     reg.NewHistogram("http_response_size_bytes", "HTTP response body size by route.", metrics.SizeBuckets, "route"),
+		inFlight: reg.NewGauge("http_requests_in_flight", "HTTP requests being served by route.", "route"),
+	}
+}
+
+// Route records the requests of the wrapped handler under route, which
+// should be its mux pattern rather than the raw path, so that IDs in paths
+// do not create a series each
+func (m *HTTPMetrics) Route(route string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			m.inFlight.Inc(route)
+			defer m.inFlight.Dec(route)
+
+			rw := newResponseWriter(w)
+			next.ServeHTTP(rw, r)
+
+			m.requests.Inc(route, strconv.Itoa(rw.statusCode))
+			m.duration.Observe(time.Since(start).Seconds(), route)
+			m.size.Observe(float64(rw.written), route)
+		})
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
 	"log/slog"
 	"net/http"
 	"runtime/debug"
 	"time"
+
+	"github.com/askme/api/pkg/slogutil"
 )
 
 // contextKey is a custom type for context keys to avoid collisions.
@@ -19,6 +22,9 @@ const (
 	RequestIDKey contextKey = "requestID"
 	// UserIDKey is the context key for the authenticated user ID.
 	UserIDKey contextKey = "userID"
+	// AnonymousKey marks requests whose user ID is FakeAuth's default
+	// rather than one the client sent.
+	AnonymousKey contextKey = "anonymous"
 )
 
 // GetRequestID retrieves the request ID from the context.
@@ -37,6 +43,13 @@ func GetUserID(ctx context.Context) string {
 	return ""
 }
 
+// IsAnonymous reports whether the request's user ID is the default one,
+// assumed because the client sent none.
+func IsAnonymous(ctx context.Context) bool {
+	anonymous, _ := ctx.Value(AnonymousKey).(bool)
+	return anonymous
+}
+
 // responseWriter wraps http.ResponseWriter to capture the status code.
 type responseWriter struct {
 	http.ResponseWriter
@@ -59,6 +72,12 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush event streams
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // Chain chains multiple middleware functions together.
 func Chain(handler http.Handler, middlewares ...func(http.Handler) http.Handler) http.Handler {
 	// Apply in reverse order so first middleware in the list is outermost
@@ -69,20 +88,18 @@ func Chain(handler http.Handler, middlewares ...func(http.Handler) http.Handler)
 }
 
 // Logger logs every HTTP request with structured JSON output using slog.
+// The request's log fields (request ID, trace ID, user and route) are added
+// by a slogutil.ContextHandler, including those set by inner middleware.
 func Logger(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		rw := newResponseWriter(w)
 
-		// Get request ID from context (set by RequestID middleware)
-		requestID := GetRequestID(r.Context())
-
 		next.ServeHTTP(rw, r)
 
 		duration := time.Since(start)
 
-		slog.Info("http request",
-			"requestId", requestID,
+		slog.InfoContext(r.Context(), "http request",
 			"method", r.Method,
 			"path", r.URL.Path,
 			"query", r.URL.RawQuery,
@@ -108,8 +125,9 @@ func RequestID(next http.Handler) http.Handler {
 		// Add to response header
 		w.Header().Set("X-Request-ID", requestID)
 
-		// Add to context
+		// Add to context, and start the request's log fields with it
 		ctx := context.WithValue(r.Context(), RequestIDKey, requestID)
+		ctx = slogutil.WithFields(ctx, slog.String("requestId", requestID))
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -153,17 +171,22 @@ func FakeAuth(config FakeAuthConfig) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			userID := r.Header.Get(config.HeaderName)
+			ctx := r.Context()
 
 			if userID == "" {
 				if config.Required {
-					http.Error(w, `{"success":false,"error":"unauthorized"}`, http.StatusUnauthorized)
+					writeProblem(w, r, http.StatusUnauthorized, "unauthorized", config.HeaderName+" header is required")
 					return
 				}
 				userID = config.DefaultUserID
+				// Clients without a user must not share the default
+				// user's rate limits and idempotency keys
+				ctx = context.WithValue(ctx, AnonymousKey, true)
 			}
 
-			// Add user ID to context
-			ctx := context.WithValue(r.Context(), UserIDKey, userID)
+			// Add user ID to context and to the request's log fields
+			ctx = context.WithValue(ctx, UserIDKey, userID)
+			slogutil.AddFields(ctx, slog.String("userId", userID))
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -185,7 +208,7 @@ func Recovery(next http.Handler) http.Handler {
 					"path", r.URL.Path,
 				)
 
-				http.Error(w, `{"success":false,"error":"internal server error"}`, http.StatusInternalServerError)
+				writeProblem(w, r, http.StatusInternalServerError, "internal", "internal server error")
 			}
 		}()
 
@@ -193,6 +216,23 @@ func Recovery(next http.Handler) http.Handler {
 	})
 }
 
+// writeProblem writes the same RFC 9457 body as httputil.WriteProblem, which
+// this package cannot import
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	body, _ := json.Marshal(map[string]any{
+		"success":   false,
+		"type":      "urn:askme:problem:" + code,
+		"title":     http.StatusText(status),
+		"status":    status,
+		"detail":    detail,
+		"code":      code,
+		"requestId": GetRequestID(r.Context()),
+	})
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	w.Write(append(body, '\n'))
+}
+
 // CORSConfig holds CORS configuration options.
 type CORSConfig struct {
 	AllowedOrigins   []string
@@ -208,8 +248,8 @@ func DefaultCORSConfig() CORSConfig {
 	return CORSConfig{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Request-ID"},
-		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Request-ID", "Idempotency-Key", "traceparent"},
+		ExposedHeaders:   []string{"X-Request-ID", "Idempotent-Replayed", "Retry-After", "RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"},
 		AllowCredentials: false,
 		MaxAge:           86400, // 24 hours
 	}
@@ -300,14 +340,12 @@ func Timeout(timeout time.Duration) func(http.Handler) http.Handler {
 			case <-done:
 				return
 			case <-ctx.Done():
-				requestID := GetRequestID(r.Context())
-				slog.Warn("request timeout",
-					"requestId", requestID,
+				slog.WarnContext(r.Context(), "request timeout",
 					"method", r.Method,
 					"path", r.URL.Path,
 					"timeout", timeout.String(),
 				)
-				http.Error(w, `{"success":false,"error":"request timeout"}`, http.StatusGatewayTimeout)
+				writeProblem(w, r, http.StatusGatewayTimeout, "timeout", "request timed out after "+timeout.String())
 			}
 		})
 	}
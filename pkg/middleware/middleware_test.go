@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMiddlewareErrorsAreProblems(t *testing.T) {
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	panics := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		status  int
+		code    string
+	}{
+		{"auth required", FakeAuth(FakeAuthConfig{Required: true})(http.NotFoundHandler()), http.StatusUnauthorized, "unauthorized"},
+		{"timeout", Timeout(10 * time.Millisecond)(slow), http.StatusGatewayTimeout, "timeout"},
+		{"panic", Recovery(panics), http.StatusInternalServerError, "internal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			RequestID(tt.handler).ServeHTTP(rec, httptest.NewRequest("GET", "/posts", nil))
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			var problem struct {
+				Code      string `json:"code"`
+				Status    int    `json:"status"`
+				RequestID string `json:"requestId"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("body %s: %v", rec.Body, err)
+			}
+			if problem.Code != tt.code || problem.Status != tt.status || problem.RequestID != rec.Header().Get("X-Request-ID") {
+				t.Errorf("problem = %+v, request ID %s", problem, rec.Header().Get("X-Request-ID"))
+			}
+		})
+	}
+}
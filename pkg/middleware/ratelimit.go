@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Limit allows Requests per Window per client. Buckets refill continuously,
+// so a client may burst up to Requests and then gets one request every
+// Window/Requests.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// rate returns the refill rate in tokens per second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Window.Seconds()
+}
+
+// RateLimitResult is the state of a bucket after a Take
+type RateLimitResult struct {
+	Allowed bool
+	// Remaining is the number of whole tokens left in the bucket
+	Remaining int
+	// RetryAfter is the wait until the next token, set when not allowed
+	RetryAfter time.Duration
+	// Reset is the wait until the bucket is full again
+	Reset time.Duration
+}
+
+// RateLimitStore holds token buckets. Implementations must be safe for
+// concurrent use; a shared store (e.g. Redis) lets several instances enforce
+// one limit.
+type RateLimitStore interface {
+	// Take removes a token from the bucket at key, creating it full if it
+	// does not exist, and reports whether one was available
+	Take(ctx context.Context, key string, limit Limit) (RateLimitResult, error)
+}
+
+// bucket is a token bucket of MemoryRateLimitStore
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// full is when the bucket refills completely and can be dropped
+	full time.Time
+}
+
+// MemoryRateLimitStore keeps token buckets in process memory. Buckets are
+// dropped once they have refilled, so idle clients cost nothing.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// sweepInterval is how often full buckets are dropped
+const sweepInterval = time.Minute
+
+// NewMemoryRateLimitStore creates an empty in-memory store
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// Take implements RateLimitStore
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, limit Limit) (RateLimitResult, error) {
+	if limit.Requests <= 0 || limit.Window <= 0 {
+		return RateLimitResult{}, fmt.Errorf("invalid limit %d per %s", limit.Requests, limit.Window)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		for k, b := range s.buckets {
+			if !now.Before(b.full) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	capacity, rate := float64(limit.Requests), limit.rate()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	var res RateLimitResult
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = seconds((capacity - b.tokens) / rate)
+	b.full = now.Add(res.Reset)
+	return res, nil
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// RateLimit limits each client to limit requests on the wrapped handler.
+// Clients are the authenticated user, or the remote IP without one, so it
+// must run after auth. name scopes the buckets, typically the route pattern.
+// Responses carry RateLimit-* headers; over-limit requests get 429 with
+// Retry-After. If the store fails the request is let through.
+func RateLimit(store RateLimitStore, name string, limit Limit) func(http.Handler) http.Handler {
+	policy := fmt.Sprintf("%d;w=%d", limit.Requests, int(math.Ceil(limit.Window.Seconds())))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res, err := store.Take(r.Context(), name+"|"+clientKey(r), limit)
+			if err != nil {
+				slog.WarnContext(r.Context(), "rate limit store failed",
+					"limit", name,
+					"error", err,
+				)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Policy", policy)
+			h.Set("RateLimit-Limit", intToString(limit.Requests))
+			h.Set("RateLimit-Remaining", intToString(res.Remaining))
+			h.Set("RateLimit-Reset", intToString(ceilSeconds(res.Reset)))
+
+			if !res.Allowed {
+				retryAfter := ceilSeconds(res.RetryAfter)
+				h.Set("Retry-After", intToString(retryAfter))
+				writeProblem(w, r, http.StatusTooManyRequests, "rate_limited",
+					fmt.Sprintf("rate limit of %d requests per %s exceeded, retry in %ds", limit.Requests, limit.Window, retryAfter))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientKey identifies the client of a request for rate limiting: its user,
+// or its IP when it sent no user and FakeAuth assumed the default one
+func clientKey(r *http.Request) string {
+	if userID := GetUserID(r.Context()); userID != "" && !IsAnonymous(r.Context()) {
+		return "user:" + userID
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// ceilSeconds rounds d up to whole seconds, as headers carry
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	store := NewMemoryRateLimitStore()
+	store.now = func() time.Time { return now }
+	limit := Limit{Requests: 2, Window: 10 * time.Second}
+	ctx := context.Background()
+
+	take := func() RateLimitResult {
+		t.Helper()
+		res, err := store.Take(ctx, "k", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	if res := take(); !res.Allowed || res.Remaining != 1 || res.Reset != 5*time.Second {
+		t.Fatalf("first take = %+v", res)
+	}
+	if res := take(); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("second take = %+v", res)
+	}
+	if res := take(); res.Allowed || res.RetryAfter != 5*time.Second {
+		t.Fatalf("over-limit take = %+v, want a 5s retry", res)
+	}
+
+	// One token refills every 5s
+	now = now.Add(5 * time.Second)
+	if res := take(); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("take after refill = %+v", res)
+	}
+
+	// Other keys have their own bucket
+	if res, _ := store.Take(ctx, "other", limit); !res.Allowed {
+		t.Fatal("separate key was limited")
+	}
+
+	// Full buckets are dropped by the next sweep
+	now = now.Add(time.Hour)
+	take()
+	if len(store.buckets) != 1 {
+		t.Errorf("%d buckets after sweep, want 1", len(store.buckets))
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	handler := RateLimit(store, "POST /posts", Limit{Requests: 1, Window: time.Minute})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	request := func(userID, addr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/posts", nil)
+		r.RemoteAddr = addr
+		if userID != "" {
+			r = r.WithContext(context.WithValue(r.Context(), UserIDKey, userID))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec
+	}
+
+	rec := request("u-alice", "10.0.0.1:1234")
+	if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Remaining") != "0" || rec.Header().Get("RateLimit-Policy") != "1;w=60" {
+		t.Fatalf("first request: %d %v", rec.Code, rec.Header())
+	}
+
+	rec = request("u-alice", "10.0.0.2:1234")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "60" {
+		t.Fatalf("second request: %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	var problem struct {
+		Code   string `json:"code"`
+		Status int    `json:"status"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil || problem.Code != "rate_limited" || problem.Status != 429 {
+		t.Errorf("body = %s", rec.Body)
+	}
+
+	// Anonymous clients are keyed by IP, apart from users on the same one
+	if rec := request("", "10.0.0.1:1234"); rec.Code != http.StatusOK {
+		t.Errorf("anonymous request: %d", rec.Code)
+	}
+	if rec := request("", "10.0.0.1:5678"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("second anonymous request from the same IP: %d", rec.Code)
+	}
+}
+
+func TestRateLimitAnonymousByIP(t *testing.T) {
+	// FakeAuth assumes the default user for clients without X-User-ID; they
+	// must still be limited per IP rather than share that user's bucket
+	handler := FakeAuth(DefaultFakeAuthConfig())(
+		RateLimit(NewMemoryRateLimitStore(), "POST /posts", Limit{Requests: 1, Window: time.Minute})(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	request := func(userID, addr string) int {
+		r := httptest.NewRequest("POST", "/posts", nil)
+		r.RemoteAddr = addr
+		if userID != "" {
+			r.Header.Set("X-User-ID", userID)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	if code := request("", "10.0.0.1:1234"); code != http.StatusOK {
+		t.Fatalf("anonymous client: %d", code)
+	}
+	if code := request("", "10.0.0.2:1234"); code != http.StatusOK {
+		t.Errorf("anonymous client on another IP: %d", code)
+	}
+	if code := request("u-johndoe", "10.0.0.1:1234"); code != http.StatusOK {
+		t.Errorf("default user sending the header: %d", code)
+	}
+	if code := request("", "10.0.0.1:5678"); code != http.StatusTooManyRequests {
+		t.Errorf("anonymous client again from the first IP: %d", code)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, Limit) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("store down")
+}
+
+func TestRateLimitFailsOpen(t *testing.T) {
+	handler := RateLimit(failingStore{}, "GET /", Limit{Requests: 1, Window: time.Second})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want the request let through", rec.Code)
+	}
+}
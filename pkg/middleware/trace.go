@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/askme/api/pkg/slogutil"
+	"github.com/askme/api/pkg/trace"
+)
+
+// TraceparentHeader is the W3C trace context request header
+const TraceparentHeader = "traceparent"
+
+// Trace starts a server span per request, continuing the caller's trace
+// when the request has a valid traceparent header. The trace ID is added to
+// the request's log fields, so it must run after RequestID. Spans are named
+// by method until Route names them by route.
+func Trace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if sc, err := trace.ParseTraceparent(r.Header.Get(TraceparentHeader)); err == nil {
+			ctx = trace.ContextWithRemote(ctx, sc)
+		}
+
+		ctx, span := trace.Start(ctx, "HTTP "+r.Method,
+			trace.WithKind(trace.KindServer),
+			trace.WithAttributes(
+				slog.String("http.request.method", r.Method),
+				slog.String("url.path", r.URL.Path),
+				slog.String("askme.request_id", GetRequestID(ctx)),
+			),
+		)
+		if traceID := trace.TraceIDFromContext(ctx); traceID != "" {
+			slogutil.AddFields(ctx, slog.String("traceId", traceID))
+		}
+
+		rw := newResponseWriter(w)
+		defer func() {
+			// Record a panic on the span before Recovery handles it
+			if err := recover(); err != nil {
+				span.RecordError(fmt.Errorf("panic: %v", err))
+				span.End()
+				panic(err)
+			}
+		}()
+
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		span.SetAttributes(slog.Int("http.response.status_code", rw.statusCode))
+		if rw.statusCode >= 500 {
+			span.RecordError(fmt.Errorf("HTTP %d", rw.statusCode))
+		}
+		span.End()
+	})
+}
+
+// Route names the request span after route, its mux pattern, and adds it
+// to the request's log fields
+func Route(route string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			slogutil.AddFields(r.Context(), slog.String("route", route))
+			span := trace.SpanFromContext(r.Context())
+			span.SetName(route)
+			span.SetAttributes(slog.String("http.route", route))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package slogutil
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+type loggerKey struct{}
+
+type fieldsKey struct{}
+
+// fields holds the log fields of a request. Middleware adds to it in place
+// as the request is authenticated and routed, so handlers that wrap those
+// layers, such as the access logger, log the same fields.
+type fields struct {
+	mu    sync.RWMutex
+	attrs []slog.Attr
+}
+
+// WithFields returns a context with a new set of log fields holding the
+// fields already in ctx followed by attrs. Call it once per request, e.g.
+// with the request ID, and use AddFields for fields known later.
+func WithFields(ctx context.Context, attrs ...slog.Attr) context.Context {
+	f := &fields{attrs: append(Fields(ctx), attrs...)}
+	return context.WithValue(ctx, fieldsKey{}, f)
+}
+
+// AddFields adds attrs to the log fields of ctx, visible to every context
+// sharing them. It does nothing if ctx has no fields.
+func AddFields(ctx context.Context, attrs ...slog.Attr) {
+	f, ok := ctx.Value(fieldsKey{}).(*fields)
+	if !ok {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attrs = append(f.attrs, attrs...)
+}
+
+// Fields returns a copy of the log fields of ctx.
+func Fields(ctx context.Context) []slog.Attr {
+	f, ok := ctx.Value(fieldsKey{}).(*fields)
+	if !ok {
+		return nil
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return append([]slog.Attr(nil), f.attrs...)
+}
+
+// WithLogger returns a context carrying logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx. Without one it returns
+// slog.Default(), bound to ctx when ctx has log fields so that records
+// logged without a context (Info rather than InfoContext) still reach a
+// ContextHandler with the request's fields.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	if _, ok := ctx.Value(fieldsKey{}).(*fields); !ok {
+		return slog.Default()
+	}
+	return slog.New(&boundHandler{ctx: ctx})
+}
+
+// boundHandler passes records to a handler with the context it was bound
+// to, unless they are logged with a context carrying its own fields. A nil
+// handler means the default logger's, looked up on every record so that
+// later slog.SetDefault calls apply.
+type boundHandler struct {
+	ctx     context.Context
+	handler slog.Handler
+}
+
+func (h *boundHandler) next() slog.Handler {
+	if h.handler != nil {
+		return h.handler
+	}
+	return slog.Default().Handler()
+}
+
+func (h *boundHandler) context(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(fieldsKey{}).(*fields); ok {
+		return ctx
+	}
+	return h.ctx
+}
+
+func (h *boundHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next().Enabled(h.context(ctx), level)
+}
+
+func (h *boundHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next().Handle(h.context(ctx), r)
+}
+
+func (h *boundHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &boundHandler{ctx: h.ctx, handler: h.next().WithAttrs(attrs)}
+}
+
+func (h *boundHandler) WithGroup(name string) slog.Handler {
+	return &boundHandler{ctx: h.ctx, handler: h.next().WithGroup(name)}
+}
@@ -0,0 +1,89 @@
+package slogutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("%v: %s", err, buf)
+	}
+	buf.Reset()
+	return entry
+}
+
+func TestFields(t *testing.T) {
+	ctx := WithFields(context.Background(), slog.String("requestId", "r1"))
+	inner := context.WithValue(ctx, struct{}{}, 1)
+	AddFields(inner, slog.String("userId", "u1"))
+
+	// Fields added through a derived context are seen by the parent
+	if got := Fields(ctx); len(got) != 2 || got[1].Value.String() != "u1" {
+		t.Errorf("Fields = %v", got)
+	}
+
+	// A new scope copies the fields without sharing later additions
+	child := WithFields(ctx, slog.String("job", "j1"))
+	AddFields(ctx, slog.String("route", "GET /x"))
+	if got := Fields(child); len(got) != 3 {
+		t.Errorf("child fields = %v", got)
+	}
+
+	AddFields(context.Background(), slog.String("ignored", "x"))
+	if Fields(context.Background()) != nil {
+		t.Error("background context has fields")
+	}
+}
+
+func TestContextHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewContextHandler(slog.NewJSONHandler(&buf, nil)))
+	ctx := WithFields(context.Background(), slog.String("requestId", "r1"))
+
+	logger.InfoContext(ctx, "plain", "n", 1)
+	if entry := decode(t, &buf); entry["requestId"] != "r1" || entry["n"] != 1.0 {
+		t.Errorf("plain = %v", entry)
+	}
+
+	// Fields stay at the top level of records logged within a group
+	logger.With("service", "post").WithGroup("vote").InfoContext(ctx, "grouped", "option", "o1")
+	entry := decode(t, &buf)
+	group, _ := entry["vote"].(map[string]any)
+	if entry["requestId"] != "r1" || entry["service"] != "post" || group["option"] != "o1" {
+		t.Errorf("grouped = %v", entry)
+	}
+
+	logger.Info("no context")
+	if entry := decode(t, &buf); entry["requestId"] != nil {
+		t.Errorf("no context = %v", entry)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(NewContextHandler(slog.NewJSONHandler(&buf, nil))))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	if FromContext(context.Background()) != slog.Default() {
+		t.Error("FromContext without fields is not the default logger")
+	}
+
+	// The logger is bound to ctx, so records logged without one keep its fields
+	ctx := WithFields(context.Background(), slog.String("requestId", "r1"))
+	FromContext(ctx).With("layer", "repo").Info("bound")
+	if entry := decode(t, &buf); entry["requestId"] != "r1" || entry["layer"] != "repo" {
+		t.Errorf("bound = %v", entry)
+	}
+
+	stored := slog.New(slog.NewJSONHandler(&buf, nil))
+	if FromContext(WithLogger(ctx, stored)) != stored {
+		t.Error("FromContext ignored the stored logger")
+	}
+}
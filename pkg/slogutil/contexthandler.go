@@ -0,0 +1,69 @@
+package slogutil
+
+import (
+	"context"
+	"log/slog"
+)
+
+// ContextHandler is a slog.Handler that adds the log fields of each record's
+// context (see WithFields) to the record before passing it on. Wrapping the
+// output handler with it lets any layer log with the request context and
+// have its records correlated by request ID, user, route and trace.
+type ContextHandler struct {
+	base    slog.Handler
+	handler slog.Handler
+	// groups replays WithAttrs and WithGroup calls on base, so fields can be
+	// added at the top level of records logged within a group
+	groups []func(slog.Handler) slog.Handler
+}
+
+// NewContextHandler creates a handler adding context fields to the records
+// it passes to h.
+func NewContextHandler(h slog.Handler) *ContextHandler {
+	return &ContextHandler{base: h, handler: h}
+}
+
+func (h *ContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := Fields(ctx)
+	if len(attrs) == 0 {
+		return h.handler.Handle(ctx, r)
+	}
+	if h.groups == nil {
+		r = r.Clone()
+		r.AddAttrs(attrs...)
+		return h.handler.Handle(ctx, r)
+	}
+
+	handler := h.base.WithAttrs(attrs)
+	for _, apply := range h.groups {
+		handler = apply(handler)
+	}
+	return handler.Handle(ctx, r)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	if h.groups == nil {
+		return &ContextHandler{base: h.base.WithAttrs(attrs), handler: h.handler.WithAttrs(attrs)}
+	}
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+func (h *ContextHandler) with(apply func(slog.Handler) slog.Handler) *ContextHandler {
+	groups := make([]func(slog.Handler) slog.Handler, len(h.groups), len(h.groups)+1)
+	copy(groups, h.groups)
+	return &ContextHandler{base: h.base, handler: apply(h.handler), groups: append(groups, apply)}
+}
@@ -2,142 +2,100 @@
 package slogutil
 
 import (
-	"context"
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
-	"sync"
+	"strings"
 )
 
-// IndentedJSONHandler is a slog.Handler that outputs indented JSON.
-type IndentedJSONHandler struct {
-	opts   slog.HandlerOptions
-	w      io.Writer
-	mu     *sync.Mutex
-	attrs  []slog.Attr
-	groups []string
-}
-
-// NewIndentedJSONHandler creates a new handler that outputs indented JSON.
-func NewIndentedJSONHandler(w io.Writer, opts *slog.HandlerOptions) *IndentedJSONHandler {
-	if opts == nil {
-		opts = &slog.HandlerOptions{}
-	}
-	return &IndentedJSONHandler{
-		opts: *opts,
-		w:    w,
-		mu:   &sync.Mutex{},
-	}
-}
+// Output formats accepted by NewHandler
+const (
+	// FormatJSON writes one JSON object per line, fields in logged order
+	FormatJSON = "json"
+	// FormatPretty writes indented JSON, for reading logs in a terminal
+	FormatPretty = "pretty"
+	// FormatText writes logfmt-style key=value lines
+	FormatText = "text"
+)
 
-func (h *IndentedJSONHandler) Enabled(_ context.Context, level slog.Level) bool {
-	minLevel := slog.LevelInfo
-	if h.opts.Level != nil {
-		minLevel = h.opts.Level.Level()
-	}
-	return level >= minLevel
+// Options configures the handler built by NewHandler
+type Options struct {
+	// Format is FormatJSON, FormatPretty or FormatText (default FormatJSON)
+	Format string
+	// Level is the minimum level logged; a *slog.LevelVar allows changing
+	// it at runtime (default slog.LevelInfo)
+	Level slog.Leveler
+	// RedactKeys are attribute keys whose values are replaced, matched
+	// case-insensitively at any group depth
+	RedactKeys []string
+	// Sampling limits repeated records at slog.LevelInfo and below; the zero
+	// value keeps every record
+	Sampling SamplingOptions
 }
 
-func (h *IndentedJSONHandler) Handle(_ context.Context, r slog.Record) error {
-	// Build the log entry map
-	entry := make(map[string]any)
-
-	entry["time"] = r.Time.Format("2006-01-02T15:04:05.000Z07:00")
-	entry["level"] = r.Level.String()
-	entry["msg"] = r.Message
-
-	if h.opts.AddSource && r.PC != 0 {
-		// Add source info if requested
-		fs := r.Source()
-		if fs != nil {
-			entry["source"] = map[string]any{
-				"function": fs.Function,
-				"file":     fs.File,
-				"line":     fs.Line,
-			}
-		}
+// NewHandler creates a handler writing records to w in opts.Format, with
+// redaction and sampling applied.
+func NewHandler(w io.Writer, opts Options) (slog.Handler, error) {
+	hopts := &slog.HandlerOptions{Level: opts.Level}
+	if len(opts.RedactKeys) > 0 {
+		hopts.ReplaceAttr = Redact(opts.RedactKeys...)
 	}
 
-	// Add handler-level attrs
-	current := entry
-	for _, g := range h.groups {
-		nested := make(map[string]any)
-		current[g] = nested
-		current = nested
-	}
-	for _, a := range h.attrs {
-		addAttr(current, a, h.opts.ReplaceAttr)
+	var h slog.Handler
+	switch opts.Format {
+	case FormatJSON, "":
+		h = slog.NewJSONHandler(w, hopts)
+	case FormatPretty:
+		h = slog.NewJSONHandler(&indentWriter{w: w}, hopts)
+	case FormatText:
+		h = slog.NewTextHandler(w, hopts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", opts.Format)
 	}
 
-	// Add record attrs
-	r.Attrs(func(a slog.Attr) bool {
-		addAttr(current, a, h.opts.ReplaceAttr)
-		return true
-	})
-
-	// Marshal with indentation
-	data, err := json.MarshalIndent(entry, "", "  ")
-	if err != nil {
-		return err
+	if opts.Sampling.First > 0 {
+		h = NewSamplingHandler(h, opts.Sampling)
 	}
-
-	h.mu.Lock()
-	defer h.mu.Unlock()
-	_, err = h.w.Write(append(data, '\n'))
-	return err
+	return h, nil
 }
 
-func (h *IndentedJSONHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	newAttrs := make([]slog.Attr, len(h.attrs)+len(attrs))
-	copy(newAttrs, h.attrs)
-	copy(newAttrs[len(h.attrs):], attrs)
-	return &IndentedJSONHandler{
-		opts:   h.opts,
-		w:      h.w,
-		mu:     h.mu,
-		attrs:  newAttrs,
-		groups: h.groups,
-	}
+// indentWriter indents the single-line JSON records written by a
+// slog.JSONHandler, which makes one Write per record under its own lock
+type indentWriter struct {
+	w io.Writer
 }
 
-func (h *IndentedJSONHandler) WithGroup(name string) slog.Handler {
-	if name == "" {
-		return h
+func (iw *indentWriter) Write(p []byte) (int, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, p, "", "  "); err != nil {
+		return iw.w.Write(p)
 	}
-	newGroups := make([]string, len(h.groups)+1)
-	copy(newGroups, h.groups)
-	newGroups[len(h.groups)] = name
-	return &IndentedJSONHandler{
-		opts:   h.opts,
-		w:      h.w,
-		mu:     h.mu,
-		attrs:  h.attrs,
-		groups: newGroups,
+	if _, err := iw.w.Write(buf.Bytes()); err != nil {
+		return 0, err
 	}
+	return len(p), nil
 }
 
-func addAttr(m map[string]any, a slog.Attr, replace func([]string, slog.Attr) slog.Attr) {
-	if replace != nil {
-		a = replace(nil, a)
-	}
-	if a.Key == "" {
-		return
-	}
-
-	v := a.Value.Resolve()
+// Redacted replaces the values of redacted attributes
+const Redacted = "[REDACTED]"
 
-	switch v.Kind() {
-	case slog.KindGroup:
-		attrs := v.Group()
-		if len(attrs) == 0 {
-			return
+// Redact returns a slog.HandlerOptions.ReplaceAttr function replacing the
+// values of attributes named by keys, case-insensitively, with Redacted.
+func Redact(keys ...string) func(groups []string, a slog.Attr) slog.Attr {
+	redacted := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		redacted[strings.ToLower(k)] = true
+	}
+	return func(groups []string, a slog.Attr) slog.Attr {
+		// Keys of the record itself (time, level, msg) are never redacted
+		if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey) {
+			return a
 		}
-		nested := make(map[string]any)
-		for _, ga := range attrs {
-			addAttr(nested, ga, replace)
+		if redacted[strings.ToLower(a.Key)] {
+			return slog.String(a.Key, Redacted)
 		}
-		m[a.Key] = nested
-	default:
-		m[a.Key] = v.Any()
+		return a
 	}
 }
@@ -0,0 +1,113 @@
+package slogutil
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewHandlerFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{FormatJSON, `"level":"INFO","msg":"vote","postId":"p1","poll":{"option":"o1"}}` + "\n"},
+		{FormatPretty, "  \"msg\": \"vote\",\n  \"postId\": \"p1\",\n  \"poll\": {\n    \"option\": \"o1\"\n  }\n}\n"},
+		{FormatText, `level=INFO msg=vote postId=p1 poll.option=o1` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			h, err := NewHandler(&buf, Options{Format: tt.format})
+			if err != nil {
+				t.Fatal(err)
+			}
+			slog.New(h).Info("vote", "postId", "p1", slog.Group("poll", "option", "o1"))
+			if !strings.HasSuffix(buf.String(), tt.want) {
+				t.Errorf("got %q, want suffix %q", buf.String(), tt.want)
+			}
+		})
+	}
+
+	if _, err := NewHandler(&bytes.Buffer{}, Options{Format: "xml"}); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	var buf bytes.Buffer
+	h, _ := NewHandler(&buf, Options{RedactKeys: []string{"token", "Text"}})
+	slog.New(h).Info("message sent",
+		"Token", "abc",
+		slog.Group("message", "text", "hello", "chatId", "c1"),
+	)
+
+	out := buf.String()
+	if strings.Contains(out, "abc") || strings.Contains(out, "hello") {
+		t.Errorf("secrets logged: %s", out)
+	}
+	if !strings.Contains(out, `"Token":"[REDACTED]"`) || !strings.Contains(out, `"chatId":"c1"`) {
+		t.Errorf("got %s", out)
+	}
+	if !strings.Contains(out, `"msg":"message sent"`) {
+		t.Errorf("message was redacted: %s", out)
+	}
+}
+
+func TestLevelVar(t *testing.T) {
+	var buf bytes.Buffer
+	level := new(slog.LevelVar)
+	h, _ := NewHandler(&buf, Options{Level: level})
+	logger := slog.New(h)
+
+	logger.Debug("hidden")
+	level.Set(slog.LevelDebug)
+	logger.Debug("shown")
+	if out := buf.String(); strings.Contains(out, "hidden") || !strings.Contains(out, "shown") {
+		t.Errorf("got %s", out)
+	}
+}
+
+func TestSamplingHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSamplingHandler(slog.NewTextHandler(&buf, nil), SamplingOptions{First: 2, Thereafter: 3})
+	now := time.Unix(0, 0)
+	h.sampler.now = func() time.Time { return now }
+	logger := slog.New(h).With("service", "api")
+
+	for range 8 {
+		logger.Info("http request")
+	}
+	logger.Info("other")
+	logger.Warn("http request")
+	// First 2, then the 5th and 8th; other messages and warnings are kept
+	if got := strings.Count(buf.String(), "msg=\"http request\""); got != 5 {
+		t.Errorf("logged %d http requests, want 5:\n%s", got, buf.String())
+	}
+	if !strings.Contains(buf.String(), "msg=other") {
+		t.Error("other message was sampled")
+	}
+
+	buf.Reset()
+	now = now.Add(time.Second)
+	logger.Info("http request")
+	if buf.Len() == 0 {
+		t.Error("counts were not reset after a tick")
+	}
+}
+
+func TestSamplingKeepsContext(t *testing.T) {
+	var buf bytes.Buffer
+	h, _ := NewHandler(&buf, Options{Sampling: SamplingOptions{First: 1}})
+	logger := slog.New(NewContextHandler(h))
+	ctx := WithFields(context.Background(), slog.String("requestId", "r1"))
+
+	logger.InfoContext(ctx, "http request")
+	logger.InfoContext(ctx, "http request")
+	if out := buf.String(); strings.Count(out, "\n") != 1 || !strings.Contains(out, `"requestId":"r1"`) {
+		t.Errorf("got %s", out)
+	}
+}
@@ -0,0 +1,91 @@
+package slogutil
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// SamplingOptions configures a SamplingHandler
+type SamplingOptions struct {
+	// First records with the same level and message are logged per Tick
+	First int
+	// Thereafter, every Thereafter-th record is logged; zero drops them all
+	Thereafter int
+	// Tick is the sampling window (default 1s)
+	Tick time.Duration
+}
+
+// SamplingHandler is a slog.Handler that caps high-volume records, such as
+// the access log under load. Within each tick it passes the first records of
+// each level and message, then one in Thereafter. Warnings and errors are
+// never sampled.
+type SamplingHandler struct {
+	handler slog.Handler
+	sampler *sampler
+}
+
+// sampler counts records per level and message, shared by the handlers
+// derived with WithAttrs and WithGroup
+type sampler struct {
+	opts SamplingOptions
+	now  func() time.Time
+
+	mu     sync.Mutex
+	start  time.Time
+	counts map[samplingKey]int
+}
+
+type samplingKey struct {
+	level slog.Level
+	msg   string
+}
+
+// NewSamplingHandler creates a handler sampling the records passed to h.
+func NewSamplingHandler(h slog.Handler, opts SamplingOptions) *SamplingHandler {
+	if opts.Tick <= 0 {
+		opts.Tick = time.Second
+	}
+	return &SamplingHandler{
+		handler: h,
+		sampler: &sampler{opts: opts, now: time.Now, counts: make(map[samplingKey]int)},
+	}
+}
+
+func (h *SamplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *SamplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level > slog.LevelInfo || h.sampler.allow(r.Level, r.Message) {
+		return h.handler.Handle(ctx, r)
+	}
+	return nil
+}
+
+func (h *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &SamplingHandler{handler: h.handler.WithAttrs(attrs), sampler: h.sampler}
+}
+
+func (h *SamplingHandler) WithGroup(name string) slog.Handler {
+	return &SamplingHandler{handler: h.handler.WithGroup(name), sampler: h.sampler}
+}
+
+func (s *sampler) allow(level slog.Level, msg string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now := s.now(); now.Sub(s.start) >= s.opts.Tick {
+		s.start = now
+		clear(s.counts)
+	}
+
+	key := samplingKey{level, msg}
+	s.counts[key]++
+	n := s.counts[key]
+	if n <= s.opts.First {
+		return true
+	}
+	return s.opts.Thereafter > 0 && (n-s.opts.First)%s.opts.Thereafter == 0
+}
@@ -0,0 +1,311 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Exporter sends finished spans to a backend
+type Exporter interface {
+	Export(ctx context.Context, spans []*SpanData) error
+}
+
+// queueSize bounds the spans waiting for export; more are dropped
+const queueSize = 4096
+
+// batchProcessor exports spans in batches from a background goroutine, so
+// ending a span never blocks on the network
+type batchProcessor struct {
+	exp      Exporter
+	size     int
+	interval time.Duration
+
+	queue chan *SpanData
+	flush chan chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newBatchProcessor(exp Exporter, size int, interval time.Duration) *batchProcessor {
+	p := &batchProcessor{
+		exp:      exp,
+		size:     size,
+		interval: interval,
+		queue:    make(chan *SpanData, queueSize),
+		flush:    make(chan chan struct{}),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *batchProcessor) enqueue(span *SpanData) {
+	select {
+	case p.queue <- span:
+	default:
+		// Dropping beats blocking requests when the collector is down
+	}
+}
+
+func (p *batchProcessor) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	batch := make([]*SpanData, 0, p.size)
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := p.exp.Export(ctx, batch); err != nil {
+			slog.Warn("trace export failed", "spans", len(batch), "error", err)
+		}
+		batch = make([]*SpanData, 0, p.size)
+	}
+	drain := func() {
+		for {
+			select {
+			case span := <-p.queue:
+				batch = append(batch, span)
+				if len(batch) >= p.size {
+					export()
+				}
+			default:
+				export()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case span := <-p.queue:
+			batch = append(batch, span)
+			if len(batch) >= p.size {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case flushed := <-p.flush:
+			drain()
+			close(flushed)
+		case <-p.stop:
+			drain()
+			return
+		}
+	}
+}
+
+// forceFlush exports every queued span
+func (p *batchProcessor) forceFlush(ctx context.Context) error {
+	flushed := make(chan struct{})
+	select {
+	case p.flush <- flushed:
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *batchProcessor) shutdown(ctx context.Context) error {
+	p.once.Do(func() { close(p.stop) })
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ForceFlush exports every span ended so far, e.g. in tests
+func (t *Tracer) ForceFlush(ctx context.Context) error {
+	return t.processor.forceFlush(ctx)
+}
+
+// StdoutExporter writes spans as JSON lines, for development
+type StdoutExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewStdoutExporter creates an exporter writing to w
+func NewStdoutExporter(w io.Writer) *StdoutExporter {
+	return &StdoutExporter{w: w}
+}
+
+// Export implements Exporter
+func (e *StdoutExporter) Export(_ context.Context, spans []*SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	enc := json.NewEncoder(e.w)
+	for _, s := range spans {
+		attrs := make(map[string]any, len(s.Attributes))
+		for _, a := range s.Attributes {
+			attrs[a.Key] = a.Value.Any()
+		}
+		line := map[string]any{
+			"name":       s.Name,
+			"traceId":    s.SpanContext.TraceID.String(),
+			"spanId":     s.SpanContext.SpanID.String(),
+			"kind":       s.Kind,
+			"start":      s.Start,
+			"durationMs": float64(s.End.Sub(s.Start).Microseconds()) / 1000,
+			"attributes": attrs,
+		}
+		if s.Parent.IsValid() {
+			line["parentSpanId"] = s.Parent.String()
+		}
+		if s.Status == StatusError {
+			line["error"] = s.StatusMessage
+		}
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OTLPExporter sends spans to an OpenTelemetry collector with OTLP/HTTP
+// using the JSON encoding
+type OTLPExporter struct {
+	url         string
+	serviceName string
+	client      *http.Client
+}
+
+// NewOTLPExporter creates an exporter posting to endpoint, the collector's
+// base URL such as http://localhost:4318
+func NewOTLPExporter(endpoint, serviceName string) *OTLPExporter {
+	return &OTLPExporter{
+		url:         strings.TrimSuffix(endpoint, "/") + "/v1/traces",
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Export implements Exporter
+func (e *OTLPExporter) Export(ctx context.Context, spans []*SpanData) error {
+	body, err := json.Marshal(otlpRequest(e.serviceName, spans))
+	if err != nil {
+		return fmt.Errorf("encode spans: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post spans: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("post spans: collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+// OTLP/JSON message types, following the protobuf JSON mapping with hex
+// trace and span IDs as the OTLP spec requires
+
+type otlpKeyValue struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    StatusCode `json:"code,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              Kind           `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+func otlpRequest(serviceName string, spans []*SpanData) map[string]any {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		span := otlpSpan{
+			TraceID:           s.SpanContext.TraceID.String(),
+			SpanID:            s.SpanContext.SpanID.String(),
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Attributes:        otlpAttributes(s.Attributes),
+			Status:            otlpStatus{Code: s.Status, Message: s.StatusMessage},
+		}
+		if s.Parent.IsValid() {
+			span.ParentSpanID = s.Parent.String()
+		}
+		out = append(out, span)
+	}
+
+	return map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": otlpAttributes([]slog.Attr{slog.String("service.name", serviceName)}),
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": "github.com/askme/api/pkg/trace"},
+				"spans": out,
+			}},
+		}},
+	}
+}
+
+func otlpAttributes(attrs []slog.Attr) []otlpKeyValue {
+	out := make([]otlpKeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		v := a.Value.Resolve()
+		var value map[string]any
+		switch v.Kind() {
+		case slog.KindBool:
+			value = map[string]any{"boolValue": v.Bool()}
+		case slog.KindInt64:
+			// int64 values are strings in the protobuf JSON mapping
+			value = map[string]any{"intValue": strconv.FormatInt(v.Int64(), 10)}
+		case slog.KindUint64:
+			value = map[string]any{"intValue": strconv.FormatUint(v.Uint64(), 10)}
+		case slog.KindFloat64:
+			value = map[string]any{"doubleValue": v.Float64()}
+		default:
+			value = map[string]any{"stringValue": v.String()}
+		}
+		out = append(out, otlpKeyValue{Key: a.Key, Value: value})
+	}
+	return out
+}
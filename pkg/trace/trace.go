@@ -0,0 +1,344 @@
+// Package trace provides OpenTelemetry-style distributed tracing using only
+// the standard library: W3C traceparent propagation, spans with attributes,
+// and batched export to an OTLP/HTTP collector or stdout.
+//
+// Spans are started from a context and end explicitly:
+//
+//	ctx, span := trace.Start(ctx, "post.Service.Vote")
+//	defer span.End()
+//
+// Without a tracer set with SetDefault, spans are not recorded but trace
+// context from incoming requests is still propagated, so logs keep their
+// trace IDs.
+package trace
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TraceID identifies a trace across services
+type TraceID [16]byte
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// IsValid reports whether t is not all zeros
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+// SpanID identifies a span within a trace
+type SpanID [8]byte
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// IsValid reports whether s is not all zeros
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+// SpanContext is the part of a span that crosses process boundaries
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid reports whether both IDs are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// ErrInvalidTraceparent is returned by ParseTraceparent for malformed headers
+var ErrInvalidTraceparent = errors.New("invalid traceparent")
+
+// ParseTraceparent parses a W3C traceparent header,
+// "00-<trace-id>-<parent-id>-<flags>"
+func ParseTraceparent(header string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, ErrInvalidTraceparent
+	}
+	// Version 00 has exactly four fields; later versions may append more
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc, ErrInvalidTraceparent
+	}
+
+	var flags [1]byte
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, ErrInvalidTraceparent
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, ErrInvalidTraceparent
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return sc, ErrInvalidTraceparent
+	}
+	if !sc.IsValid() || strings.ToLower(header) != header {
+		return sc, ErrInvalidTraceparent
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, nil
+}
+
+// Traceparent formats sc as a W3C traceparent header
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags)
+}
+
+// Kind is the role of a span in a trace, as in OpenTelemetry
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+)
+
+// StatusCode is the outcome of a span
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// SpanData is a finished span as exporters receive it
+type SpanData struct {
+	Name          string
+	SpanContext   SpanContext
+	Parent        SpanID
+	Kind          Kind
+	Start         time.Time
+	End           time.Time
+	Attributes    []slog.Attr
+	Status        StatusCode
+	StatusMessage string
+}
+
+// Span is an operation within a trace. A nil or unsampled span records
+// nothing, so callers never need to check.
+type Span struct {
+	tracer *Tracer
+	sc     SpanContext
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// SpanContext returns the IDs of the span, valid even when not recording
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// recording reports whether the span's data is kept
+func (s *Span) recording() bool {
+	return s != nil && s.tracer != nil && s.sc.Sampled
+}
+
+// SetName renames the span, e.g. once the route of a request is known
+func (s *Span) SetName(name string) {
+	if !s.recording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Name = name
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attrs ...slog.Attr) {
+	if !s.recording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attributes = append(s.data.Attributes, attrs...)
+}
+
+// RecordError marks the span as failed with err. A nil err is ignored.
+func (s *Span) RecordError(err error) {
+	if err == nil || !s.recording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Status = StatusError
+	s.data.StatusMessage = err.Error()
+}
+
+// End finishes the span and hands it to the exporter. Later calls are
+// ignored.
+func (s *Span) End() {
+	if !s.recording() {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	s.tracer.processor.enqueue(&data)
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a context carrying span
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the current span, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemote returns a context whose next span continues the trace
+// of a remote parent, e.g. from an incoming traceparent header
+func ContextWithRemote(ctx context.Context, sc SpanContext) context.Context {
+	return ContextWithSpan(ctx, &Span{sc: sc})
+}
+
+// TraceIDFromContext returns the hex trace ID of the current span, or ""
+func TraceIDFromContext(ctx context.Context) string {
+	sc := SpanFromContext(ctx).SpanContext()
+	if !sc.TraceID.IsValid() {
+		return ""
+	}
+	return sc.TraceID.String()
+}
+
+// Options configures a Tracer
+type Options struct {
+	// SampleRatio is the fraction of new traces recorded, from 0 to 1.
+	// Traces started upstream follow the caller's sampling decision.
+	SampleRatio float64
+	// BatchSize is the number of spans sent per export (default 512)
+	BatchSize int
+	// FlushInterval is the longest a span waits for export (default 5s)
+	FlushInterval time.Duration
+}
+
+// Tracer starts spans and exports them in batches
+type Tracer struct {
+	opts      Options
+	processor *batchProcessor
+}
+
+// NewTracer creates a tracer exporting to exp. Call Shutdown to flush
+// pending spans before exit.
+func NewTracer(exp Exporter, opts Options) *Tracer {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 512
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 5 * time.Second
+	}
+	return &Tracer{opts: opts, processor: newBatchProcessor(exp, opts.BatchSize, opts.FlushInterval)}
+}
+
+// Shutdown exports pending spans and stops the tracer
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	return t.processor.shutdown(ctx)
+}
+
+var defaultTracer atomic.Pointer[Tracer]
+
+// SetDefault sets the tracer used by Start
+func SetDefault(t *Tracer) {
+	defaultTracer.Store(t)
+}
+
+// StartOption configures a span at start
+type StartOption func(*SpanData)
+
+// WithKind sets the span kind (default KindInternal)
+func WithKind(kind Kind) StartOption {
+	return func(d *SpanData) { d.Kind = kind }
+}
+
+// WithAttributes sets attributes at start
+func WithAttributes(attrs ...slog.Attr) StartOption {
+	return func(d *SpanData) { d.Attributes = append(d.Attributes, attrs...) }
+}
+
+// Start starts a span as a child of the current span in ctx, or a new
+// trace, using the default tracer
+func Start(ctx context.Context, name string, opts ...StartOption) (context.Context, *Span) {
+	return defaultTracer.Load().Start(ctx, name, opts...)
+}
+
+// Start starts a span as a child of the current span in ctx, or a new
+// trace. A nil tracer only propagates the parent's trace.
+func (t *Tracer) Start(ctx context.Context, name string, opts ...StartOption) (context.Context, *Span) {
+	parent := SpanFromContext(ctx).SpanContext()
+	if t == nil {
+		return ctx, nil
+	}
+
+	sc := SpanContext{TraceID: parent.TraceID, Sampled: parent.Sampled}
+	if !parent.IsValid() {
+		sc.TraceID = newTraceID()
+		sc.Sampled = rand.Float64() < t.opts.SampleRatio
+	}
+	sc.SpanID = newSpanID()
+
+	span := &Span{tracer: t, sc: sc}
+	span.data = SpanData{Name: name, SpanContext: sc, Parent: parent.SpanID, Kind: KindInternal, Start: time.Now()}
+	for _, opt := range opts {
+		opt(&span.data)
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		putUint64(id[:8], rand.Uint64())
+		putUint64(id[8:], rand.Uint64())
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		putUint64(id[:], rand.Uint64())
+	}
+	return id
+}
+
+func putUint64(b []byte, v uint64) {
+	for i := range 8 {
+		b[i] = byte(v >> (56 - 8*i))
+	}
+}
@@ -0,0 +1,161 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		header  string
+		valid   bool
+		sampled bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", true, true},
+		{"", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01", false, false},
+	}
+	for _, tt := range tests {
+		sc, err := ParseTraceparent(tt.header)
+		if (err == nil) != tt.valid || sc.Sampled != tt.sampled {
+			t.Errorf("ParseTraceparent(%q) = %+v, %v", tt.header, sc, err)
+		}
+	}
+
+	header := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	if sc, _ := ParseTraceparent(header); sc.Traceparent() != header {
+		t.Errorf("round trip = %q", sc.Traceparent())
+	}
+}
+
+// recorder is an Exporter keeping spans in memory
+type recorder struct {
+	mu    sync.Mutex
+	spans []*SpanData
+}
+
+func (r *recorder) Export(_ context.Context, spans []*SpanData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func TestTracer(t *testing.T) {
+	exp := &recorder{}
+	tracer := NewTracer(exp, Options{SampleRatio: 1})
+
+	remote, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := ContextWithRemote(context.Background(), remote)
+
+	ctx, parent := tracer.Start(ctx, "request", WithKind(KindServer))
+	_, child := tracer.Start(ctx, "query", WithAttributes(slog.String("db.query.name", "post.GetPostByID")))
+	child.RecordError(errors.New("boom"))
+	child.End()
+	parent.SetName("GET /posts/{postId}")
+	parent.End()
+	parent.End()
+
+	if TraceIDFromContext(ctx) != remote.TraceID.String() {
+		t.Errorf("trace ID = %s, want the remote one", TraceIDFromContext(ctx))
+	}
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(exp.spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(exp.spans))
+	}
+	q, req := exp.spans[0], exp.spans[1]
+	if req.Name != "GET /posts/{postId}" || req.Parent != remote.SpanID || req.Kind != KindServer {
+		t.Errorf("request span = %+v", req)
+	}
+	if q.Parent != req.SpanContext.SpanID || q.SpanContext.TraceID != remote.TraceID {
+		t.Errorf("query span is not a child of the request span: %+v", q)
+	}
+	if q.Status != StatusError || q.StatusMessage != "boom" || q.Attributes[0].Value.String() != "post.GetPostByID" {
+		t.Errorf("query span = %+v", q)
+	}
+}
+
+func TestUnsampled(t *testing.T) {
+	exp := &recorder{}
+	tracer := NewTracer(exp, Options{SampleRatio: 0})
+
+	ctx, span := tracer.Start(context.Background(), "request")
+	span.End()
+	tracer.Shutdown(context.Background())
+
+	if len(exp.spans) != 0 {
+		t.Errorf("exported %d unsampled spans", len(exp.spans))
+	}
+	// Unsampled traces still have IDs to log and propagate
+	if TraceIDFromContext(ctx) == "" {
+		t.Error("unsampled span has no trace ID")
+	}
+}
+
+func TestNoTracer(t *testing.T) {
+	remote, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := ContextWithRemote(context.Background(), remote)
+
+	ctx, span := Start(ctx, "request")
+	span.SetAttributes(slog.Int("n", 1))
+	span.End()
+	if TraceIDFromContext(ctx) != remote.TraceID.String() {
+		t.Error("trace context was not propagated without a tracer")
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var got struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID    string `json:"traceId"`
+					Name       string `json:"name"`
+					Attributes []struct {
+						Key   string         `json:"key"`
+						Value map[string]any `json:"value"`
+					} `json:"attributes"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("request to %s with %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer collector.Close()
+
+	tracer := NewTracer(NewOTLPExporter(collector.URL+"/", "askme-test"), Options{SampleRatio: 1})
+	_, span := tracer.Start(context.Background(), "query", WithAttributes(slog.Int("rows", 3)))
+	span.End()
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := got.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 1 || spans[0].Name != "query" || len(spans[0].TraceID) != 32 {
+		t.Fatalf("collector got %+v", spans)
+	}
+	if attr := spans[0].Attributes[0]; attr.Key != "rows" || attr.Value["intValue"] != "3" {
+		t.Errorf("attribute = %+v", attr)
+	}
+}
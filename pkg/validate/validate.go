@@ -0,0 +1,224 @@
+// Package validate checks request structs against struct-tag rules and
+// Validate methods using only the standard library.
+//
+// Rules are set in a `validate` tag and apply to the field's JSON name:
+//
+//	Text    string   `json:"text" validate:"required,max=2000"`
+//	Options []string `json:"options" validate:"min=2,max=10"`
+//	Type    string   `json:"type" validate:"oneof=direct group"`
+//
+// The rules are:
+//
+//   - required: not the zero value; slices and maps must be non-empty
+//   - min=N, max=N: bounds on string length in characters, on slice and map
+//     length, or on a number's value. min skips empty values, so optional
+//     fields stay optional; add required to insist on them.
+//   - oneof=a b c: a string must be one of the listed values when set
+//
+// Rules that span fields belong in a Validate method on the struct.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError describes why a single field is invalid.
+type FieldError struct {
+	// Field is the JSON path of the field, e.g. "aiRaw.category"
+	Field string `json:"field"`
+	// Code is a machine-readable reason such as "required" or "max"
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors is a list of field errors. It is returned as an error.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Field returns a single field error, for rules checked outside of tags.
+func Field(field, code, message string) Errors {
+	return Errors{{Field: field, Code: code, Message: message}}
+}
+
+// Validator is implemented by structs with rules that span fields. Validate
+// returns Errors, or nil when the value is valid.
+type Validator interface {
+	Validate() error
+}
+
+// Rule is a single parsed rule of a validate tag.
+type Rule struct {
+	Name string
+	Arg  string
+}
+
+// ParseTag parses a validate tag such as "required,max=280".
+func ParseTag(tag string) []Rule {
+	if tag == "" {
+		return nil
+	}
+	var rules []Rule
+	for _, part := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		rules = append(rules, Rule{Name: name, Arg: arg})
+	}
+	return rules
+}
+
+// Struct checks the tag rules of v, a struct or a pointer to one, and of
+// its nested structs, then calls its Validate method. It returns Errors for
+// invalid input; any other error means a malformed tag.
+func Struct(v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("validate: %T is not a struct", v)
+	}
+
+	var errs Errors
+	if err := checkStruct(rv, "", &errs); err != nil {
+		return err
+	}
+
+	if validator, ok := v.(Validator); ok {
+		err := validator.Validate()
+		var fieldErrs Errors
+		switch {
+		case errors.As(err, &fieldErrs):
+			errs = append(errs, fieldErrs...)
+		case err != nil:
+			return err
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func checkStruct(rv reflect.Value, prefix string, errs *Errors) error {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := jsonName(f)
+		if name == "-" {
+			continue
+		}
+		path := prefix + name
+		fv := rv.Field(i)
+
+		for _, rule := range ParseTag(f.Tag.Get("validate")) {
+			fe, err := check(fv, path, rule)
+			if err != nil {
+				return fmt.Errorf("validate: field %s: %w", path, err)
+			}
+			if fe != nil {
+				*errs = append(*errs, *fe)
+				// Later rules of the field would only repeat the problem
+				break
+			}
+		}
+
+		for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Struct {
+			if err := checkStruct(fv, path+".", errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonName returns the name a field is decoded from
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+func check(v reflect.Value, field string, rule Rule) (*FieldError, error) {
+	switch rule.Name {
+	case "required":
+		if v.IsZero() || (isCollection(v) && v.Len() == 0) {
+			return &FieldError{Field: field, Code: "required", Message: field + " is required"}, nil
+		}
+	case "min", "max":
+		bound, err := strconv.ParseFloat(rule.Arg, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad %s bound %q", rule.Name, rule.Arg)
+		}
+		size, unit, ok := measure(v)
+		if !ok {
+			return nil, fmt.Errorf("%s does not apply to %s", rule.Name, v.Kind())
+		}
+		if rule.Name == "min" && size < bound && !v.IsZero() {
+			return &FieldError{Field: field, Code: "min", Message: fmt.Sprintf("%s must be at least %s%s", field, rule.Arg, unit)}, nil
+		}
+		if rule.Name == "max" && size > bound {
+			return &FieldError{Field: field, Code: "max", Message: fmt.Sprintf("%s must be at most %s%s", field, rule.Arg, unit)}, nil
+		}
+	case "oneof":
+		if v.Kind() != reflect.String {
+			return nil, fmt.Errorf("oneof does not apply to %s", v.Kind())
+		}
+		values := strings.Fields(rule.Arg)
+		if s := v.String(); s != "" && !slices.Contains(values, s) {
+			return &FieldError{Field: field, Code: "oneof", Message: fmt.Sprintf("%s must be one of: %s", field, strings.Join(values, ", "))}, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown rule %q", rule.Name)
+	}
+	return nil, nil
+}
+
+func isCollection(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return true
+	}
+	return false
+}
+
+// measure returns what min and max compare against, with the unit used in
+// messages
+func measure(v reflect.Value) (float64, string, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), " characters", true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), " items", true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), "", true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), "", true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), "", true
+	}
+	return 0, "", false
+}
@@ -0,0 +1,86 @@
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type inner struct {
+	Label string `json:"label" validate:"max=3"`
+}
+
+type request struct {
+	Name    string            `json:"name" validate:"required,max=5"`
+	Tags    []string          `json:"tags,omitempty" validate:"min=2,max=3"`
+	Kind    string            `json:"kind,omitempty" validate:"oneof=a b"`
+	Score   int               `json:"score,omitempty" validate:"max=10"`
+	Meta    map[string]string `json:"meta,omitempty" validate:"required"`
+	Nested  inner             `json:"nested"`
+	Pointer *inner            `json:"pointer,omitempty"`
+	Skipped string            `json:"-" validate:"required"`
+}
+
+func (r *request) Validate() error {
+	if r.Kind == "b" && r.Score == 0 {
+		return Field("score", "required", "score is required for kind b")
+	}
+	return nil
+}
+
+func TestStruct(t *testing.T) {
+	valid := func() *request {
+		return &request{Name: "ok", Meta: map[string]string{"k": "v"}}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*request)
+		want   []string // field:code pairs
+	}{
+		{"valid", func(*request) {}, nil},
+		{"required", func(r *request) { r.Name, r.Meta = "", map[string]string{} }, []string{"name:required", "meta:required"}},
+		{"string length counts characters", func(r *request) { r.Name = "héllo" }, nil},
+		{"too long", func(r *request) { r.Name = "toolong" }, []string{"name:max"}},
+		{"min skips empty", func(r *request) { r.Tags = nil }, nil},
+		{"too few", func(r *request) { r.Tags = []string{"a"} }, []string{"tags:min"}},
+		{"too many", func(r *request) { r.Tags = []string{"a", "b", "c", "d"} }, []string{"tags:max"}},
+		{"oneof", func(r *request) { r.Kind = "c" }, []string{"kind:oneof"}},
+		{"number bound", func(r *request) { r.Score = 11 }, []string{"score:max"}},
+		{"nested", func(r *request) { r.Nested.Label = "long" }, []string{"nested.label:max"}},
+		{"pointer", func(r *request) { r.Pointer = &inner{Label: "long"} }, []string{"pointer.label:max"}},
+		{"method", func(r *request) { r.Kind = "b" }, []string{"score:required"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := valid()
+			tt.modify(r)
+			err := Struct(r)
+
+			var got []string
+			var errs Errors
+			if errors.As(err, &errs) {
+				for _, fe := range errs {
+					got = append(got, fe.Field+":"+fe.Code)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructMalformedTag(t *testing.T) {
+	var v struct {
+		Flag bool `json:"flag" validate:"max=1"`
+	}
+	err := Struct(&v)
+	var errs Errors
+	if err == nil || errors.As(err, &errs) || !strings.Contains(err.Error(), "flag") {
+		t.Fatalf("got %v, want a tag error naming the field", err)
+	}
+}